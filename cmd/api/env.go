@@ -1,15 +1,38 @@
 package main
 
 import (
+	"time"
+
 	"github.com/joho/godotenv"
 	"github.com/kelseyhightower/envconfig"
 )
 
 type config struct {
-	Env         string `default:"dev"`
-	APIPort     int    `default:3000`
-	LogLevel    string `default:"debug"`
-	DMLInitFile string
+	Env                   string `default:"dev"`
+	APIPort               int    `default:"3000"`
+	LogLevel              string `default:"debug"`
+	DMLInitFile           string
+	RatesFile             string
+	WALFile               string
+	WALSync               string        `default:"always"`
+	WALSyncInterval       time.Duration `default:"1s"`
+	SnapshotFile          string
+	BackupDir             string
+	BackupInterval        time.Duration `default:"24h"`
+	PriceScheduleInterval time.Duration `default:"1m"`
+	SalesTaxRate          string        `default:"0"`
+	WebhookInterval       time.Duration `default:"10s"`
+	WebhookTimeout        time.Duration `default:"10s"`
+	WebhookRetention      time.Duration `default:"168h"`
+	BootstrapKeyFile      string
+	JWTIssuer             string
+	JWTAudience           string
+	JWKSFile              string
+	JWTPublicKeyFile      string
+	JWTHMACSecret         string
+	JWTRoleClaim          string `default:"roles"`
+	JWTRoleMap            map[string]string
+	JWTLeeway             time.Duration `default:"1m"`
 }
 
 func load() (cfg config, err error) {
@@ -3,15 +3,27 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"log"
+	nethttp "net/http"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"syscall"
 	"time"
 
+	"github.com/davidlick/supermarket-api/internal/auth"
+	"github.com/davidlick/supermarket-api/internal/checkout"
+	"github.com/davidlick/supermarket-api/internal/exchange"
 	"github.com/davidlick/supermarket-api/internal/http"
+	"github.com/davidlick/supermarket-api/internal/inventory"
+	"github.com/davidlick/supermarket-api/internal/prices"
 	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/davidlick/supermarket-api/internal/promotions"
+	"github.com/davidlick/supermarket-api/internal/stores"
+	"github.com/davidlick/supermarket-api/internal/webhooks"
 	"github.com/davidlick/supermarket-api/pkg/ramdb"
 	"github.com/sirupsen/logrus"
 )
@@ -39,15 +51,273 @@ func init() {
 
 func main() {
 	db := ramdb.NewDatabase()
-	err = db.CreateTable("produce", produce.KeyProduceCode)
-	if err != nil {
+	if cfg.WALFile != "" {
+		policy, err := ramdb.ParseSyncPolicy(cfg.WALSync)
+		if err != nil {
+			logger.Fatal(err)
+		}
+
+		db, err = ramdb.OpenDatabase(cfg.WALFile, policy, cfg.WALSyncInterval)
+		if err != nil {
+			logger.Fatalf("could not open write-ahead log %s: %v", cfg.WALFile, err)
+		}
+	} else {
+		logger.Info("no write-ahead log file provided, database will not be persisted")
+	}
+	defer db.Close()
+
+	if cfg.SnapshotFile != "" && !db.HasTable("produce") {
+		f, err := os.Open(cfg.SnapshotFile)
+		if err != nil {
+			logger.Fatalf("could not open snapshot: %s", cfg.SnapshotFile)
+		}
+
+		err = db.Restore(f)
+		f.Close()
+		if err != nil {
+			logger.Fatalf("could not restore snapshot %s: %v", cfg.SnapshotFile, err)
+		}
+	}
+
+	err = db.CreateTable("produce")
+	restored := errors.Is(err, ramdb.ErrTableExists)
+	if err != nil && !restored {
 		logger.Fatal(err)
 	}
 
+	if !restored {
+		// Every index is ordered so produce can be listed sorted by any of them.
+		for _, column := range []string{produce.KeyProduceCode, produce.KeyProduceName, produce.KeyProducePrice} {
+			err = db.From("produce").CreateOrderedIndex(column)
+			if err != nil {
+				logger.Fatal(err)
+			}
+		}
+
+		err = db.From("produce").CreateTextIndex(produce.KeyProduceSearch)
+		if err != nil {
+			logger.Fatal(err)
+		}
+	}
+
 	produceSvc := produce.NewService(db.From("produce"))
-	initProduce(produceSvc)
+	if restored && !db.From("produce").HasIndex(produce.KeyProduceSearch) {
+		// Produce restored from before search existed is stored without search keys, so it is reindexed before the
+		// search index is created from it.
+		err = produceSvc.Reindex()
+		if err != nil {
+			logger.Fatal(err)
+		}
+
+		err = db.From("produce").CreateTextIndex(produce.KeyProduceSearch)
+		if err != nil {
+			logger.Fatal(err)
+		}
+
+		logger.Info("created search index on restored produce table")
+	}
+
+	if restored {
+		logger.Info("produce table restored, skipping init file")
+	} else {
+		initProduce(produceSvc)
+	}
 
-	server := http.NewServer(cfg.APIPort, logger, cfg.Env, produceSvc)
+	err = db.CreateTable("prices")
+	if err != nil && !errors.Is(err, ramdb.ErrTableExists) {
+		logger.Fatal(err)
+	}
+
+	if err == nil {
+		for _, column := range []string{prices.KeyPriceID, prices.KeyPriceDue} {
+			err = db.From("prices").CreateOrderedIndex(column)
+			if err != nil {
+				logger.Fatal(err)
+			}
+		}
+	}
+
+	priceSvc := prices.NewService(db.From("prices"), produceSvc)
+
+	// Apply scheduled price changes until the server shuts down.
+	schedulerCtx, stopScheduler := context.WithCancel(context.Background())
+	defer stopScheduler()
+	go priceSvc.Run(schedulerCtx, cfg.PriceScheduleInterval, logger)
+
+	err = db.CreateTable("promotions")
+	if err != nil && !errors.Is(err, ramdb.ErrTableExists) {
+		logger.Fatal(err)
+	}
+
+	if err == nil {
+		err = db.From("promotions").CreateOrderedIndex(promotions.KeyPromotionID)
+		if err != nil {
+			logger.Fatal(err)
+		}
+
+		err = db.From("promotions").CreateIndex(promotions.KeyPromotionCode)
+		if err != nil {
+			logger.Fatal(err)
+		}
+	}
+
+	promoSvc := promotions.NewService(db.From("promotions"))
+
+	err = db.CreateTable("rates")
+	if err != nil && !errors.Is(err, ramdb.ErrTableExists) {
+		logger.Fatal(err)
+	}
+
+	rateSvc := exchange.NewService(db.From("rates"))
+	if err == nil {
+		err = db.From("rates").CreateOrderedIndex(exchange.KeyRatePair)
+		if err != nil {
+			logger.Fatal(err)
+		}
+
+		initRates(rateSvc)
+	} else {
+		logger.Info("rates table restored, skipping rates file")
+	}
+
+	err = db.CreateTable("inventory")
+	if err != nil && !errors.Is(err, ramdb.ErrTableExists) {
+		logger.Fatal(err)
+	}
+
+	if err == nil {
+		err = db.From("inventory").CreateOrderedIndex(inventory.KeyStockCode)
+		if err != nil {
+			logger.Fatal(err)
+		}
+	}
+
+	stockSvc := inventory.NewService(db.From("inventory"), produceSvc)
+	produceSvc.AddDependent(stockSvc)
+
+	// Stores and their overrides of the catalogue are kept apart, so a store's overrides can be scanned by prefix.
+	for table, column := range map[string]string{"stores": stores.KeyStoreID, "store_produce": stores.KeyOverrideID} {
+		err = db.CreateTable(table)
+		if err != nil && !errors.Is(err, ramdb.ErrTableExists) {
+			logger.Fatal(err)
+		}
+
+		if err == nil {
+			err = db.From(table).CreateOrderedIndex(column)
+			if err != nil {
+				logger.Fatal(err)
+			}
+		}
+	}
+
+	storeSvc := stores.NewService(db.From("stores"), db.From("store_produce"), produceSvc)
+	produceSvc.AddDependent(storeSvc)
+
+	// Deliveries are indexed by when they are next due as well as by ID, so retries don't scan delivered ones.
+	for table, columns := range map[string][]string{
+		"webhooks":           {webhooks.KeySubscriptionID},
+		"webhook_deliveries": {webhooks.KeyDeliveryID, webhooks.KeyDeliveryDue},
+	} {
+		err = db.CreateTable(table)
+		if err != nil && !errors.Is(err, ramdb.ErrTableExists) {
+			logger.Fatal(err)
+		}
+
+		if err == nil {
+			for _, column := range columns {
+				err = db.From(table).CreateOrderedIndex(column)
+				if err != nil {
+					logger.Fatal(err)
+				}
+			}
+		}
+	}
+
+	webhookSvc := webhooks.NewService(db.From("webhooks"), db.From("webhook_deliveries"), produceSvc,
+		&nethttp.Client{Timeout: cfg.WebhookTimeout})
+
+	// Send catalogue changes to webhooks, retry failed deliveries and prune old ones until the server shuts down.
+	go webhookSvc.Run(schedulerCtx, cfg.WebhookInterval, cfg.WebhookRetention, logger)
+
+	err = db.CreateTable("api_keys")
+	if err != nil && !errors.Is(err, ramdb.ErrTableExists) {
+		logger.Fatal(err)
+	}
+
+	if err == nil {
+		err = db.From("api_keys").CreateOrderedIndex(auth.KeyAPIKeyID)
+		if err != nil {
+			logger.Fatal(err)
+		}
+	}
+
+	keySvc := auth.NewService(db.From("api_keys"))
+
+	// Without an admin key no other key can be created, so one is made. Its token is written to the bootstrap key file,
+	// or printed once to stdout, but never logged.
+	bootstrap, created, err := keySvc.Bootstrap()
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	if created {
+		if cfg.BootstrapKeyFile != "" {
+			err = writeBootstrapKey(cfg.BootstrapKeyFile, bootstrap.Token)
+			if err != nil {
+				logger.Fatalf("could not write bootstrap API key to %s: %v", cfg.BootstrapKeyFile, err)
+			}
+
+			logger.Warnf("created admin API key %s, its token was written to %s", bootstrap.ID, cfg.BootstrapKeyFile)
+		} else {
+			fmt.Fprintf(os.Stdout, "admin API key token, store it now as it will not be shown again: %s\n", bootstrap.Token)
+			logger.Warnf("created admin API key %s, its token was printed to stdout", bootstrap.ID)
+		}
+	}
+
+	taxRate, err := checkout.ParseTaxRate(cfg.SalesTaxRate)
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	checkoutSvc := checkout.NewService(produceSvc, promoSvc, taxRate)
+
+	if cfg.BackupDir != "" {
+		backups := time.NewTicker(cfg.BackupInterval)
+		defer backups.Stop()
+
+		go func() {
+			for now := range backups.C {
+				path := filepath.Join(cfg.BackupDir, fmt.Sprintf("ramdb-%s.snapshot", now.UTC().Format("20060102T150405Z")))
+				err := db.SnapshotFile(path)
+				if err != nil {
+					logger.Errorf("could not write backup %s: %v", path, err)
+					continue
+				}
+
+				logger.Infof("wrote backup %s", path)
+			}
+		}()
+	}
+
+	opts := []http.ServerOption{
+		http.WithPriceService(priceSvc),
+		http.WithCheckoutService(checkoutSvc),
+		http.WithPromotionService(promoSvc),
+		http.WithRateService(rateSvc),
+		http.WithInventoryService(stockSvc),
+		http.WithStoreService(storeSvc),
+		http.WithWebhookService(webhookSvc),
+		http.WithKeyService(keySvc),
+	}
+
+	// Bearer tokens from the company's SSO are accepted alongside API keys once an issuer is configured.
+	if cfg.JWTIssuer != "" {
+		opts = append(opts, http.WithTokenVerifier(newTokenVerifier()))
+	} else {
+		logger.Info("no JWT issuer provided, only API keys will be accepted")
+	}
+
+	server := http.NewServer(cfg.APIPort, logger, cfg.Env, produceSvc, opts...)
 
 	// Allow app to listen for OS Interrupts and SIGTERMS.
 	serverErrors := make(chan error, 1)
@@ -74,6 +344,29 @@ func main() {
 	}
 }
 
+// writeBootstrapKey writes the token of the bootstrap admin key to path, replacing the file, which is only readable and
+// writable by its owner.
+func writeBootstrapKey(path, token string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// The file may have existed with wider permissions, so they are narrowed before the token is written.
+	err = f.Chmod(0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = f.WriteString(token + "\n")
+	if err != nil {
+		return err
+	}
+
+	return f.Close()
+}
+
 func initProduce(produceSvc http.ProduceService) {
 	if cfg.DMLInitFile == "" {
 		logger.Info("no init file provided, database will be empty")
@@ -101,3 +394,78 @@ func initProduce(produceSvc http.ProduceService) {
 		logger.Fatal("failed to add items to produce service")
 	}
 }
+
+func newTokenVerifier() http.TokenVerifier {
+	var keys []auth.VerificationKey
+
+	if cfg.JWKSFile != "" {
+		b, err := ioutil.ReadFile(cfg.JWKSFile)
+		if err != nil {
+			logger.Fatalf("could not read file: %s", cfg.JWKSFile)
+		}
+
+		jwks, err := auth.ParseJWKS(b)
+		if err != nil {
+			logger.Fatalf("could not parse JWKS %s: %v", cfg.JWKSFile, err)
+		}
+
+		keys = append(keys, jwks...)
+	}
+
+	if cfg.JWTPublicKeyFile != "" {
+		b, err := ioutil.ReadFile(cfg.JWTPublicKeyFile)
+		if err != nil {
+			logger.Fatalf("could not read file: %s", cfg.JWTPublicKeyFile)
+		}
+
+		pems, err := auth.ParsePublicKeys(b)
+		if err != nil {
+			logger.Fatalf("could not parse public keys %s: %v", cfg.JWTPublicKeyFile, err)
+		}
+
+		keys = append(keys, pems...)
+	}
+
+	if cfg.JWTHMACSecret != "" {
+		keys = append(keys, auth.VerificationKey{Algorithm: auth.AlgHS256, Key: []byte(cfg.JWTHMACSecret)})
+	}
+
+	verifier, err := auth.NewVerifier(keys, auth.VerifierConfig{
+		Issuer:    cfg.JWTIssuer,
+		Audience:  cfg.JWTAudience,
+		RoleClaim: cfg.JWTRoleClaim,
+		RoleMap:   cfg.JWTRoleMap,
+		Leeway:    cfg.JWTLeeway,
+	})
+	if err != nil {
+		logger.Fatal(err)
+	}
+
+	logger.Infof("accepting JWTs issued by %s for %s signed by %d keys", cfg.JWTIssuer, cfg.JWTAudience, len(keys))
+	return verifier
+}
+
+func initRates(rateSvc http.RateService) {
+	if cfg.RatesFile == "" {
+		logger.Info("no rates file provided, prices can't be converted until rates are set")
+		return
+	}
+
+	b, err := ioutil.ReadFile(cfg.RatesFile)
+	if err != nil {
+		logger.Fatalf("could not read file: %s", cfg.RatesFile)
+	}
+
+	var rates []exchange.Rate
+	err = json.Unmarshal(b, &rates)
+	if err != nil {
+		logger.Fatalf("could not unmarshal rates: %v", err)
+	}
+
+	for _, rate := range rates {
+		_, err = rateSvc.Set(rate)
+		if err != nil {
+			logger.Fatalf("could not set rate from %s to %s: %v", rate.From, rate.To, err)
+		}
+	}
+}
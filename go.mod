@@ -3,12 +3,12 @@ module github.com/davidlick/supermarket-api
 go 1.16
 
 require (
-	github.com/Rhymond/go-money v1.0.2 // indirect
-	github.com/go-chi/chi v1.5.4 // indirect
-	github.com/golang/mock v1.6.0 // indirect
-	github.com/google/btree v1.0.1 // indirect
-	github.com/joho/godotenv v1.3.0 // indirect
-	github.com/kelseyhightower/envconfig v1.4.0 // indirect
-	github.com/sirupsen/logrus v1.8.1 // indirect
-	github.com/stretchr/testify v1.7.0 // indirect
+	github.com/Rhymond/go-money v1.0.2
+	github.com/go-chi/chi v1.5.4
+	github.com/golang/mock v1.6.0
+	github.com/google/btree v1.0.1
+	github.com/joho/godotenv v1.3.0
+	github.com/kelseyhightower/envconfig v1.4.0
+	github.com/sirupsen/logrus v1.8.1
+	github.com/stretchr/testify v1.7.0
 )
@@ -0,0 +1,25 @@
+package auth
+
+const (
+	KeyAPIKeyID = "api_key_id"
+)
+
+const (
+	// RoleReader can read the catalogue and everything derived from it, and price baskets at checkout.
+	RoleReader = "reader"
+	// RolePricingEditor can do everything a reader can, and change produce, prices, promotions, exchange rates, stock
+	// and the produce sold at stores.
+	RolePricingEditor = "pricing-editor"
+	// RoleAdmin can do everything, including managing stores, webhooks and API keys.
+	RoleAdmin = "admin"
+)
+
+// roleRanks orders the roles, each allowed everything the roles ranked below it are.
+var roleRanks = map[string]int{
+	RoleReader:        1,
+	RolePricingEditor: 2,
+	RoleAdmin:         3,
+}
+
+// tokenPrefix begins every API key token, so keys can be told apart from other bearer tokens and found by scanners.
+const tokenPrefix = "sk_"
@@ -0,0 +1,12 @@
+package auth
+
+import "errors"
+
+var (
+	ErrInvalidKey   = errors.New("invalid API key")
+	ErrInvalidToken = errors.New("invalid bearer token")
+	ErrKeyRevoked   = errors.New("API key is revoked")
+
+	ErrInvalidVerifier = errors.New("invalid token verifier configuration")
+	ErrInvalidKeySet   = errors.New("invalid verification keys")
+)
@@ -0,0 +1,168 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+)
+
+// jwk is a JSON Web Key, with the fields of RSA, EC and symmetric keys.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	K   string `json:"k"`
+}
+
+// ParseJWKS returns the verification keys in a JSON Web Key Set, such as the one an identity provider publishes at
+// its jwks_uri. Keys for encryption or for algorithms other than RS256, ES256 and HS256 are skipped. It returns an
+// error matching ErrInvalidKeySet if the set is malformed or has no keys that can be used.
+func ParseJWKS(b []byte) ([]VerificationKey, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	err := json.Unmarshal(b, &set)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidKeySet, err)
+	}
+
+	var keys []VerificationKey
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		key, err := k.verificationKey()
+		if err != nil {
+			return nil, fmt.Errorf("%w: key %q: %v", ErrInvalidKeySet, k.Kid, err)
+		}
+
+		if key.Algorithm == "" || (k.Alg != "" && k.Alg != key.Algorithm) {
+			continue
+		}
+
+		keys = append(keys, key)
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: no RS256, ES256 or HS256 signing keys", ErrInvalidKeySet)
+	}
+
+	return keys, nil
+}
+
+// verificationKey returns k as a VerificationKey, with no algorithm if k is a type of key that isn't supported.
+func (k jwk) verificationKey() (VerificationKey, error) {
+	key := VerificationKey{ID: k.Kid}
+
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return key, err
+		}
+
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return key, err
+		}
+
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return key, fmt.Errorf("exponent is too large")
+		}
+
+		key.Algorithm = AlgRS256
+		key.Key = &rsa.PublicKey{N: n, E: int(e.Int64())}
+	case "EC":
+		if k.Crv != "P-256" {
+			return key, nil
+		}
+
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return key, err
+		}
+
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return key, err
+		}
+
+		if !elliptic.P256().IsOnCurve(x, y) {
+			return key, fmt.Errorf("point is not on P-256")
+		}
+
+		key.Algorithm = AlgES256
+		key.Key = &ecdsa.PublicKey{Curve: elliptic.P256(), X: x, Y: y}
+	case "oct":
+		secret, err := base64.RawURLEncoding.DecodeString(k.K)
+		if err != nil {
+			return key, err
+		}
+
+		key.Algorithm = AlgHS256
+		key.Key = secret
+	}
+
+	return key, nil
+}
+
+// ParsePublicKeys returns the RSA and P-256 EC public keys in PEM encoded data, as RS256 and ES256 verification keys
+// without IDs. It returns an error matching ErrInvalidKeySet if a block isn't a public key of either kind, or there are
+// none.
+func ParsePublicKeys(b []byte) ([]VerificationKey, error) {
+	var keys []VerificationKey
+	for {
+		var block *pem.Block
+		block, b = pem.Decode(b)
+		if block == nil {
+			break
+		}
+
+		pub, err := x509.ParsePKIXPublicKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidKeySet, err)
+		}
+
+		switch k := pub.(type) {
+		case *rsa.PublicKey:
+			keys = append(keys, VerificationKey{Algorithm: AlgRS256, Key: k})
+		case *ecdsa.PublicKey:
+			keys = append(keys, VerificationKey{Algorithm: AlgES256, Key: k})
+		default:
+			return nil, fmt.Errorf("%w: unsupported public key type %T", ErrInvalidKeySet, pub)
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: no PEM encoded public keys", ErrInvalidKeySet)
+	}
+
+	return keys, nil
+}
+
+// decodeInt decodes a base64url encoded big-endian unsigned integer.
+func decodeInt(s string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(b) == 0 {
+		return nil, fmt.Errorf("integer is empty")
+	}
+
+	return new(big.Int).SetBytes(b), nil
+}
@@ -0,0 +1,317 @@
+package auth
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	AlgRS256 = "RS256"
+	AlgES256 = "ES256"
+	AlgHS256 = "HS256"
+)
+
+const (
+	// DefaultRoleClaim is the claim a token's roles are read from when VerifierConfig.RoleClaim is empty.
+	DefaultRoleClaim = "roles"
+	// DefaultLeeway is how far clocks may disagree when VerifierConfig.Leeway is 0.
+	DefaultLeeway = time.Minute
+)
+
+// VerifierConfig configures the tokens a verifier accepts and how their claims are mapped to identities.
+type VerifierConfig struct {
+	// Issuer is the iss every token must have.
+	Issuer string
+	// Audience is a value the aud of every token must have.
+	Audience string
+	// RoleClaim is the claim holding a token's roles or groups, as a string or an array of strings. Claims nested in
+	// objects are named by their path, such as "realm_access.roles".
+	RoleClaim string
+	// RoleMap maps the values of RoleClaim to roles, and values it doesn't map are ignored. Without a RoleMap, values
+	// that are role names are used as they are.
+	RoleMap map[string]string
+	// Leeway is how long after it expires, or before it is valid, a token is still accepted, to allow for clock skew.
+	Leeway time.Duration
+}
+
+// VerificationKey is a key tokens are verified with: an *rsa.PublicKey for RS256, an *ecdsa.PublicKey on P-256 for
+// ES256, or a []byte secret for HS256.
+type VerificationKey struct {
+	// ID is matched against the kid of a token's header. Keys without one are tried for every token using Algorithm.
+	ID        string
+	Algorithm string
+	Key       interface{}
+}
+
+type verifier struct {
+	config VerifierConfig
+	keys   []VerificationKey
+	now    func() time.Time
+}
+
+// NewVerifier creates a new verifier of tokens signed by one of keys and issued by config.Issuer for config.Audience.
+// It returns an error matching ErrInvalidVerifier if the issuer, audience or keys are missing, or a key doesn't suit
+// its algorithm.
+func NewVerifier(keys []VerificationKey, config VerifierConfig) (*verifier, error) {
+	if config.Issuer == "" || config.Audience == "" {
+		return nil, fmt.Errorf("%w: issuer and audience are required", ErrInvalidVerifier)
+	}
+
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("%w: at least one key is required", ErrInvalidVerifier)
+	}
+
+	for _, key := range keys {
+		err := checkKey(key)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if config.RoleClaim == "" {
+		config.RoleClaim = DefaultRoleClaim
+	}
+	if config.Leeway == 0 {
+		config.Leeway = DefaultLeeway
+	}
+
+	return &verifier{
+		config: config,
+		keys:   keys,
+		now:    time.Now,
+	}, nil
+}
+
+// Verify returns the identity of the subject of token, with the highest ranked role its role claim maps to, or no
+// role if it maps to none. It returns an error matching ErrInvalidToken if token is not a JWT signed by one of the
+// verifier's keys, or its iss, aud, exp or nbf claims don't allow it to be used.
+func (v *verifier) Verify(token string) (Identity, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return Identity{}, fmt.Errorf("%w: token is not a JWT", ErrInvalidToken)
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	err := decodeSegment(parts[0], &header)
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: malformed header", ErrInvalidToken)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: malformed signature", ErrInvalidToken)
+	}
+
+	if !v.verifySignature(header.Alg, header.Kid, parts[0]+"."+parts[1], signature) {
+		return Identity{}, fmt.Errorf("%w: signature is invalid", ErrInvalidToken)
+	}
+
+	var claims map[string]interface{}
+	err = decodeSegment(parts[1], &claims)
+	if err != nil {
+		return Identity{}, fmt.Errorf("%w: malformed claims", ErrInvalidToken)
+	}
+
+	err = v.checkClaims(claims)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	sub, _ := claims["sub"].(string)
+	if sub == "" {
+		return Identity{}, fmt.Errorf("%w: sub is required", ErrInvalidToken)
+	}
+
+	id := Identity{ID: sub, Name: sub, Role: v.role(claims)}
+	for _, claim := range []string{"preferred_username", "email", "name"} {
+		if name, ok := claims[claim].(string); ok && name != "" {
+			id.Name = name
+			break
+		}
+	}
+
+	return id, nil
+}
+
+// verifySignature returns true if signature is the signature of signed made with alg by one of the verifier's keys,
+// or by the key with kid if it is set.
+func (v *verifier) verifySignature(alg, kid, signed string, signature []byte) bool {
+	digest := sha256.Sum256([]byte(signed))
+
+	for _, key := range v.keys {
+		if key.Algorithm != alg || (kid != "" && key.ID != "" && key.ID != kid) {
+			continue
+		}
+
+		switch k := key.Key.(type) {
+		case *rsa.PublicKey:
+			if rsa.VerifyPKCS1v15(k, crypto.SHA256, digest[:], signature) == nil {
+				return true
+			}
+		case *ecdsa.PublicKey:
+			// ES256 signatures are r and s as 32 bytes each, rather than ASN.1.
+			if len(signature) == 64 {
+				r, s := new(big.Int).SetBytes(signature[:32]), new(big.Int).SetBytes(signature[32:])
+				if ecdsa.Verify(k, digest[:], r, s) {
+					return true
+				}
+			}
+		case []byte:
+			mac := hmac.New(sha256.New, k)
+			mac.Write([]byte(signed))
+			if hmac.Equal(mac.Sum(nil), signature) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// checkClaims returns an error matching ErrInvalidToken unless claims are issued by the verifier's issuer for its
+// audience, and are valid now.
+func (v *verifier) checkClaims(claims map[string]interface{}) error {
+	if iss, _ := claims["iss"].(string); iss != v.config.Issuer {
+		return fmt.Errorf("%w: iss must be %q", ErrInvalidToken, v.config.Issuer)
+	}
+
+	if !contains(stringsClaim(claims["aud"]), v.config.Audience) {
+		return fmt.Errorf("%w: aud must include %q", ErrInvalidToken, v.config.Audience)
+	}
+
+	now := v.now()
+
+	exp, ok := timeClaim(claims["exp"])
+	if !ok {
+		return fmt.Errorf("%w: exp is required", ErrInvalidToken)
+	}
+	if now.After(exp.Add(v.config.Leeway)) {
+		return fmt.Errorf("%w: token expired at %s", ErrInvalidToken, exp.UTC().Format(time.RFC3339))
+	}
+
+	if nbf, ok := timeClaim(claims["nbf"]); ok && now.Add(v.config.Leeway).Before(nbf) {
+		return fmt.Errorf("%w: token is not valid until %s", ErrInvalidToken, nbf.UTC().Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// role returns the highest ranked role the values of the role claim in claims map to, or "" if none do.
+func (v *verifier) role(claims map[string]interface{}) (role string) {
+	var value interface{} = claims
+	for _, name := range strings.Split(v.config.RoleClaim, ".") {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return ""
+		}
+		value = object[name]
+	}
+
+	for _, s := range stringsClaim(value) {
+		mapped := s
+		if len(v.config.RoleMap) > 0 {
+			mapped = v.config.RoleMap[s]
+		}
+
+		if roleRanks[mapped] > roleRanks[role] {
+			role = mapped
+		}
+	}
+
+	return
+}
+
+// checkKey returns an error matching ErrInvalidVerifier if key can't verify tokens signed with its algorithm.
+func checkKey(key VerificationKey) error {
+	ok := false
+	switch key.Algorithm {
+	case AlgRS256:
+		_, ok = key.Key.(*rsa.PublicKey)
+	case AlgES256:
+		var k *ecdsa.PublicKey
+		k, ok = key.Key.(*ecdsa.PublicKey)
+		ok = ok && k.Curve.Params().Name == "P-256"
+	case AlgHS256:
+		var k []byte
+		k, ok = key.Key.([]byte)
+		ok = ok && len(k) > 0
+	default:
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidVerifier, key.Algorithm)
+	}
+
+	if !ok {
+		return fmt.Errorf("%w: key %q is not a %s key", ErrInvalidVerifier, key.ID, key.Algorithm)
+	}
+
+	return nil
+}
+
+// decodeSegment decodes a base64url encoded JSON segment of a token into v.
+func decodeSegment(segment string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// stringsClaim returns the value of a claim that is either a string or an array of strings.
+func stringsClaim(value interface{}) []string {
+	switch v := value.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var values []string
+		for _, e := range v {
+			if s, ok := e.(string); ok {
+				values = append(values, s)
+			}
+		}
+		return values
+	}
+
+	return nil
+}
+
+// timeClaim returns the time of a claim holding seconds since the Unix epoch, ignoring fractions of a second, and false
+// if it has none.
+func timeClaim(value interface{}) (time.Time, bool) {
+	n, ok := value.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	return time.Unix(int64(f), 0), true
+}
+
+// contains returns true if values contains value.
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,362 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testIssuer   = "https://sso.example.com"
+	testAudience = "supermarket-api"
+)
+
+// testKeys are keys generated for signing tokens in tests.
+type testKeys struct {
+	rsa    *rsa.PrivateKey
+	ec     *ecdsa.PrivateKey
+	secret []byte
+}
+
+// newTestKeys generates an RSA key, a P-256 key and an HMAC secret.
+func newTestKeys(t *testing.T) testKeys {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return testKeys{rsa: rsaKey, ec: ecKey, secret: []byte("0123456789abcdef0123456789abcdef")}
+}
+
+// sign returns a token with claims signed with alg by key, with kid in its header if it is set.
+func sign(t *testing.T, alg, kid string, key interface{}, claims map[string]interface{}) string {
+	header := map[string]string{"alg": alg, "typ": "JWT"}
+	if kid != "" {
+		header["kid"] = kid
+	}
+
+	signed := encodeSegment(t, header) + "." + encodeSegment(t, claims)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	var err error
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, k, crypto.SHA256, digest[:])
+	case *ecdsa.PrivateKey:
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, k, digest[:])
+		signature = make([]byte, 64)
+		r.FillBytes(signature[:32])
+		s.FillBytes(signature[32:])
+	case []byte:
+		mac := hmac.New(sha256.New, k)
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+// encodeSegment returns v as base64url encoded JSON.
+func encodeSegment(t *testing.T, v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// testClaims returns claims valid at testNow, with changes applied.
+func testClaims(changes map[string]interface{}) map[string]interface{} {
+	claims := map[string]interface{}{
+		"iss":                testIssuer,
+		"aud":                []string{testAudience, "other-api"},
+		"sub":                "user-1",
+		"preferred_username": "jane",
+		"exp":                testNow.Add(time.Hour).Unix(),
+		"nbf":                testNow.Add(-time.Minute).Unix(),
+		"roles":              []string{"supermarket-pricing"},
+	}
+
+	for claim, value := range changes {
+		if value == nil {
+			delete(claims, claim)
+			continue
+		}
+		claims[claim] = value
+	}
+
+	return claims
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	keys := newTestKeys(t)
+
+	jwks, err := json.Marshal(map[string]interface{}{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa-1", "use": "sig", "alg": "RS256", "n": b64(keys.rsa.N.Bytes()), "e": b64(big.NewInt(int64(keys.rsa.E)).Bytes())},
+		{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": b64(keys.ec.X.Bytes()), "y": b64(keys.ec.Y.Bytes())},
+		{"kty": "oct", "kid": "hmac-1", "k": b64(keys.secret)},
+		{"kty": "RSA", "kid": "enc-1", "use": "enc", "n": b64(keys.rsa.N.Bytes()), "e": "AQAB"},
+	}})
+	assert.Nil(t, err)
+
+	verificationKeys, err := ParseJWKS(jwks)
+	assert.Nil(t, err)
+	assert.Len(t, verificationKeys, 3)
+
+	v, err := NewVerifier(verificationKeys, VerifierConfig{
+		Issuer:   testIssuer,
+		Audience: testAudience,
+		RoleMap:  map[string]string{"supermarket-pricing": RolePricingEditor, "supermarket-ops": RoleAdmin},
+	})
+	assert.Nil(t, err)
+	v.now = func() time.Time { return testNow }
+
+	other := newTestKeys(t)
+	pricingEditor := Identity{ID: "user-1", Name: "jane", Role: RolePricingEditor}
+
+	tests := []struct {
+		test             string
+		token            string
+		expectedIdentity Identity
+		expectedError    string
+	}{
+		{
+			test:             "it should accept RS256 tokens",
+			token:            sign(t, AlgRS256, "rsa-1", keys.rsa, testClaims(nil)),
+			expectedIdentity: pricingEditor,
+		},
+		{
+			test:             "it should accept ES256 tokens",
+			token:            sign(t, AlgES256, "ec-1", keys.ec, testClaims(nil)),
+			expectedIdentity: pricingEditor,
+		},
+		{
+			test:             "it should accept HS256 tokens",
+			token:            sign(t, AlgHS256, "hmac-1", keys.secret, testClaims(nil)),
+			expectedIdentity: pricingEditor,
+		},
+		{
+			test:             "it should try every key of the algorithm if the token has no kid",
+			token:            sign(t, AlgRS256, "", keys.rsa, testClaims(nil)),
+			expectedIdentity: pricingEditor,
+		},
+		{
+			test:          "it should reject tokens signed by another key",
+			token:         sign(t, AlgRS256, "rsa-1", other.rsa, testClaims(nil)),
+			expectedError: "signature is invalid",
+		},
+		{
+			test:          "it should reject tokens whose kid names a key of another algorithm",
+			token:         sign(t, AlgES256, "rsa-1", keys.ec, testClaims(nil)),
+			expectedError: "signature is invalid",
+		},
+		{
+			test:          "it should reject tokens signed with the public key as an HMAC secret",
+			token:         sign(t, AlgHS256, "rsa-1", x509.MarshalPKCS1PublicKey(&keys.rsa.PublicKey), testClaims(nil)),
+			expectedError: "signature is invalid",
+		},
+		{
+			test:          "it should reject unsigned tokens",
+			token:         sign(t, "none", "", nil, testClaims(nil)),
+			expectedError: "signature is invalid",
+		},
+		{
+			test:          "it should reject tokens that aren't JWTs",
+			token:         "sk_test-key_secret",
+			expectedError: "token is not a JWT",
+		},
+		{
+			test:          "it should reject tokens from another issuer",
+			token:         sign(t, AlgRS256, "rsa-1", keys.rsa, testClaims(map[string]interface{}{"iss": "https://evil.example.com"})),
+			expectedError: "iss must be",
+		},
+		{
+			test:          "it should reject tokens for another audience",
+			token:         sign(t, AlgRS256, "rsa-1", keys.rsa, testClaims(map[string]interface{}{"aud": "other-api"})),
+			expectedError: "aud must include",
+		},
+		{
+			test:             "it should accept a single audience",
+			token:            sign(t, AlgRS256, "rsa-1", keys.rsa, testClaims(map[string]interface{}{"aud": testAudience})),
+			expectedIdentity: pricingEditor,
+		},
+		{
+			test:          "it should reject expired tokens",
+			token:         sign(t, AlgRS256, "rsa-1", keys.rsa, testClaims(map[string]interface{}{"exp": testNow.Add(-2 * time.Minute).Unix()})),
+			expectedError: "token expired at",
+		},
+		{
+			test:             "it should accept tokens that expired within the leeway",
+			token:            sign(t, AlgRS256, "rsa-1", keys.rsa, testClaims(map[string]interface{}{"exp": testNow.Add(-30 * time.Second).Unix()})),
+			expectedIdentity: pricingEditor,
+		},
+		{
+			test:          "it should reject tokens without an expiry",
+			token:         sign(t, AlgRS256, "rsa-1", keys.rsa, testClaims(map[string]interface{}{"exp": nil})),
+			expectedError: "exp is required",
+		},
+		{
+			test:          "it should reject tokens that are not valid yet",
+			token:         sign(t, AlgRS256, "rsa-1", keys.rsa, testClaims(map[string]interface{}{"nbf": testNow.Add(time.Hour).Unix()})),
+			expectedError: "not valid until",
+		},
+		{
+			test:          "it should reject tokens without a subject",
+			token:         sign(t, AlgRS256, "rsa-1", keys.rsa, testClaims(map[string]interface{}{"sub": nil})),
+			expectedError: "sub is required",
+		},
+		{
+			test:             "it should use the highest ranked role the claims map to",
+			token:            sign(t, AlgRS256, "rsa-1", keys.rsa, testClaims(map[string]interface{}{"roles": []string{"supermarket-pricing", "supermarket-ops", "unknown"}})),
+			expectedIdentity: Identity{ID: "user-1", Name: "jane", Role: RoleAdmin},
+		},
+		{
+			test:             "it should ignore role names the role map doesn't map",
+			token:            sign(t, AlgRS256, "rsa-1", keys.rsa, testClaims(map[string]interface{}{"roles": []string{RoleAdmin, "supermarket-pricing"}})),
+			expectedIdentity: pricingEditor,
+		},
+		{
+			test:             "it should give tokens whose claims map to no role no role",
+			token:            sign(t, AlgRS256, "rsa-1", keys.rsa, testClaims(map[string]interface{}{"roles": nil, "preferred_username": nil})),
+			expectedIdentity: Identity{ID: "user-1", Name: "user-1"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			id, err := v.Verify(tc.token)
+			if tc.expectedError == "" {
+				assert.Nil(t, err)
+			} else {
+				assert.True(t, errors.Is(err, ErrInvalidToken), "unexpected error: %v", err)
+				assert.Contains(t, err.Error(), tc.expectedError)
+			}
+			assert.Equal(t, tc.expectedIdentity, id)
+		})
+	}
+}
+
+func TestVerifier_nestedRoleClaim(t *testing.T) {
+	keys := newTestKeys(t)
+
+	v, err := NewVerifier([]VerificationKey{{Algorithm: AlgHS256, Key: keys.secret}}, VerifierConfig{
+		Issuer:    testIssuer,
+		Audience:  testAudience,
+		RoleClaim: "realm_access.roles",
+	})
+	assert.Nil(t, err)
+	v.now = func() time.Time { return testNow }
+
+	id, err := v.Verify(sign(t, AlgHS256, "", keys.secret, testClaims(map[string]interface{}{
+		"realm_access": map[string]interface{}{"roles": []string{"offline_access", RolePricingEditor}},
+	})))
+	assert.Nil(t, err)
+	assert.Equal(t, RolePricingEditor, id.Role)
+}
+
+func TestNewVerifier(t *testing.T) {
+	keys := newTestKeys(t)
+	config := VerifierConfig{Issuer: testIssuer, Audience: testAudience}
+
+	tests := []struct {
+		test   string
+		keys   []VerificationKey
+		config VerifierConfig
+	}{
+		{
+			test:   "it should require an issuer and audience",
+			keys:   []VerificationKey{{Algorithm: AlgHS256, Key: keys.secret}},
+			config: VerifierConfig{Issuer: testIssuer},
+		},
+		{
+			test:   "it should require a key",
+			config: config,
+		},
+		{
+			test:   "it should reject keys that don't suit their algorithm",
+			keys:   []VerificationKey{{Algorithm: AlgRS256, Key: &keys.ec.PublicKey}},
+			config: config,
+		},
+		{
+			test:   "it should reject unsupported algorithms",
+			keys:   []VerificationKey{{Algorithm: "none"}},
+			config: config,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			_, err := NewVerifier(tc.keys, tc.config)
+			assert.True(t, errors.Is(err, ErrInvalidVerifier), "unexpected error: %v", err)
+		})
+	}
+}
+
+func TestParsePublicKeys(t *testing.T) {
+	keys := newTestKeys(t)
+
+	var data []byte
+	for _, pub := range []interface{}{&keys.rsa.PublicKey, &keys.ec.PublicKey} {
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		assert.Nil(t, err)
+		data = append(data, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})...)
+	}
+
+	parsed, err := ParsePublicKeys(data)
+	assert.Nil(t, err)
+	assert.Equal(t, []VerificationKey{
+		{Algorithm: AlgRS256, Key: &keys.rsa.PublicKey},
+		{Algorithm: AlgES256, Key: &keys.ec.PublicKey},
+	}, parsed)
+
+	_, err = ParsePublicKeys([]byte("not a key"))
+	assert.True(t, errors.Is(err, ErrInvalidKeySet))
+}
+
+func TestParseJWKS(t *testing.T) {
+	tests := []struct {
+		test string
+		jwks string
+	}{
+		{test: "it should reject malformed sets", jwks: `{"keys":`},
+		{test: "it should reject sets without signing keys", jwks: `{"keys":[{"kty":"RSA","use":"enc","n":"AQAB","e":"AQAB"}]}`},
+		{test: "it should reject points that aren't on the curve", jwks: `{"keys":[{"kty":"EC","crv":"P-256","x":"AQ","y":"AQ"}]}`},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			_, err := ParseJWKS([]byte(tc.jwks))
+			assert.True(t, errors.Is(err, ErrInvalidKeySet), "unexpected error: %v", err)
+		})
+	}
+}
+
+// b64 returns b base64url encoded without padding.
+func b64(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
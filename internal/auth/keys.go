@@ -0,0 +1,289 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/davidlick/supermarket-api/internal/interfaces"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+)
+
+type service struct {
+	db  interfaces.RamDB
+	now func() time.Time
+}
+
+// NewService creates a new API key service storing keys in db.
+func NewService(db interfaces.RamDB) *service {
+	return &service{
+		db:  db,
+		now: time.Now,
+	}
+}
+
+// Create stores a new key with the name and role of key under a generated ID, and returns it with its token. It
+// returns an error matching ErrInvalidKey if the name is blank or the role is unknown.
+func (s *service) Create(key Key) (IssuedKey, error) {
+	err := validate(key)
+	if err != nil {
+		return IssuedKey{}, err
+	}
+
+	key.ID, err = randomHex(8)
+	if err != nil {
+		return IssuedKey{}, err
+	}
+
+	token, err := newToken(key.ID)
+	if err != nil {
+		return IssuedKey{}, err
+	}
+
+	key.Hash = hash(token)
+	key.CreatedAt = s.now().UTC()
+	key.RotatedAt = nil
+	key.RevokedAt = nil
+
+	rec, err := newRecord(key)
+	if err != nil {
+		return IssuedKey{}, err
+	}
+
+	err = s.db.Insert(rec)
+	if err != nil {
+		return IssuedKey{}, err
+	}
+
+	key.Version = 1
+	return IssuedKey{Key: redact(key), Token: token}, nil
+}
+
+// Rotate issues the key with id a new token, which replaces its old token straight away. It returns ErrKeyRevoked if
+// the key is revoked.
+func (s *service) Rotate(id string) (IssuedKey, error) {
+	key, err := s.key(id)
+	if err != nil {
+		return IssuedKey{}, err
+	}
+
+	if key.RevokedAt != nil {
+		return IssuedKey{}, ErrKeyRevoked
+	}
+
+	token, err := newToken(key.ID)
+	if err != nil {
+		return IssuedKey{}, err
+	}
+
+	now := s.now().UTC()
+	key.Hash = hash(token)
+	key.RotatedAt = &now
+
+	key, err = s.swap(key)
+	if err != nil {
+		return IssuedKey{}, err
+	}
+
+	return IssuedKey{Key: key, Token: token}, nil
+}
+
+// Revoke stops the key with id from authenticating. Revoked keys are kept, so the identities recorded by audit logs
+// can still be looked up. It returns ErrKeyRevoked if the key is already revoked.
+func (s *service) Revoke(id string) (Key, error) {
+	key, err := s.key(id)
+	if err != nil {
+		return Key{}, err
+	}
+
+	if key.RevokedAt != nil {
+		return Key{}, ErrKeyRevoked
+	}
+
+	now := s.now().UTC()
+	key.RevokedAt = &now
+
+	return s.swap(key)
+}
+
+// Get returns the key with id, without its hash.
+func (s *service) Get(id string) (Key, error) {
+	key, err := s.key(id)
+	return redact(key), err
+}
+
+// All returns every key sorted by ID, including revoked keys, without their hashes.
+func (s *service) All() (keys []Key, err error) {
+	recs, err := s.db.Scan(KeyAPIKeyID, ramdb.ScanOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rec := range recs {
+		var key Key
+		err = rec.Deserialize(&key)
+		if err != nil {
+			return nil, err
+		}
+
+		key.Version = rec.Version()
+		keys = append(keys, redact(key))
+	}
+
+	return
+}
+
+// Authenticate returns the identity of the key token was issued to. It returns ErrInvalidToken if the token is not
+// the current token of a key that hasn't been revoked.
+func (s *service) Authenticate(token string) (Identity, error) {
+	id, ok := tokenID(token)
+	if !ok {
+		return Identity{}, ErrInvalidToken
+	}
+
+	key, err := s.key(id)
+	if err == ramdb.ErrNoRecord {
+		return Identity{}, ErrInvalidToken
+	}
+	if err != nil {
+		return Identity{}, err
+	}
+
+	if key.RevokedAt != nil || subtle.ConstantTimeCompare([]byte(key.Hash), []byte(hash(token))) != 1 {
+		return Identity{}, ErrInvalidToken
+	}
+
+	return Identity{ID: key.ID, Name: key.Name, Role: key.Role}, nil
+}
+
+// Bootstrap creates an admin key named "bootstrap" if there is no admin key that hasn't been revoked, so a new
+// deployment, or one that revoked its last admin key, can be administered. It returns false if no key was created.
+func (s *service) Bootstrap() (IssuedKey, bool, error) {
+	keys, err := s.All()
+	if err != nil {
+		return IssuedKey{}, false, err
+	}
+
+	for _, key := range keys {
+		if key.Role == RoleAdmin && key.RevokedAt == nil {
+			return IssuedKey{}, false, nil
+		}
+	}
+
+	issued, err := s.Create(Key{Name: "bootstrap", Role: RoleAdmin})
+	if err != nil {
+		return IssuedKey{}, false, err
+	}
+
+	return issued, true, nil
+}
+
+// Allows returns true if role is allowed everything required is. Unknown roles are allowed nothing.
+func Allows(role, required string) bool {
+	return roleRanks[required] > 0 && roleRanks[role] >= roleRanks[required]
+}
+
+// IsToken returns true if token is formatted as an API key token, rather than some other kind of bearer token.
+func IsToken(token string) bool {
+	_, ok := tokenID(token)
+	return ok
+}
+
+// key returns the key with id, including its hash.
+func (s *service) key(id string) (key Key, err error) {
+	rec, err := s.db.Get(KeyAPIKeyID, id)
+	if err != nil {
+		return
+	}
+
+	err = rec.Deserialize(&key)
+	key.Version = rec.Version()
+	return
+}
+
+// swap replaces the stored key with key if it is still at key's version, and returns it without its hash.
+func (s *service) swap(key Key) (Key, error) {
+	rec, err := newRecord(key)
+	if err != nil {
+		return Key{}, err
+	}
+
+	err = s.db.CompareAndSwap(rec, key.Version)
+	if err != nil {
+		return Key{}, err
+	}
+
+	key.Version++
+	return redact(key), nil
+}
+
+// validate returns an error matching ErrInvalidKey if key is invalid, or nil if it is valid.
+func validate(key Key) error {
+	if strings.TrimSpace(key.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidKey)
+	}
+
+	if roleRanks[key.Role] == 0 {
+		return fmt.Errorf("%w: role must be %s, %s or %s", ErrInvalidKey, RoleReader, RolePricingEditor, RoleAdmin)
+	}
+
+	return nil
+}
+
+// redact returns key without its hash.
+func redact(key Key) Key {
+	key.Hash = ""
+	return key
+}
+
+// newRecord returns the record storing key, keyed by ID.
+func newRecord(key Key) (*ramdb.Record, error) {
+	key.Version = 0
+	return ramdb.NewRecord(key.ID, KeyAPIKeyID, key)
+}
+
+// newToken returns a new token for the key with id. Tokens are the prefix, the key's ID and 32 random bytes, so the key
+// a token is for can be found without storing the token.
+func newToken(id string) (string, error) {
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", err
+	}
+
+	return tokenPrefix + id + "_" + secret, nil
+}
+
+// tokenID returns the ID of the key token is for, and false if token is not an API key token.
+func tokenID(token string) (string, bool) {
+	if !strings.HasPrefix(token, tokenPrefix) {
+		return "", false
+	}
+
+	parts := strings.SplitN(strings.TrimPrefix(token, tokenPrefix), "_", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", false
+	}
+
+	return parts[0], true
+}
+
+// hash returns the hex SHA-256 hash of token. Tokens are random, so they don't need a slow or salted hash.
+func hash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// randomHex returns n random bytes encoded as hex.
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
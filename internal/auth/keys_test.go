@@ -0,0 +1,239 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+	"github.com/stretchr/testify/assert"
+)
+
+var testNow = time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// newTestService returns an API key service on a new api_keys table, with testNow as the current time.
+func newTestService(t *testing.T) *service {
+	db := ramdb.NewDatabase()
+	assert.Nil(t, db.CreateTable("api_keys"))
+	assert.Nil(t, db.From("api_keys").CreateOrderedIndex(KeyAPIKeyID))
+
+	svc := NewService(db.From("api_keys"))
+	svc.now = func() time.Time { return testNow }
+	return svc
+}
+
+func TestService_Create(t *testing.T) {
+	tests := []struct {
+		test          string
+		key           Key
+		expectedError error
+	}{
+		{
+			test: "it should create a key",
+			key:  Key{Name: "pricing-team", Role: RolePricingEditor},
+		},
+		{
+			test:          "it should reject a blank name",
+			key:           Key{Name: " ", Role: RoleReader},
+			expectedError: ErrInvalidKey,
+		},
+		{
+			test:          "it should reject an unknown role",
+			key:           Key{Name: "pricing-team", Role: "owner"},
+			expectedError: ErrInvalidKey,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			svc := newTestService(t)
+
+			issued, err := svc.Create(tc.key)
+			assert.True(t, errors.Is(err, tc.expectedError), "unexpected error: %v", err)
+			if tc.expectedError != nil {
+				return
+			}
+
+			assert.NotEmpty(t, issued.ID)
+			assert.True(t, strings.HasPrefix(issued.Token, tokenPrefix+issued.ID+"_"))
+			assert.True(t, IsToken(issued.Token))
+			assert.Empty(t, issued.Hash)
+			assert.Equal(t, testNow, issued.CreatedAt)
+			assert.Equal(t, uint64(1), issued.Version)
+
+			// Only the hash of the token is stored.
+			stored, err := svc.key(issued.ID)
+			assert.Nil(t, err)
+			assert.Equal(t, hash(issued.Token), stored.Hash)
+			assert.NotContains(t, stored.Hash, issued.Token)
+
+			key, err := svc.Get(issued.ID)
+			assert.Nil(t, err)
+			assert.Equal(t, issued.Key, key)
+		})
+	}
+}
+
+func TestService_Authenticate(t *testing.T) {
+	svc := newTestService(t)
+
+	issued, err := svc.Create(Key{Name: "pricing-team", Role: RolePricingEditor})
+	assert.Nil(t, err)
+	other, err := svc.Create(Key{Name: "till", Role: RoleReader})
+	assert.Nil(t, err)
+
+	tests := []struct {
+		test             string
+		token            string
+		expectedIdentity Identity
+		expectedError    error
+	}{
+		{
+			test:             "it should return the identity of the key a token was issued to",
+			token:            issued.Token,
+			expectedIdentity: Identity{ID: issued.ID, Name: "pricing-team", Role: RolePricingEditor},
+		},
+		{
+			test:          "it should reject a token with the wrong secret",
+			token:         tokenPrefix + issued.ID + "_" + strings.TrimPrefix(other.Token, tokenPrefix+other.ID+"_"),
+			expectedError: ErrInvalidToken,
+		},
+		{
+			test:          "it should reject a token for a key that doesn't exist",
+			token:         tokenPrefix + "missing_secret",
+			expectedError: ErrInvalidToken,
+		},
+		{
+			test:          "it should reject a token that isn't an API key token",
+			token:         "eyJhbGciOiJIUzI1NiJ9.e30.signature",
+			expectedError: ErrInvalidToken,
+		},
+		{
+			test:          "it should reject a token without a secret",
+			token:         tokenPrefix + issued.ID + "_",
+			expectedError: ErrInvalidToken,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			id, err := svc.Authenticate(tc.token)
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedIdentity, id)
+		})
+	}
+}
+
+func TestService_Rotate(t *testing.T) {
+	t.Run("it should replace the token of a key", func(t *testing.T) {
+		svc := newTestService(t)
+
+		issued, err := svc.Create(Key{Name: "pricing-team", Role: RolePricingEditor})
+		assert.Nil(t, err)
+
+		rotated, err := svc.Rotate(issued.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, issued.ID, rotated.ID)
+		assert.NotEqual(t, issued.Token, rotated.Token)
+		assert.Equal(t, testNow, *rotated.RotatedAt)
+		assert.Equal(t, uint64(2), rotated.Version)
+		assert.Empty(t, rotated.Hash)
+
+		_, err = svc.Authenticate(issued.Token)
+		assert.Equal(t, ErrInvalidToken, err)
+
+		id, err := svc.Authenticate(rotated.Token)
+		assert.Nil(t, err)
+		assert.Equal(t, issued.ID, id.ID)
+	})
+
+	t.Run("it should return ErrNoRecord if the key doesn't exist", func(t *testing.T) {
+		_, err := newTestService(t).Rotate("missing")
+		assert.Equal(t, ramdb.ErrNoRecord, err)
+	})
+}
+
+func TestService_Revoke(t *testing.T) {
+	svc := newTestService(t)
+
+	issued, err := svc.Create(Key{Name: "pricing-team", Role: RolePricingEditor})
+	assert.Nil(t, err)
+
+	key, err := svc.Revoke(issued.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, testNow, *key.RevokedAt)
+
+	_, err = svc.Authenticate(issued.Token)
+	assert.Equal(t, ErrInvalidToken, err)
+
+	_, err = svc.Revoke(issued.ID)
+	assert.Equal(t, ErrKeyRevoked, err)
+
+	_, err = svc.Rotate(issued.ID)
+	assert.Equal(t, ErrKeyRevoked, err)
+
+	// Revoked keys are still listed.
+	keys, err := svc.All()
+	assert.Nil(t, err)
+	assert.Equal(t, []Key{key}, keys)
+}
+
+func TestService_Bootstrap(t *testing.T) {
+	svc := newTestService(t)
+
+	_, err := svc.Create(Key{Name: "pricing-team", Role: RolePricingEditor})
+	assert.Nil(t, err)
+
+	issued, created, err := svc.Bootstrap()
+	assert.Nil(t, err)
+	assert.True(t, created)
+	assert.Equal(t, RoleAdmin, issued.Role)
+	assert.NotEmpty(t, issued.Token)
+
+	_, created, err = svc.Bootstrap()
+	assert.Nil(t, err)
+	assert.False(t, created)
+
+	// Revoking the last admin key lets another be bootstrapped.
+	_, err = svc.Revoke(issued.ID)
+	assert.Nil(t, err)
+
+	_, created, err = svc.Bootstrap()
+	assert.Nil(t, err)
+	assert.True(t, created)
+}
+
+func TestAllows(t *testing.T) {
+	tests := []struct {
+		role     string
+		required string
+		expected bool
+	}{
+		{RoleReader, RoleReader, true},
+		{RoleReader, RolePricingEditor, false},
+		{RolePricingEditor, RoleReader, true},
+		{RolePricingEditor, RoleAdmin, false},
+		{RoleAdmin, RolePricingEditor, true},
+		{"", RoleReader, false},
+		{RoleAdmin, "owner", false},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.role+" "+tc.required, func(t *testing.T) {
+			assert.Equal(t, tc.expected, Allows(tc.role, tc.required))
+		})
+	}
+}
+
+func TestFromContext(t *testing.T) {
+	_, ok := FromContext(context.Background())
+	assert.False(t, ok)
+
+	id := Identity{ID: "9f86d081884c7d65", Name: "pricing-team", Role: RolePricingEditor}
+	got, ok := FromContext(NewContext(context.Background(), id))
+	assert.True(t, ok)
+	assert.Equal(t, id, got)
+	assert.Equal(t, "pricing-team (9f86d081884c7d65)", got.String())
+}
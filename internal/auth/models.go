@@ -0,0 +1,37 @@
+package auth
+
+import (
+	"fmt"
+	"time"
+)
+
+// Key is an API key. Only a hash of its token is stored, so the token is only known when the key is created or
+// rotated.
+type Key struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	Role      string     `json:"role"`
+	Hash      string     `json:"hash,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	RotatedAt *time.Time `json:"rotated_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	Version   uint64     `json:"version"`
+}
+
+// IssuedKey is a Key with the token it was issued, which is only returned when the key is created or rotated.
+type IssuedKey struct {
+	Key
+	Token string `json:"token"`
+}
+
+// Identity is who a request is made by.
+type Identity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	Role string `json:"role"`
+}
+
+// String returns the identity as its name and ID, such as "pricing-team (9f86d081884c7d65)".
+func (i Identity) String() string {
+	return fmt.Sprintf("%s (%s)", i.Name, i.ID)
+}
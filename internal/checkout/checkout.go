@@ -0,0 +1,190 @@
+package checkout
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+)
+
+type service struct {
+	produceSvc   ProduceService
+	promotionSvc PromotionService
+	taxRate      *big.Rat
+	now          func() time.Time
+}
+
+// NewService creates a new checkout service pricing baskets from produceSvc, discounting them with promotionSvc and
+// charging sales tax at taxRate. promotionSvc may be nil to price baskets without promotions.
+func NewService(produceSvc ProduceService, promotionSvc PromotionService, taxRate *big.Rat) *service {
+	return &service{
+		produceSvc:   produceSvc,
+		promotionSvc: promotionSvc,
+		taxRate:      taxRate,
+		now:          time.Now,
+	}
+}
+
+// ParseTaxRate parses a sales tax rate written as a decimal fraction, such as "0.0725" for 7.25%. The rate must be
+// from 0 up to and including 1.
+func ParseTaxRate(s string) (*big.Rat, error) {
+	rate, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok || rate.Sign() < 0 || rate.Cmp(big.NewRat(1, 1)) > 0 {
+		return nil, fmt.Errorf("%w: %q must be a decimal from 0 to 1", ErrInvalidTaxRate, s)
+	}
+
+	return rate, nil
+}
+
+// Checkout prices every item in basket at its current price, applies the promotions active now, and returns the
+// itemised Receipt. Items sharing a code are priced as one line, in the unit the item is priced per. Sales tax is
+// charged on the discounted subtotal and rounded half up to the currency's minor unit. Every item must be priced in the
+// same currency.
+func (s *service) Checkout(basket []BasketItem) (Receipt, error) {
+	lines, err := s.basketLines(basket)
+	if err != nil {
+		return Receipt{}, err
+	}
+
+	now := s.now()
+	receipt := Receipt{
+		Lines:   make([]Line, 0, len(lines)),
+		TaxRate: formatRate(s.taxRate),
+	}
+
+	for _, line := range lines {
+		line, err = s.priceLine(line, now)
+		if err != nil {
+			return Receipt{}, err
+		}
+
+		if receipt.Subtotal == nil {
+			receipt.Subtotal = money.New(0, line.Total.Currency().Code)
+			receipt.Discount = money.New(0, line.Total.Currency().Code)
+		}
+
+		if !receipt.Subtotal.SameCurrency(line.Total) {
+			return Receipt{}, fmt.Errorf("%w: %s is priced in %s, not %s", ErrMixedCurrencies, line.Code, line.Total.Currency().Code, receipt.Subtotal.Currency().Code)
+		}
+
+		receipt.Subtotal, err = receipt.Subtotal.Add(line.Total)
+		if err != nil {
+			return Receipt{}, err
+		}
+
+		for _, d := range line.Discounts {
+			receipt.Discount, err = receipt.Discount.Add(d.Amount)
+			if err != nil {
+				return Receipt{}, err
+			}
+		}
+
+		receipt.Lines = append(receipt.Lines, line)
+	}
+
+	// Tax is rounded half up to a whole number of minor units.
+	tax := new(big.Rat).Mul(new(big.Rat).SetInt64(receipt.Subtotal.Amount()), s.taxRate)
+	receipt.Tax = money.New(produce.RoundHalfUp(tax), receipt.Subtotal.Currency().Code)
+	receipt.Total, err = receipt.Subtotal.Add(receipt.Tax)
+	if err != nil {
+		return Receipt{}, err
+	}
+
+	return receipt, nil
+}
+
+// basketLines looks up each produce item in basket and returns its unpriced Line. The quantities of items sharing a
+// code, ignoring case, are converted to the unit the item is priced per and added together in the position of the
+// first of them. Each item is checked against MaxQuantity before it is added, so the sum can't overflow. Items priced
+// per a unit that isn't weighed must come in whole units.
+func (s *service) basketLines(basket []BasketItem) ([]Line, error) {
+	if len(basket) == 0 {
+		return nil, ErrEmptyBasket
+	}
+
+	lines := make([]Line, 0, len(basket))
+	positions := make(map[string]int, len(basket))
+	for _, bi := range basket {
+		if bi.Quantity <= 0 || bi.Quantity > MaxQuantity {
+			return nil, fmt.Errorf("%w: %s: quantity must be more than 0 and at most %s", ErrInvalidQuantity, bi.Code, produce.Quantity(MaxQuantity))
+		}
+
+		code := strings.ToLower(bi.Code)
+		i, found := positions[code]
+		if !found {
+			item, err := s.produceSvc.Get(bi.Code)
+			if errors.Is(err, ramdb.ErrNoRecord) {
+				return nil, fmt.Errorf("%w: %s", ErrUnknownProduce, bi.Code)
+			}
+			if err != nil {
+				return nil, err
+			}
+
+			if item.Price == nil {
+				return nil, fmt.Errorf("%w: %s", ErrUnpricedProduce, item.Code)
+			}
+
+			i = len(lines)
+			positions[code] = i
+			lines = append(lines, Line{Code: item.Code, Name: item.Name, Unit: item.Unit, UnitPrice: item.Price})
+		}
+
+		unit := bi.Unit
+		if unit == "" {
+			unit = lines[i].Unit
+		}
+
+		quantity, err := produce.ConvertQuantity(bi.Quantity, unit, lines[i].Unit)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, bi.Code)
+		}
+
+		lines[i].Quantity += quantity
+	}
+
+	for _, line := range lines {
+		if line.Quantity <= 0 || line.Quantity > MaxQuantity {
+			return nil, fmt.Errorf("%w: %s: quantity must be more than 0 and at most %s", ErrInvalidQuantity, line.Code, produce.Quantity(MaxQuantity))
+		}
+
+		if !line.Unit.Weighed() && !line.Quantity.IsWhole() {
+			return nil, fmt.Errorf("%w: %s: quantity must be a whole number", ErrInvalidQuantity, line.Code)
+		}
+	}
+
+	return lines, nil
+}
+
+// priceLine returns line with its total at its unit price and the promotions active at now applied.
+func (s *service) priceLine(line Line, now time.Time) (Line, error) {
+	line.Total = produce.LineTotal(line.UnitPrice, line.Quantity)
+	if s.promotionSvc == nil {
+		return line, nil
+	}
+
+	var err error
+	line.Discounts, err = s.promotionSvc.Discounts(line.Code, line.Quantity, line.UnitPrice, line.Total, now)
+	if err != nil {
+		return Line{}, err
+	}
+
+	for _, d := range line.Discounts {
+		line.Total, err = line.Total.Subtract(d.Amount)
+		if err != nil {
+			return Line{}, err
+		}
+	}
+
+	return line, nil
+}
+
+// formatRate returns rate as a decimal without trailing zeros.
+func formatRate(rate *big.Rat) string {
+	s := strings.TrimRight(rate.FloatString(10), "0")
+	return strings.TrimSuffix(s, ".")
+}
@@ -0,0 +1,314 @@
+package checkout
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/Rhymond/go-money"
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/davidlick/supermarket-api/internal/promotions"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestProduceService returns a produce service on a new table holding items.
+func newTestProduceService(t *testing.T, items []produce.Item) ProduceService {
+	db := ramdb.NewDatabase()
+	assert.Nil(t, db.CreateTable("produce"))
+	assert.Nil(t, db.From("produce").CreateOrderedIndex(produce.KeyProduceCode))
+	assert.Nil(t, db.From("produce").CreateOrderedIndex(produce.KeyProduceName))
+	assert.Nil(t, db.From("produce").CreateOrderedIndex(produce.KeyProducePrice))
+
+	produceSvc := produce.NewService(db.From("produce"))
+	assert.Nil(t, produceSvc.Add(items))
+	return produceSvc
+}
+
+// newTestPromotionService returns a promotion service on a new table holding rules.
+func newTestPromotionService(t *testing.T, rules []promotions.Rule) PromotionService {
+	db := ramdb.NewDatabase()
+	assert.Nil(t, db.CreateTable("promotions"))
+	assert.Nil(t, db.From("promotions").CreateOrderedIndex(promotions.KeyPromotionID))
+	assert.Nil(t, db.From("promotions").CreateIndex(promotions.KeyPromotionCode))
+
+	promotionSvc := promotions.NewService(db.From("promotions"))
+	for _, rule := range rules {
+		_, err := promotionSvc.Create(rule)
+		assert.Nil(t, err)
+	}
+
+	return promotionSvc
+}
+
+func TestService_Checkout(t *testing.T) {
+	items := []produce.Item{
+		{Code: "A12T-4GH7-QPL9-3N4M", Name: "Lettuce", Price: money.New(346, "USD")},
+		{Code: "E5T6-9UI3-TH15-QR88", Name: "Peach", Price: money.New(299, "USD")},
+		{Code: "A12T-0000-0000-0001", Name: "Red Pepper", Price: money.New(120, "EUR")},
+		{Code: "YRT6-72AS-K736-L4AR", Name: "Green Pepper", Price: money.New(299, "USD"), Unit: produce.UnitPound},
+	}
+
+	tests := []struct {
+		test            string
+		taxRate         string
+		rules           []promotions.Rule
+		basket          []BasketItem
+		expectedReceipt Receipt
+		expectedError   error
+	}{
+		{
+			test:    "it should itemise the basket and charge tax on the subtotal",
+			taxRate: "0.0725",
+			basket: []BasketItem{
+				{Code: "A12T-4GH7-QPL9-3N4M", Quantity: produce.NewQuantity(2)},
+				{Code: "e5t6-9ui3-th15-qr88", Quantity: produce.NewQuantity(1)},
+			},
+			expectedReceipt: Receipt{
+				Lines: []Line{
+					{Code: "A12T-4GH7-QPL9-3N4M", Name: "Lettuce", Quantity: produce.NewQuantity(2), Unit: produce.UnitEach, UnitPrice: money.New(346, "USD"), Total: money.New(692, "USD")},
+					{Code: "E5T6-9UI3-TH15-QR88", Name: "Peach", Quantity: produce.NewQuantity(1), Unit: produce.UnitEach, UnitPrice: money.New(299, "USD"), Total: money.New(299, "USD")},
+				},
+				Discount: money.New(0, "USD"),
+				Subtotal: money.New(991, "USD"),
+				TaxRate:  "0.0725",
+				// 991 * 0.0725 = 71.8475, rounded to 72.
+				Tax:   money.New(72, "USD"),
+				Total: money.New(1063, "USD"),
+			},
+		},
+		{
+			test:    "it should round tax of exactly half a cent up",
+			taxRate: "0.05",
+			basket:  []BasketItem{{Code: "E5T6-9UI3-TH15-QR88", Quantity: produce.NewQuantity(1)}},
+			expectedReceipt: Receipt{
+				Lines: []Line{
+					{Code: "E5T6-9UI3-TH15-QR88", Name: "Peach", Quantity: produce.NewQuantity(1), Unit: produce.UnitEach, UnitPrice: money.New(299, "USD"), Total: money.New(299, "USD")},
+				},
+				Discount: money.New(0, "USD"),
+				Subtotal: money.New(299, "USD"),
+				TaxRate:  "0.05",
+				// 299 * 0.05 = 14.95, rounded to 15.
+				Tax:   money.New(15, "USD"),
+				Total: money.New(314, "USD"),
+			},
+		},
+		{
+			test:    "it should round tax of less than half a cent down",
+			taxRate: "0.07",
+			basket:  []BasketItem{{Code: "A12T-4GH7-QPL9-3N4M", Quantity: produce.NewQuantity(1)}},
+			expectedReceipt: Receipt{
+				Lines: []Line{
+					{Code: "A12T-4GH7-QPL9-3N4M", Name: "Lettuce", Quantity: produce.NewQuantity(1), Unit: produce.UnitEach, UnitPrice: money.New(346, "USD"), Total: money.New(346, "USD")},
+				},
+				Discount: money.New(0, "USD"),
+				Subtotal: money.New(346, "USD"),
+				TaxRate:  "0.07",
+				// 346 * 0.07 = 24.22, rounded to 24.
+				Tax:   money.New(24, "USD"),
+				Total: money.New(370, "USD"),
+			},
+		},
+		{
+			test:    "it should charge no tax at a zero rate",
+			taxRate: "0",
+			basket:  []BasketItem{{Code: "A12T-0000-0000-0001", Quantity: produce.NewQuantity(3)}},
+			expectedReceipt: Receipt{
+				Lines: []Line{
+					{Code: "A12T-0000-0000-0001", Name: "Red Pepper", Quantity: produce.NewQuantity(3), Unit: produce.UnitEach, UnitPrice: money.New(120, "EUR"), Total: money.New(360, "EUR")},
+				},
+				Discount: money.New(0, "EUR"),
+				Subtotal: money.New(360, "EUR"),
+				TaxRate:  "0",
+				Tax:      money.New(0, "EUR"),
+				Total:    money.New(360, "EUR"),
+			},
+		},
+		{
+			test:    "it should merge lines sharing a code, apply promotions and charge tax on the discounted subtotal",
+			taxRate: "0.05",
+			rules: []promotions.Rule{
+				{Name: "buy 2 get 1 free", Code: "E5T6-9UI3-TH15-QR88", Type: promotions.BuyGet, Buy: 2, Get: 1},
+				{Name: "10% off", Code: "A12T-0000-0000-0001", Type: promotions.PercentOff, Percent: 10},
+			},
+			basket: []BasketItem{
+				{Code: "E5T6-9UI3-TH15-QR88", Quantity: produce.NewQuantity(1)},
+				{Code: "A12T-4GH7-QPL9-3N4M", Quantity: produce.NewQuantity(1)},
+				{Code: "e5t6-9ui3-th15-qr88", Quantity: produce.NewQuantity(2)},
+			},
+			expectedReceipt: Receipt{
+				Lines: []Line{
+					{
+						Code:      "E5T6-9UI3-TH15-QR88",
+						Name:      "Peach",
+						Quantity:  produce.NewQuantity(3),
+						Unit:      produce.UnitEach,
+						UnitPrice: money.New(299, "USD"),
+						Discounts: []promotions.Discount{{Name: "buy 2 get 1 free", Amount: money.New(299, "USD")}},
+						Total:     money.New(598, "USD"),
+					},
+					{Code: "A12T-4GH7-QPL9-3N4M", Name: "Lettuce", Quantity: produce.NewQuantity(1), Unit: produce.UnitEach, UnitPrice: money.New(346, "USD"), Total: money.New(346, "USD")},
+				},
+				Discount: money.New(299, "USD"),
+				Subtotal: money.New(944, "USD"),
+				TaxRate:  "0.05",
+				// 944 * 0.05 = 47.2, rounded to 47.
+				Tax:   money.New(47, "USD"),
+				Total: money.New(991, "USD"),
+			},
+		},
+		{
+			test:    "it should price weighed items by fractional weight, converting other units to the priced unit",
+			taxRate: "0",
+			basket: []BasketItem{
+				{Code: "YRT6-72AS-K736-L4AR", Quantity: 1250},
+				{Code: "YRT6-72AS-K736-L4AR", Quantity: 500, Unit: produce.UnitKilogram},
+			},
+			expectedReceipt: Receipt{
+				Lines: []Line{
+					// 0.5 kg is 1.102 lb, and 2.352 lb at $2.99/lb is $7.03248.
+					{Code: "YRT6-72AS-K736-L4AR", Name: "Green Pepper", Quantity: 2352, Unit: produce.UnitPound, UnitPrice: money.New(299, "USD"), Total: money.New(703, "USD")},
+				},
+				Discount: money.New(0, "USD"),
+				Subtotal: money.New(703, "USD"),
+				TaxRate:  "0",
+				Tax:      money.New(0, "USD"),
+				Total:    money.New(703, "USD"),
+			},
+		},
+		{
+			test:          "it should return ErrInvalidQuantity for a fraction of an item that isn't weighed",
+			taxRate:       "0",
+			basket:        []BasketItem{{Code: "A12T-4GH7-QPL9-3N4M", Quantity: 1500}},
+			expectedError: ErrInvalidQuantity,
+		},
+		{
+			test:          "it should return ErrIncompatibleUnits for a weight of an item sold each",
+			taxRate:       "0",
+			basket:        []BasketItem{{Code: "A12T-4GH7-QPL9-3N4M", Quantity: produce.NewQuantity(1), Unit: produce.UnitKilogram}},
+			expectedError: produce.ErrIncompatibleUnits,
+		},
+		{
+			test:          "it should return ErrEmptyBasket for an empty basket",
+			taxRate:       "0",
+			expectedError: ErrEmptyBasket,
+		},
+		{
+			test:          "it should return ErrInvalidQuantity for a quantity that isn't positive",
+			taxRate:       "0",
+			basket:        []BasketItem{{Code: "A12T-4GH7-QPL9-3N4M", Quantity: produce.NewQuantity(0)}},
+			expectedError: ErrInvalidQuantity,
+		},
+		{
+			test:          "it should return ErrInvalidQuantity for a quantity over the maximum",
+			taxRate:       "0",
+			basket:        []BasketItem{{Code: "A12T-4GH7-QPL9-3N4M", Quantity: MaxQuantity + 1}},
+			expectedError: ErrInvalidQuantity,
+		},
+		{
+			test:    "it should return ErrInvalidQuantity for lines sharing a code over the maximum",
+			taxRate: "0",
+			basket: []BasketItem{
+				{Code: "A12T-4GH7-QPL9-3N4M", Quantity: MaxQuantity},
+				{Code: "A12T-4GH7-QPL9-3N4M", Quantity: produce.NewQuantity(1)},
+			},
+			expectedError: ErrInvalidQuantity,
+		},
+		{
+			test:    "it should return ErrInvalidQuantity for lines sharing a code that would overflow",
+			taxRate: "0",
+			basket: []BasketItem{
+				// Added up, these wrap around to one item.
+				{Code: "A12T-4GH7-QPL9-3N4M", Quantity: produce.NewQuantity(1) + 2},
+				{Code: "A12T-4GH7-QPL9-3N4M", Quantity: math.MaxInt64},
+				{Code: "A12T-4GH7-QPL9-3N4M", Quantity: math.MaxInt64},
+			},
+			expectedError: ErrInvalidQuantity,
+		},
+		{
+			test:          "it should return ErrUnknownProduce for a code that isn't catalogued",
+			taxRate:       "0",
+			basket:        []BasketItem{{Code: "ZZZZ-ZZZZ-ZZZZ-ZZZZ", Quantity: produce.NewQuantity(1)}},
+			expectedError: ErrUnknownProduce,
+		},
+		{
+			test:    "it should return ErrMixedCurrencies for items priced in different currencies",
+			taxRate: "0",
+			basket: []BasketItem{
+				{Code: "A12T-4GH7-QPL9-3N4M", Quantity: produce.NewQuantity(1)},
+				{Code: "A12T-0000-0000-0001", Quantity: produce.NewQuantity(1)},
+			},
+			expectedError: ErrMixedCurrencies,
+		},
+	}
+
+	produceSvc := newTestProduceService(t, items)
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			taxRate, err := ParseTaxRate(tc.taxRate)
+			assert.Nil(t, err)
+
+			promotionSvc := newTestPromotionService(t, tc.rules)
+			svc := NewService(produceSvc, promotionSvc, taxRate)
+			receipt, err := svc.Checkout(tc.basket)
+
+			assert.True(t, errors.Is(err, tc.expectedError), "expected %v, got %v", tc.expectedError, err)
+
+			// Rule IDs are generated, so only the rest of each discount is compared.
+			for _, line := range receipt.Lines {
+				for i := range line.Discounts {
+					line.Discounts[i].RuleID = ""
+				}
+			}
+			assert.Equal(t, tc.expectedReceipt, receipt)
+		})
+	}
+}
+
+func TestParseTaxRate(t *testing.T) {
+	tests := []struct {
+		test          string
+		rate          string
+		expectedRate  *big.Rat
+		expectedError error
+	}{
+		{
+			test:         "it should parse a decimal rate",
+			rate:         "0.0725",
+			expectedRate: big.NewRat(29, 400),
+		},
+		{
+			test:         "it should parse a zero rate",
+			rate:         "0",
+			expectedRate: big.NewRat(0, 1),
+		},
+		{
+			test:          "it should return ErrInvalidTaxRate for a negative rate",
+			rate:          "-0.1",
+			expectedError: ErrInvalidTaxRate,
+		},
+		{
+			test:          "it should return ErrInvalidTaxRate for a rate over 1",
+			rate:          "7.25",
+			expectedError: ErrInvalidTaxRate,
+		},
+		{
+			test:          "it should return ErrInvalidTaxRate for a rate that isn't a number",
+			rate:          "seven",
+			expectedError: ErrInvalidTaxRate,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			rate, err := ParseTaxRate(tc.rate)
+
+			assert.True(t, errors.Is(err, tc.expectedError), "expected %v, got %v", tc.expectedError, err)
+			if tc.expectedRate != nil {
+				assert.Equal(t, 0, tc.expectedRate.Cmp(rate))
+			}
+		})
+	}
+}
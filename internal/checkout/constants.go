@@ -0,0 +1,6 @@
+package checkout
+
+import "github.com/davidlick/supermarket-api/internal/produce"
+
+// MaxQuantity is the most of one produce item a basket line can hold, in the item's unit.
+const MaxQuantity = 10000 * produce.QuantityScale
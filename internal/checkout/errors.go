@@ -0,0 +1,12 @@
+package checkout
+
+import "errors"
+
+var (
+	ErrEmptyBasket     = errors.New("basket is empty")
+	ErrInvalidQuantity = errors.New("invalid quantity")
+	ErrUnknownProduce  = errors.New("unknown produce item")
+	ErrUnpricedProduce = errors.New("produce item has no price")
+	ErrMixedCurrencies = errors.New("basket holds items priced in different currencies")
+	ErrInvalidTaxRate  = errors.New("invalid tax rate")
+)
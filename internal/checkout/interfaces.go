@@ -0,0 +1,17 @@
+package checkout
+
+import (
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/davidlick/supermarket-api/internal/promotions"
+)
+
+type ProduceService interface {
+	Get(produceCode string) (item produce.Item, err error)
+}
+
+type PromotionService interface {
+	Discounts(produceCode string, quantity produce.Quantity, unitPrice, total *money.Money, at time.Time) ([]promotions.Discount, error)
+}
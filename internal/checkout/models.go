@@ -0,0 +1,41 @@
+package checkout
+
+import (
+	"github.com/Rhymond/go-money"
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/davidlick/supermarket-api/internal/promotions"
+)
+
+// BasketItem is a quantity of one produce item in a basket. Quantity is in Unit, or in the unit the item is priced per
+// if Unit is empty.
+type BasketItem struct {
+	Code     string           `json:"code"`
+	Quantity produce.Quantity `json:"quantity"`
+	Unit     produce.Unit     `json:"unit,omitempty"`
+}
+
+// Line is the price of one BasketItem on a Receipt.
+type Line struct {
+	Code string `json:"code"`
+	Name string `json:"name"`
+	// Quantity is in Unit, the unit the item is priced per, and UnitPrice is the price of one Unit.
+	Quantity  produce.Quantity `json:"quantity"`
+	Unit      produce.Unit     `json:"unit,omitempty"`
+	UnitPrice *money.Money     `json:"unit_price"`
+	// Discounts are the promotions applied to the line, in the order they were applied.
+	Discounts []promotions.Discount `json:"discounts,omitempty"`
+	// Total is the line total after Discounts.
+	Total *money.Money `json:"total"`
+}
+
+// Receipt is an itemised price of a basket.
+type Receipt struct {
+	Lines []Line `json:"lines"`
+	// Discount is the sum of every line's Discounts, which Subtotal is net of.
+	Discount *money.Money `json:"discount"`
+	Subtotal *money.Money `json:"subtotal"`
+	// TaxRate is the sales tax rate applied to Subtotal as a decimal fraction, such as "0.0725".
+	TaxRate string       `json:"tax_rate"`
+	Tax     *money.Money `json:"tax"`
+	Total   *money.Money `json:"total"`
+}
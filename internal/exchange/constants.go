@@ -0,0 +1,6 @@
+package exchange
+
+const (
+	// KeyRatePair is the column rates are keyed by, such as "USD:EUR" for the rate from USD to EUR.
+	KeyRatePair = "pair"
+)
@@ -0,0 +1,8 @@
+package exchange
+
+import "errors"
+
+var (
+	ErrNoRate      = errors.New("no exchange rate")
+	ErrInvalidRate = errors.New("invalid exchange rate")
+)
@@ -0,0 +1,161 @@
+package exchange
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/davidlick/supermarket-api/internal/interfaces"
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+)
+
+type service struct {
+	db  interfaces.RamDB
+	now func() time.Time
+}
+
+// NewService creates a new exchange service storing exchange rates in db.
+func NewService(db interfaces.RamDB) *service {
+	return &service{
+		db:  db,
+		now: time.Now,
+	}
+}
+
+// Set stores rate, replacing any rate already stored between the same currencies, and returns it as stored. It returns
+// an error matching ErrInvalidRate if either currency is unknown, the currencies are the same or the rate is not a
+// positive decimal.
+func (s *service) Set(rate Rate) (Rate, error) {
+	rate.From = strings.ToUpper(strings.TrimSpace(rate.From))
+	rate.To = strings.ToUpper(strings.TrimSpace(rate.To))
+	rate.Rate = strings.TrimSpace(rate.Rate)
+
+	_, err := parseRate(rate)
+	if err != nil {
+		return Rate{}, err
+	}
+
+	rate.UpdatedAt = s.now().UTC()
+	rec, err := ramdb.NewRecord(pair(rate.From, rate.To), KeyRatePair, rate)
+	if err != nil {
+		return Rate{}, err
+	}
+
+	err = s.db.Upsert(rec)
+	if err != nil {
+		return Rate{}, err
+	}
+
+	return rate, nil
+}
+
+// Get returns the rate from one currency to another. It returns an error matching ErrNoRate if no rate is stored.
+func (s *service) Get(from, to string) (rate Rate, err error) {
+	from, to = strings.ToUpper(from), strings.ToUpper(to)
+
+	rec, err := s.db.Get(KeyRatePair, pair(from, to))
+	if err == ramdb.ErrNoRecord {
+		return Rate{}, fmt.Errorf("%w: %s to %s", ErrNoRate, from, to)
+	}
+	if err != nil {
+		return Rate{}, err
+	}
+
+	err = rec.Deserialize(&rate)
+	return
+}
+
+// All returns every rate sorted by the currencies it converts between.
+func (s *service) All() (rates []Rate, err error) {
+	recs, err := s.db.Scan(KeyRatePair, ramdb.ScanOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rec := range recs {
+		var rate Rate
+		err = rec.Deserialize(&rate)
+		if err != nil {
+			return nil, err
+		}
+
+		rates = append(rates, rate)
+	}
+
+	return rates, nil
+}
+
+// Convert converts price into the currency with code to and returns the converted price with the Conversion made. The
+// amount is multiplied by the exact rate, scaled between the minor units of the two currencies and rounded half up to
+// the nearest minor unit of to. Prices already in to are returned unchanged. It returns an error matching ErrNoRate if
+// there is no rate from the price's currency to to.
+func (s *service) Convert(price *money.Money, to string) (*money.Money, Conversion, error) {
+	from := price.Currency().Code
+	to = strings.ToUpper(strings.TrimSpace(to))
+
+	if from == to {
+		return price, Conversion{From: from, To: to, Rate: "1", Original: price}, nil
+	}
+
+	rate, err := s.Get(from, to)
+	if err != nil {
+		return nil, Conversion{}, err
+	}
+
+	r, err := parseRate(rate)
+	if err != nil {
+		return nil, Conversion{}, err
+	}
+
+	// Scale between the minor units of the currencies, such as cents to yen.
+	switch scale := money.GetCurrency(to).Fraction - money.GetCurrency(from).Fraction; {
+	case scale > 0:
+		r.Mul(r, new(big.Rat).SetInt(pow10(scale)))
+	case scale < 0:
+		r.Quo(r, new(big.Rat).SetInt(pow10(-scale)))
+	}
+
+	amount := produce.RoundHalfUp(new(big.Rat).Mul(r, new(big.Rat).SetInt64(price.Amount())))
+	updatedAt := rate.UpdatedAt
+
+	return money.New(amount, to), Conversion{
+		From:          from,
+		To:            to,
+		Rate:          rate.Rate,
+		Original:      price,
+		RateUpdatedAt: &updatedAt,
+	}, nil
+}
+
+// parseRate checks rate converts between two different known currencies and returns its value.
+func parseRate(rate Rate) (*big.Rat, error) {
+	for _, code := range []string{rate.From, rate.To} {
+		if money.GetCurrency(code) == nil {
+			return nil, fmt.Errorf("%w: %q is not a known currency", ErrInvalidRate, code)
+		}
+	}
+
+	if rate.From == rate.To {
+		return nil, fmt.Errorf("%w: %s can't be converted to itself", ErrInvalidRate, rate.From)
+	}
+
+	r, ok := new(big.Rat).SetString(rate.Rate)
+	if !ok || strings.ContainsAny(rate.Rate, "/eE") || r.Sign() <= 0 {
+		return nil, fmt.Errorf("%w: %q must be a positive decimal", ErrInvalidRate, rate.Rate)
+	}
+
+	return r, nil
+}
+
+// pair returns the key of the rate from one currency to another.
+func pair(from, to string) string {
+	return from + ":" + to
+}
+
+// pow10 returns 10 to the power of n, which must not be negative.
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
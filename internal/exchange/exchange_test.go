@@ -0,0 +1,161 @@
+package exchange
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+	"github.com/stretchr/testify/assert"
+)
+
+var testNow = time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// newTestService returns an exchange service on a new table, with the time fixed at testNow.
+func newTestService(t *testing.T) *service {
+	db := ramdb.NewDatabase()
+	assert.Nil(t, db.CreateTable("rates"))
+	assert.Nil(t, db.From("rates").CreateOrderedIndex(KeyRatePair))
+
+	s := NewService(db.From("rates"))
+	s.now = func() time.Time { return testNow }
+	return s
+}
+
+func TestService_Set(t *testing.T) {
+	tests := []struct {
+		test          string
+		rate          Rate
+		expectedRate  Rate
+		expectedError error
+	}{
+		{
+			test:         "it should store a rate with uppercased currencies",
+			rate:         Rate{From: "usd", To: "eur", Rate: " 0.92 "},
+			expectedRate: Rate{From: "USD", To: "EUR", Rate: "0.92", UpdatedAt: testNow},
+		},
+		{
+			test:          "it should return ErrInvalidRate for an unknown currency",
+			rate:          Rate{From: "USD", To: "XYZ", Rate: "0.92"},
+			expectedError: ErrInvalidRate,
+		},
+		{
+			test:          "it should return ErrInvalidRate for a rate between the same currency",
+			rate:          Rate{From: "USD", To: "USD", Rate: "1"},
+			expectedError: ErrInvalidRate,
+		},
+		{
+			test:          "it should return ErrInvalidRate for a rate that isn't positive",
+			rate:          Rate{From: "USD", To: "EUR", Rate: "0"},
+			expectedError: ErrInvalidRate,
+		},
+		{
+			test:          "it should return ErrInvalidRate for a rate that isn't a decimal",
+			rate:          Rate{From: "USD", To: "EUR", Rate: "23/25"},
+			expectedError: ErrInvalidRate,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			s := newTestService(t)
+
+			rate, err := s.Set(tc.rate)
+			assert.True(t, errors.Is(err, tc.expectedError), "expected %v, got %v", tc.expectedError, err)
+			if tc.expectedError != nil {
+				return
+			}
+
+			assert.Equal(t, tc.expectedRate, rate)
+
+			stored, err := s.Get("USD", "EUR")
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedRate, stored)
+		})
+	}
+
+	t.Run("it should replace the stored rate", func(t *testing.T) {
+		s := newTestService(t)
+
+		_, err := s.Set(Rate{From: "USD", To: "EUR", Rate: "0.92"})
+		assert.Nil(t, err)
+		_, err = s.Set(Rate{From: "USD", To: "EUR", Rate: "0.9"})
+		assert.Nil(t, err)
+
+		rates, err := s.All()
+		assert.Nil(t, err)
+		assert.Equal(t, []Rate{{From: "USD", To: "EUR", Rate: "0.9", UpdatedAt: testNow}}, rates)
+	})
+}
+
+func TestService_Convert(t *testing.T) {
+	tests := []struct {
+		test               string
+		price              *money.Money
+		to                 string
+		expectedPrice      *money.Money
+		expectedConversion Conversion
+		expectedError      error
+	}{
+		{
+			test:          "it should convert at the stored rate",
+			price:         money.New(346, "USD"),
+			to:            "eur",
+			expectedPrice: money.New(318, "EUR"),
+			expectedConversion: Conversion{
+				From: "USD", To: "EUR", Rate: "0.92", Original: money.New(346, "USD"), RateUpdatedAt: &testNow,
+			},
+		},
+		{
+			test:          "it should round half up to the nearest minor unit",
+			price:         money.New(250, "USD"),
+			to:            "GBP",
+			expectedPrice: money.New(197, "GBP"),
+			expectedConversion: Conversion{
+				From: "USD", To: "GBP", Rate: "0.786", Original: money.New(250, "USD"), RateUpdatedAt: &testNow,
+			},
+		},
+		{
+			test:          "it should scale between currencies with different minor units",
+			price:         money.New(346, "USD"),
+			to:            "JPY",
+			expectedPrice: money.New(377, "JPY"),
+			expectedConversion: Conversion{
+				From: "USD", To: "JPY", Rate: "108.95", Original: money.New(346, "USD"), RateUpdatedAt: &testNow,
+			},
+		},
+		{
+			test:               "it should leave a price already in the currency unchanged",
+			price:              money.New(346, "USD"),
+			to:                 "USD",
+			expectedPrice:      money.New(346, "USD"),
+			expectedConversion: Conversion{From: "USD", To: "USD", Rate: "1", Original: money.New(346, "USD")},
+		},
+		{
+			test:          "it should return ErrNoRate if there is no rate to the currency",
+			price:         money.New(346, "EUR"),
+			to:            "USD",
+			expectedError: ErrNoRate,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			s := newTestService(t)
+			for _, rate := range []Rate{
+				{From: "USD", To: "EUR", Rate: "0.92"},
+				{From: "USD", To: "GBP", Rate: "0.786"},
+				{From: "USD", To: "JPY", Rate: "108.95"},
+			} {
+				_, err := s.Set(rate)
+				assert.Nil(t, err)
+			}
+
+			price, conversion, err := s.Convert(tc.price, tc.to)
+			assert.True(t, errors.Is(err, tc.expectedError), "expected %v, got %v", tc.expectedError, err)
+			assert.Equal(t, tc.expectedPrice, price)
+			assert.Equal(t, tc.expectedConversion, conversion)
+		})
+	}
+}
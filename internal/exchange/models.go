@@ -0,0 +1,25 @@
+package exchange
+
+import (
+	"time"
+
+	"github.com/Rhymond/go-money"
+)
+
+// Rate is how many units of To one unit of From is worth, written as a decimal such as "0.92".
+type Rate struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Rate      string    `json:"rate"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Conversion records how a price was converted, so the rate used can be shown alongside the converted price.
+type Conversion struct {
+	From     string       `json:"from"`
+	To       string       `json:"to"`
+	Rate     string       `json:"rate"`
+	Original *money.Money `json:"original"`
+	// RateUpdatedAt is when the rate was last set. It is nil when the price was already in the requested currency.
+	RateUpdatedAt *time.Time `json:"rate_updated_at,omitempty"`
+}
@@ -0,0 +1,102 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/davidlick/supermarket-api/internal/auth"
+)
+
+// apiKeyHeader is the request header an API key token can be sent in instead of the Authorization header.
+const apiKeyHeader = "X-API-Key"
+
+// authenticate is a middleware that identifies who a request is made by from its API key or bearer token, and adds
+// their identity to the request's context. Requests without a valid token are responded to with 401 Unauthorized.
+func (s *server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		token := bearerToken(r)
+		if token == "" {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="supermarket-api"`)
+			s.writeError(ctx, w, ErrUnauthenticated, http.StatusUnauthorized)
+			return
+		}
+
+		id, err := s.identify(token)
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="supermarket-api", error="invalid_token"`)
+			s.writeError(ctx, w, err, errorStatus(err))
+			return
+		}
+
+		s.requestLogger(ctx).Debugf("authenticated as %s with role %s", id, id.Role)
+		next.ServeHTTP(w, r.WithContext(auth.NewContext(ctx, id)))
+	})
+}
+
+// authorize is a middleware that only lets a request through if its identity has the read role for GET and HEAD
+// requests, or the write role for any other request, and responds with 403 Forbidden otherwise. It lets every request
+// through if the server doesn't authenticate requests.
+func (s *server) authorize(read, write string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !s.authenticates() {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			required := write
+			if r.Method == http.MethodGet || r.Method == http.MethodHead {
+				required = read
+			}
+
+			id, ok := auth.FromContext(r.Context())
+			if !ok || !auth.Allows(id.Role, required) {
+				s.writeError(r.Context(), w, ErrForbidden, http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// identify returns the identity of the API key token was issued to, or, if it is not an API key token, the identity
+// the token verifier finds in it. It returns auth.ErrInvalidToken if the server can't verify that kind of token.
+func (s *server) identify(token string) (auth.Identity, error) {
+	if auth.IsToken(token) {
+		if s.keySvc == nil {
+			return auth.Identity{}, auth.ErrInvalidToken
+		}
+
+		return s.keySvc.Authenticate(token)
+	}
+
+	if s.verifier == nil {
+		return auth.Identity{}, auth.ErrInvalidToken
+	}
+
+	return s.verifier.Verify(token)
+}
+
+// bearerToken returns the token a request is made with, from either its Authorization header or its X-API-Key header,
+// or "" if it has neither.
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	if len(header) > len("Bearer ") && strings.EqualFold(header[:len("Bearer ")], "Bearer ") {
+		return strings.TrimSpace(header[len("Bearer "):])
+	}
+
+	return strings.TrimSpace(r.Header.Get(apiKeyHeader))
+}
+
+// author returns who made a request for the audit trail: the identity it was made by if it was authenticated, or
+// else its X-Author header.
+func author(r *http.Request) string {
+	if id, ok := auth.FromContext(r.Context()); ok {
+		return id.String()
+	}
+
+	return r.Header.Get(authorHeader)
+}
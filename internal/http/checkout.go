@@ -0,0 +1,47 @@
+package http
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/davidlick/supermarket-api/internal/auth"
+	"github.com/davidlick/supermarket-api/internal/checkout"
+	"github.com/go-chi/chi"
+)
+
+// checkoutRequest is the body of a request to price a basket.
+type checkoutRequest struct {
+	Items []checkout.BasketItem `json:"items"`
+}
+
+func (s *server) checkoutGroup(r chi.Router) {
+	// Pricing a basket changes nothing, so readers can check out.
+	r.With(s.authorize(auth.RoleReader, auth.RoleReader)).Post("/checkout", s.handleCheckout)
+}
+
+func (s *server) handleCheckout(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		s.writeError(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	var req checkoutRequest
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		s.writeError(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	receipt, err := s.checkoutSvc.Checkout(req.Items)
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	s.writeSuccess(ctx, w, receipt, http.StatusOK)
+	return
+}
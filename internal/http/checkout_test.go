@@ -0,0 +1,129 @@
+package http
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Rhymond/go-money"
+	"github.com/davidlick/supermarket-api/internal/checkout"
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_handleCheckout(t *testing.T) {
+	tests := []struct {
+		test       string
+		body       string
+		expectFunc func(mockCheckoutSvc *MockCheckoutService)
+		assertFunc func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			test: "it should respond with the receipt for the basket",
+			body: `{"items":[{"code":"test-code","quantity":2}]}`,
+			expectFunc: func(mockCheckoutSvc *MockCheckoutService) {
+				mockCheckoutSvc.EXPECT().Checkout([]checkout.BasketItem{{Code: "test-code", Quantity: produce.NewQuantity(2)}}).Return(checkout.Receipt{
+					Lines: []checkout.Line{
+						{Code: "test-code", Name: "test", Quantity: produce.NewQuantity(2), Unit: produce.UnitEach, UnitPrice: money.New(101, "USD"), Total: money.New(202, "USD")},
+					},
+					Discount: money.New(0, "USD"),
+					Subtotal: money.New(202, "USD"),
+					TaxRate:  "0.05",
+					Tax:      money.New(10, "USD"),
+					Total:    money.New(212, "USD"),
+				}, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+
+				b, err := ioutil.ReadAll(w.Body)
+				if err != nil {
+					t.Error(err)
+				}
+
+				assert.Equal(t, "{\"lines\":[{\"code\":\"test-code\",\"name\":\"test\",\"quantity\":2,\"unit\":\"each\",\"unit_price\":{\"amount\":101,\"currency\":\"USD\"},\"total\":{\"amount\":202,\"currency\":\"USD\"}}],\"discount\":{\"amount\":0,\"currency\":\"USD\"},\"subtotal\":{\"amount\":202,\"currency\":\"USD\"},\"tax_rate\":\"0.05\",\"tax\":{\"amount\":10,\"currency\":\"USD\"},\"total\":{\"amount\":212,\"currency\":\"USD\"}}\n", string(b))
+			},
+		},
+		{
+			test:       "it should respond bad request if the body isn't a basket",
+			body:       `[]`,
+			expectFunc: func(mockCheckoutSvc *MockCheckoutService) {},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			test: "it should respond unprocessable entity if a code isn't catalogued",
+			body: `{"items":[{"code":"test-code","quantity":1}]}`,
+			expectFunc: func(mockCheckoutSvc *MockCheckoutService) {
+				mockCheckoutSvc.EXPECT().Checkout(gomock.Any()).Return(checkout.Receipt{}, fmt.Errorf("%w: test-code", checkout.ErrUnknownProduce))
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				assert.Contains(t, w.Body.String(), `"code":"unknown_produce"`)
+			},
+		},
+		{
+			test: "it should respond unprocessable entity if the basket mixes currencies",
+			body: `{"items":[{"code":"test-code","quantity":1},{"code":"other-code","quantity":1}]}`,
+			expectFunc: func(mockCheckoutSvc *MockCheckoutService) {
+				mockCheckoutSvc.EXPECT().Checkout(gomock.Any()).Return(checkout.Receipt{}, checkout.ErrMixedCurrencies)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				assert.Contains(t, w.Body.String(), `"code":"mixed_currencies"`)
+			},
+		},
+		{
+			test: "it should respond unprocessable entity if a quantity is in a unit the item can't be converted to",
+			body: `{"items":[{"code":"test-code","quantity":0.5,"unit":"kg"}]}`,
+			expectFunc: func(mockCheckoutSvc *MockCheckoutService) {
+				mockCheckoutSvc.EXPECT().Checkout([]checkout.BasketItem{{Code: "test-code", Quantity: 500, Unit: produce.UnitKilogram}}).
+					Return(checkout.Receipt{}, fmt.Errorf("%w: kg to each", produce.ErrIncompatibleUnits))
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				assert.Contains(t, w.Body.String(), `"code":"incompatible_units"`)
+			},
+		},
+		{
+			test: "it should respond internal server error if pricing fails",
+			body: `{"items":[{"code":"test-code","quantity":1}]}`,
+			expectFunc: func(mockCheckoutSvc *MockCheckoutService) {
+				mockCheckoutSvc.EXPECT().Checkout(gomock.Any()).Return(checkout.Receipt{}, errors.New("test error"))
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, w.Code)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			r := httptest.NewRequest(http.MethodPost, "/v1/checkout", strings.NewReader(tc.body))
+			w := httptest.NewRecorder()
+
+			noopLogger := logrus.New()
+			noopLogger.SetOutput(ioutil.Discard)
+
+			mockCheckoutSvc := NewMockCheckoutService(ctrl)
+			tc.expectFunc(mockCheckoutSvc)
+
+			s := NewServer(3000, noopLogger, "test", nil, WithCheckoutService(mockCheckoutSvc))
+
+			handler := http.HandlerFunc(s.handleCheckout)
+			handler.ServeHTTP(w, r)
+
+			tc.assertFunc(t, w)
+		})
+	}
+}
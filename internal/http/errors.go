@@ -1,8 +1,104 @@
 package http
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/davidlick/supermarket-api/internal/auth"
+	"github.com/davidlick/supermarket-api/internal/checkout"
+	"github.com/davidlick/supermarket-api/internal/exchange"
+	"github.com/davidlick/supermarket-api/internal/inventory"
+	"github.com/davidlick/supermarket-api/internal/prices"
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/davidlick/supermarket-api/internal/promotions"
+	"github.com/davidlick/supermarket-api/internal/stores"
+	"github.com/davidlick/supermarket-api/internal/webhooks"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+)
 
 var (
 	ErrUnknownError     = errors.New("unknown error occurred")
 	ErrUnrecognizedCode = errors.New("unrecognized status code")
+	ErrCodeMismatch     = errors.New("produce code in body does not match the url")
+	ErrInvalidQuery     = errors.New("invalid query parameter")
+	ErrIDMismatch       = errors.New("id in body does not match the url")
+	ErrInvalidEventID   = errors.New("invalid Last-Event-ID")
+	ErrNoStreaming      = errors.New("response writer does not support streaming")
+	ErrUnauthenticated  = errors.New("an API key or bearer token is required")
+	ErrForbidden        = errors.New("the role of the API key or bearer token does not allow this request")
 )
+
+// knownErrors maps errors to the status code responded with and the machine-readable code sent in the response.
+var knownErrors = []struct {
+	err    error
+	status int
+	code   string
+}{
+	{ErrUnauthenticated, http.StatusUnauthorized, "unauthenticated"},
+	{auth.ErrInvalidToken, http.StatusUnauthorized, "invalid_token"},
+	{ErrForbidden, http.StatusForbidden, "forbidden"},
+	{ramdb.ErrNoRecord, http.StatusNotFound, "not_found"},
+	{prices.ErrNoPrice, http.StatusNotFound, "no_price"},
+	{ramdb.ErrRecordExists, http.StatusConflict, "already_exists"},
+	{ramdb.ErrVersionMismatch, http.StatusConflict, "version_mismatch"},
+	{produce.ErrInvalidItem, http.StatusUnprocessableEntity, "invalid_item"},
+	{produce.ErrInvalidSort, http.StatusBadRequest, "invalid_sort"},
+	{ramdb.ErrInvalidCursor, http.StatusBadRequest, "invalid_cursor"},
+	{ramdb.ErrCursorMismatch, http.StatusBadRequest, "cursor_mismatch"},
+	{produce.ErrCurrencyRequired, http.StatusBadRequest, "currency_required"},
+	{checkout.ErrEmptyBasket, http.StatusUnprocessableEntity, "empty_basket"},
+	{checkout.ErrInvalidQuantity, http.StatusUnprocessableEntity, "invalid_quantity"},
+	{checkout.ErrUnknownProduce, http.StatusUnprocessableEntity, "unknown_produce"},
+	{checkout.ErrUnpricedProduce, http.StatusUnprocessableEntity, "unpriced_produce"},
+	{checkout.ErrMixedCurrencies, http.StatusUnprocessableEntity, "mixed_currencies"},
+	{produce.ErrIncompatibleUnits, http.StatusUnprocessableEntity, "incompatible_units"},
+	{promotions.ErrInvalidRule, http.StatusUnprocessableEntity, "invalid_rule"},
+	{exchange.ErrNoRate, http.StatusUnprocessableEntity, "no_exchange_rate"},
+	{exchange.ErrInvalidRate, http.StatusUnprocessableEntity, "invalid_rate"},
+	{inventory.ErrInvalidQuantity, http.StatusUnprocessableEntity, "invalid_quantity"},
+	{inventory.ErrInsufficientStock, http.StatusConflict, "insufficient_stock"},
+	{inventory.ErrInStock, http.StatusConflict, "in_stock"},
+	{stores.ErrInvalidStore, http.StatusUnprocessableEntity, "invalid_store"},
+	{webhooks.ErrInvalidSubscription, http.StatusUnprocessableEntity, "invalid_subscription"},
+	{auth.ErrInvalidKey, http.StatusUnprocessableEntity, "invalid_key"},
+	{auth.ErrKeyRevoked, http.StatusConflict, "key_revoked"},
+	{ErrInvalidQuery, http.StatusBadRequest, "invalid_query"},
+	{ErrCodeMismatch, http.StatusBadRequest, "code_mismatch"},
+	{ErrIDMismatch, http.StatusBadRequest, "id_mismatch"},
+	{ErrInvalidEventID, http.StatusBadRequest, "invalid_event_id"},
+}
+
+// errorStatus returns the status code for an error returned by a service, or http.StatusInternalServerError if the
+// error is not a known one.
+func errorStatus(err error) int {
+	for _, ke := range knownErrors {
+		if errors.Is(err, ke.err) {
+			return ke.status
+		}
+	}
+
+	return http.StatusInternalServerError
+}
+
+// decodeStatus returns the status code for an error decoding a request body: http.StatusUnprocessableEntity for
+// ValidationErrors and http.StatusBadRequest for anything else.
+func decodeStatus(err error) int {
+	if errors.Is(err, produce.ErrInvalidItem) {
+		return http.StatusUnprocessableEntity
+	}
+
+	return http.StatusBadRequest
+}
+
+// errorCode returns the machine-readable code for err. Errors that are not known ones are given a code made from the
+// status text, such as "bad_request".
+func errorCode(err error, status int) string {
+	for _, ke := range knownErrors {
+		if errors.Is(err, ke.err) {
+			return ke.code
+		}
+	}
+
+	return strings.ToLower(strings.ReplaceAll(http.StatusText(status), " ", "_"))
+}
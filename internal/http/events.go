@@ -0,0 +1,106 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+)
+
+const (
+	// DefaultEventHeartbeat is how often event streams send a heartbeat unless set with WithEventHeartbeat.
+	DefaultEventHeartbeat = 15 * time.Second
+	// eventRetry is how long clients are told to wait before reconnecting to an event stream.
+	eventRetry = time.Second
+	// eventStreamMargin is how long before the server's write timeout an event stream is ended, so clients reconnect
+	// instead of having the connection cut.
+	eventStreamMargin = 5 * time.Second
+)
+
+// handleProduceEvents streams changes to the produce catalogue as server-sent events. A client reconnecting with the
+// Last-Event-ID header resumes after that event, or is sent a reset event if the changes since then are no longer
+// kept and it should fetch the catalogue again.
+func (s *server) handleProduceEvents(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		s.writeError(ctx, w, ErrNoStreaming, http.StatusInternalServerError)
+		return
+	}
+
+	var from uint64
+	if id := r.Header.Get("Last-Event-ID"); id != "" {
+		last, err := strconv.ParseUint(id, 10, 64)
+		if err != nil {
+			s.writeError(ctx, w, ErrInvalidEventID, errorStatus(ErrInvalidEventID))
+			return
+		}
+
+		from = last + 1
+	}
+
+	events, err := s.produceSvc.Watch(ctx, from)
+	reset := errors.Is(err, ramdb.ErrSequenceUnavailable)
+	if reset {
+		events, err = s.produceSvc.Watch(ctx, 0)
+	}
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprintf(w, "retry: %d\n\n", eventRetry.Milliseconds())
+	if reset {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(s.heartbeat)
+	defer heartbeat.Stop()
+
+	var expired <-chan time.Time
+	if s.server.WriteTimeout > eventStreamMargin {
+		timer := time.NewTimer(s.server.WriteTimeout - eventStreamMargin)
+		defer timer.Stop()
+		expired = timer.C
+	}
+
+	for {
+		select {
+		case e, ok := <-events:
+			if !ok {
+				return
+			}
+
+			data, err := json.Marshal(e.Item)
+			if err != nil {
+				s.requestLogger(ctx).Errorf("could not encode event %d: %v", e.ID, err)
+				continue
+			}
+
+			fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": heartbeat\n\n")
+		case <-expired:
+			return
+		case <-s.shutdown:
+			return
+		case <-ctx.Done():
+			return
+		}
+
+		flusher.Flush()
+	}
+}
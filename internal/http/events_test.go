@@ -0,0 +1,203 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+// closedEvents returns a closed channel holding events.
+func closedEvents(events ...produce.Event) <-chan produce.Event {
+	ch := make(chan produce.Event, len(events))
+	for _, e := range events {
+		ch <- e
+	}
+	close(ch)
+
+	return ch
+}
+
+func TestServer_handleProduceEvents(t *testing.T) {
+	tests := []struct {
+		test        string
+		lastEventID string
+		expectFunc  func(mockProduceSvc *MockProduceService)
+		assertFunc  func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			test: "it should stream events until the service closes them",
+			expectFunc: func(mockProduceSvc *MockProduceService) {
+				mockProduceSvc.EXPECT().Watch(gomock.Any(), uint64(0)).Return(closedEvents(
+					produce.Event{ID: 1, Type: produce.EventAdd, Item: produce.Item{Code: "code-1", Name: "name-1", Price: money.New(101, "USD"), Version: 1}},
+					produce.Event{ID: 2, Type: produce.EventDelete, Item: produce.Item{Code: "code-1", Name: "name-1", Price: money.New(101, "USD"), Version: 1}},
+				), nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Equal(t, "text/event-stream", w.Header().Get("Content-Type"))
+
+				b, err := ioutil.ReadAll(w.Body)
+				if err != nil {
+					t.Error(err)
+				}
+
+				assert.Equal(t, "retry: 1000\n\n"+
+					"id: 1\nevent: add\ndata: {\"code\":\"code-1\",\"name\":\"name-1\",\"price\":{\"amount\":101,\"currency\":\"USD\"},\"version\":1}\n\n"+
+					"id: 2\nevent: delete\ndata: {\"code\":\"code-1\",\"name\":\"name-1\",\"price\":{\"amount\":101,\"currency\":\"USD\"},\"version\":1}\n\n", string(b))
+			},
+		},
+		{
+			test:        "it should resume after the Last-Event-ID",
+			lastEventID: "7",
+			expectFunc: func(mockProduceSvc *MockProduceService) {
+				mockProduceSvc.EXPECT().Watch(gomock.Any(), uint64(8)).Return(closedEvents(), nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			test:        "it should send a reset event if the events since the Last-Event-ID are not kept",
+			lastEventID: "7",
+			expectFunc: func(mockProduceSvc *MockProduceService) {
+				mockProduceSvc.EXPECT().Watch(gomock.Any(), uint64(8)).Return(nil, ramdb.ErrSequenceUnavailable)
+				mockProduceSvc.EXPECT().Watch(gomock.Any(), uint64(0)).Return(closedEvents(), nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+
+				b, err := ioutil.ReadAll(w.Body)
+				if err != nil {
+					t.Error(err)
+				}
+
+				assert.Equal(t, "retry: 1000\n\nevent: reset\ndata: {}\n\n", string(b))
+			},
+		},
+		{
+			test:        "it should respond bad request if the Last-Event-ID is not an event id",
+			lastEventID: "abc",
+			expectFunc:  func(mockProduceSvc *MockProduceService) {},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			test: "it should respond internal server error if watching fails",
+			expectFunc: func(mockProduceSvc *MockProduceService) {
+				mockProduceSvc.EXPECT().Watch(gomock.Any(), uint64(0)).Return(nil, errors.New("test error"))
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, w.Code)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			r := httptest.NewRequest(http.MethodGet, "/v1/produce/events", nil)
+			if tc.lastEventID != "" {
+				r.Header.Set("Last-Event-ID", tc.lastEventID)
+			}
+			w := httptest.NewRecorder()
+
+			noopLogger := logrus.New()
+			noopLogger.SetOutput(ioutil.Discard)
+
+			mockProduceSvc := NewMockProduceService(ctrl)
+			tc.expectFunc(mockProduceSvc)
+
+			s := NewServer(3000, noopLogger, "test", mockProduceSvc)
+
+			handler := http.HandlerFunc(s.handleProduceEvents)
+			handler.ServeHTTP(w, r)
+
+			tc.assertFunc(t, w)
+		})
+	}
+}
+
+func TestServer_handleProduceEvents_Stream(t *testing.T) {
+	// serve runs the handler until it returns, calling during while it is streaming.
+	serve := func(t *testing.T, s *server, during func()) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/v1/produce/events", nil)
+		w := httptest.NewRecorder()
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			s.handleProduceEvents(w, r)
+		}()
+
+		during()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("the stream did not end")
+		}
+
+		return w
+	}
+
+	t.Run("it should send heartbeats while there are no events", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		noopLogger := logrus.New()
+		noopLogger.SetOutput(ioutil.Discard)
+
+		events := make(chan produce.Event)
+		mockProduceSvc := NewMockProduceService(ctrl)
+		mockProduceSvc.EXPECT().Watch(gomock.Any(), uint64(0)).Return((<-chan produce.Event)(events), nil)
+
+		s := NewServer(3000, noopLogger, "test", mockProduceSvc, WithEventHeartbeat(5*time.Millisecond))
+
+		w := serve(t, s, func() {
+			time.Sleep(50 * time.Millisecond)
+			close(events)
+		})
+
+		b, err := ioutil.ReadAll(w.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		assert.Contains(t, string(b), ": heartbeat\n\n")
+	})
+
+	t.Run("it should end the stream when the server shuts down", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		noopLogger := logrus.New()
+		noopLogger.SetOutput(ioutil.Discard)
+
+		events := make(chan produce.Event)
+		mockProduceSvc := NewMockProduceService(ctrl)
+		mockProduceSvc.EXPECT().Watch(gomock.Any(), uint64(0)).Return((<-chan produce.Event)(events), nil)
+
+		s := NewServer(3000, noopLogger, "test", mockProduceSvc)
+
+		w := serve(t, s, func() {
+			time.Sleep(10 * time.Millisecond)
+			assert.Nil(t, s.Shutdown(context.Background()))
+		})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"sync"
 	"time"
 
 	"github.com/go-chi/chi"
@@ -17,22 +18,120 @@ type server struct {
 	logger      *logrus.Logger
 	environment string
 	produceSvc  ProduceService
+	priceSvc    PriceService
+	checkoutSvc CheckoutService
+	promoSvc    PromotionService
+	rateSvc     RateService
+	stockSvc    InventoryService
+	storeSvc    StoreService
+	webhookSvc  WebhookService
+	keySvc      KeyService
+	verifier    TokenVerifier
 	server      *http.Server
+
+	// heartbeat is how often event streams send a comment to keep idle connections open.
+	heartbeat time.Duration
+	// shutdown is closed by Shutdown to end every event stream, which would otherwise keep their connections active.
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+}
+
+// ServerOption configures an optional service or setting of a server.
+type ServerOption func(*server)
+
+// WithPriceService serves price history with priceSvc and records the prices set through the produce endpoints.
+func WithPriceService(priceSvc PriceService) ServerOption {
+	return func(s *server) {
+		s.priceSvc = priceSvc
+	}
+}
+
+// WithCheckoutService serves basket pricing with checkoutSvc.
+func WithCheckoutService(checkoutSvc CheckoutService) ServerOption {
+	return func(s *server) {
+		s.checkoutSvc = checkoutSvc
+	}
+}
+
+// WithPromotionService serves the promotion rules in promoSvc.
+func WithPromotionService(promoSvc PromotionService) ServerOption {
+	return func(s *server) {
+		s.promoSvc = promoSvc
+	}
+}
+
+// WithRateService serves the exchange rates in rateSvc and converts produce prices with them.
+func WithRateService(rateSvc RateService) ServerOption {
+	return func(s *server) {
+		s.rateSvc = rateSvc
+	}
+}
+
+// WithInventoryService serves produce stock with stockSvc and stops produce still in stock from being deleted.
+func WithInventoryService(stockSvc InventoryService) ServerOption {
+	return func(s *server) {
+		s.stockSvc = stockSvc
+	}
+}
+
+// WithStoreService serves stores and the produce they sell with storeSvc.
+func WithStoreService(storeSvc StoreService) ServerOption {
+	return func(s *server) {
+		s.storeSvc = storeSvc
+	}
+}
+
+// WithWebhookService serves the webhook subscriptions and deliveries in webhookSvc.
+func WithWebhookService(webhookSvc WebhookService) ServerOption {
+	return func(s *server) {
+		s.webhookSvc = webhookSvc
+	}
+}
+
+// WithKeyService requires every request to /v1 to be made with an API key authenticated by keySvc, with a role
+// allowing it, and serves the keys in keySvc to admins.
+func WithKeyService(keySvc KeyService) ServerOption {
+	return func(s *server) {
+		s.keySvc = keySvc
+	}
+}
+
+// WithTokenVerifier requires every request to /v1 to be made with a bearer token verified by verifier, or an API key
+// if the server also has a key service, with a role allowing it.
+func WithTokenVerifier(verifier TokenVerifier) ServerOption {
+	return func(s *server) {
+		s.verifier = verifier
+	}
+}
+
+// WithEventHeartbeat sets how often event streams send a heartbeat. It defaults to DefaultEventHeartbeat.
+func WithEventHeartbeat(heartbeat time.Duration) ServerOption {
+	return func(s *server) {
+		s.heartbeat = heartbeat
+	}
 }
 
 // NewServer initializes a new server with the required configurations.
-func NewServer(port int, logger *logrus.Logger, environment string, produceSvc ProduceService) *server {
-	return &server{
+func NewServer(port int, logger *logrus.Logger, environment string, produceSvc ProduceService, opts ...ServerOption) *server {
+	s := &server{
 		port:        port,
 		logger:      logger,
 		environment: environment,
 		produceSvc:  produceSvc,
+		heartbeat:   DefaultEventHeartbeat,
+		shutdown:    make(chan struct{}),
 		server: &http.Server{
 			Addr:         fmt.Sprintf(":%d", port),
 			ReadTimeout:  60 * time.Second,
 			WriteTimeout: 60 * time.Second,
 		},
 	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
 // Run builds the routes and starts the server listening on the configured port.
@@ -43,10 +142,20 @@ func (s *server) Run() error {
 }
 
 // Shutdown calls Shutdown on the http.Server which attempts to gracefully shutdown. If the context deadline is exceeded any cotnext errors are returned.
+// Event streams are ended first so their connections can close.
 func (s *server) Shutdown(ctx context.Context) error {
+	s.shutdownOnce.Do(func() {
+		close(s.shutdown)
+	})
+
 	return s.server.Shutdown(ctx)
 }
 
+// authenticates returns true if the server requires requests to /v1 to be authenticated.
+func (s *server) authenticates() bool {
+	return s.keySvc != nil || s.verifier != nil
+}
+
 // buildRoutes configures a router with middleware, headers, and routes.
 func (s *server) buildRoutes() http.Handler {
 	r := s.configureRouter()
@@ -56,7 +165,39 @@ func (s *server) buildRoutes() http.Handler {
 
 	r.Group(func(r chi.Router) {
 		r.Route("/v1", func(r chi.Router) {
+			if s.authenticates() {
+				r.Use(s.authenticate)
+			}
+
 			s.produceGroup(r)
+
+			if s.checkoutSvc != nil {
+				s.checkoutGroup(r)
+			}
+
+			if s.promoSvc != nil {
+				s.promotionGroup(r)
+			}
+
+			if s.rateSvc != nil {
+				s.rateGroup(r)
+			}
+
+			if s.stockSvc != nil {
+				s.inventoryGroup(r)
+			}
+
+			if s.storeSvc != nil {
+				s.storeGroup(r)
+			}
+
+			if s.webhookSvc != nil {
+				s.webhookGroup(r)
+			}
+
+			if s.keySvc != nil {
+				s.keyGroup(r)
+			}
 		})
 	})
 
@@ -73,8 +214,8 @@ func (s *server) configureRouter() chi.Router {
 	r.Use(setResponseHeaders(map[string]string{
 		"Content-Type":                 "application/json",
 		"Allow-Access-Control-Origin":  "*",
-		"Allow-Access-Control-Method":  "OPTIONS, GET, POST, DELETE",
-		"Allow-Access-Control-Headers": "Origin, Content-Type, Accept",
+		"Allow-Access-Control-Method":  "OPTIONS, GET, POST, PUT, PATCH, DELETE",
+		"Allow-Access-Control-Headers": "Origin, Content-Type, Accept, Authorization, X-API-Key, X-Author",
 		"Access-Control-Max-Age":       "600",
 	}))
 
@@ -1,10 +1,101 @@
 package http
 
-import "github.com/davidlick/supermarket-api/internal/produce"
+import (
+	"context"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/davidlick/supermarket-api/internal/auth"
+	"github.com/davidlick/supermarket-api/internal/checkout"
+	"github.com/davidlick/supermarket-api/internal/exchange"
+	"github.com/davidlick/supermarket-api/internal/inventory"
+	"github.com/davidlick/supermarket-api/internal/prices"
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/davidlick/supermarket-api/internal/promotions"
+	"github.com/davidlick/supermarket-api/internal/stores"
+	"github.com/davidlick/supermarket-api/internal/webhooks"
+)
 
 type ProduceService interface {
 	Add(items []produce.Item) error
+	Update(item produce.Item) (produce.Item, error)
+	Patch(produceCode string, patch produce.ItemPatch) (produce.Item, error)
 	Remove(item produce.Item) error
+	ForceRemove(item produce.Item) error
 	Get(produceCode string) (item produce.Item, err error)
 	All() (items []produce.Item, err error)
+	List(opts produce.ListOptions) (items []produce.Item, next string, err error)
+	Search(query string, limit int) (items []produce.Item, err error)
+	Watch(ctx context.Context, from uint64) (<-chan produce.Event, error)
+}
+
+type PriceService interface {
+	Record(produceCode string, price *money.Money, effectiveFrom time.Time, author string) (prices.Change, error)
+	RecordCurrent(item produce.Item, author string) error
+	History(produceCode string) (changes []prices.Change, err error)
+	At(produceCode string, at time.Time) (prices.Change, error)
+}
+
+type CheckoutService interface {
+	Checkout(basket []checkout.BasketItem) (checkout.Receipt, error)
+}
+
+type PromotionService interface {
+	Create(rule promotions.Rule) (promotions.Rule, error)
+	Update(rule promotions.Rule) (promotions.Rule, error)
+	Remove(id string) error
+	Get(id string) (rule promotions.Rule, err error)
+	All() (rules []promotions.Rule, err error)
+}
+
+type RateService interface {
+	Set(rate exchange.Rate) (exchange.Rate, error)
+	All() (rates []exchange.Rate, err error)
+	Convert(price *money.Money, to string) (*money.Money, exchange.Conversion, error)
+}
+
+type InventoryService interface {
+	Get(produceCode string) (inventory.Stock, error)
+	All() (stocks []inventory.Stock, err error)
+	Low() (stocks []inventory.Stock, err error)
+	Receive(produceCode string, quantity produce.Quantity) (inventory.Stock, error)
+	Adjust(produceCode string, delta produce.Quantity) (inventory.Stock, error)
+	Reserve(produceCode string, quantity produce.Quantity) (inventory.Stock, error)
+	Release(produceCode string, quantity produce.Quantity) (inventory.Stock, error)
+	SetThreshold(produceCode string, threshold produce.Quantity) (inventory.Stock, error)
+}
+
+type StoreService interface {
+	Create(store stores.Store) (stores.Store, error)
+	Update(store stores.Store) (stores.Store, error)
+	Remove(id string) error
+	Get(id string) (store stores.Store, err error)
+	All() (all []stores.Store, err error)
+	SetOverride(override stores.Override) (stores.Item, error)
+	RemoveOverride(storeID, produceCode string) error
+	Item(storeID, produceCode string) (stores.Item, error)
+	Items(storeID string, opts produce.ListOptions) (items []stores.Item, next string, err error)
+}
+
+type WebhookService interface {
+	Create(sub webhooks.Subscription) (webhooks.Subscription, error)
+	Update(sub webhooks.Subscription) (webhooks.Subscription, error)
+	Remove(id string) error
+	Get(id string) (sub webhooks.Subscription, err error)
+	All() (subs []webhooks.Subscription, err error)
+	Deliveries(id string) ([]webhooks.Delivery, error)
+	DeadLetters() ([]webhooks.Delivery, error)
+}
+
+type KeyService interface {
+	Create(key auth.Key) (auth.IssuedKey, error)
+	Rotate(id string) (auth.IssuedKey, error)
+	Revoke(id string) (auth.Key, error)
+	Get(id string) (key auth.Key, err error)
+	All() (keys []auth.Key, err error)
+	Authenticate(token string) (auth.Identity, error)
+}
+
+type TokenVerifier interface {
+	Verify(token string) (auth.Identity, error)
 }
@@ -0,0 +1,175 @@
+package http
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/davidlick/supermarket-api/internal/auth"
+	"github.com/davidlick/supermarket-api/internal/inventory"
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/go-chi/chi"
+)
+
+// stockRequest is the body of a request to change the stock of a produce item. Adjustments take stock away with a
+// negative quantity.
+type stockRequest struct {
+	Quantity produce.Quantity `json:"quantity"`
+}
+
+// thresholdRequest is the body of a request to set the low stock threshold of a produce item.
+type thresholdRequest struct {
+	Threshold produce.Quantity `json:"threshold"`
+}
+
+func (s *server) inventoryGroup(r chi.Router) {
+	r.Route("/inventory", func(r chi.Router) {
+		r.Use(s.authorize(auth.RoleReader, auth.RolePricingEditor))
+		r.Get("/", s.handleGetAllStock)
+		r.Route("/{produceCode}", func(r chi.Router) {
+			r.Get("/", s.handleGetStock)
+			r.Post("/receive", s.handleReceiveStock)
+			r.Post("/adjust", s.handleAdjustStock)
+			r.Post("/reserve", s.handleReserveStock)
+			r.Post("/release", s.handleReleaseStock)
+			r.Put("/threshold", s.handleSetStockThreshold)
+		})
+	})
+}
+
+func (s *server) handleGetAllStock(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	all := s.stockSvc.All
+	if r.URL.Query().Get("low") == "true" {
+		all = s.stockSvc.Low
+	}
+
+	stocks, err := all()
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	if stocks == nil {
+		stocks = []inventory.Stock{}
+	}
+
+	s.writeSuccess(ctx, w, stocks, http.StatusOK)
+	return
+}
+
+func (s *server) handleGetStock(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	produceCode := chi.URLParam(r, "produceCode")
+	if produceCode == "" {
+		s.writeError(ctx, w, ErrUnrecognizedCode, http.StatusBadRequest)
+		return
+	}
+
+	stock, err := s.stockSvc.Get(produceCode)
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	s.writeSuccess(ctx, w, stock, http.StatusOK)
+	return
+}
+
+func (s *server) handleReceiveStock(w http.ResponseWriter, r *http.Request) {
+	s.changeStock(w, r, s.stockSvc.Receive)
+}
+
+func (s *server) handleAdjustStock(w http.ResponseWriter, r *http.Request) {
+	s.changeStock(w, r, s.stockSvc.Adjust)
+}
+
+func (s *server) handleReserveStock(w http.ResponseWriter, r *http.Request) {
+	s.changeStock(w, r, s.stockSvc.Reserve)
+}
+
+func (s *server) handleReleaseStock(w http.ResponseWriter, r *http.Request) {
+	s.changeStock(w, r, s.stockSvc.Release)
+}
+
+// changeStock changes the stock of the produce item in the url by the quantity in the request body with change, and
+// responds with the changed stock.
+func (s *server) changeStock(w http.ResponseWriter, r *http.Request, change func(produceCode string, quantity produce.Quantity) (inventory.Stock, error)) {
+	ctx := r.Context()
+
+	produceCode := chi.URLParam(r, "produceCode")
+	if produceCode == "" {
+		s.writeError(ctx, w, ErrUnrecognizedCode, http.StatusBadRequest)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		s.writeError(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	var req stockRequest
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		s.writeError(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	stock, err := change(produceCode, req.Quantity)
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	s.warnLowStock(r, stock)
+
+	s.writeSuccess(ctx, w, stock, http.StatusOK)
+	return
+}
+
+func (s *server) handleSetStockThreshold(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	produceCode := chi.URLParam(r, "produceCode")
+	if produceCode == "" {
+		s.writeError(ctx, w, ErrUnrecognizedCode, http.StatusBadRequest)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		s.writeError(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	var req thresholdRequest
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		s.writeError(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	stock, err := s.stockSvc.SetThreshold(produceCode, req.Threshold)
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	s.warnLowStock(r, stock)
+
+	s.writeSuccess(ctx, w, stock, http.StatusOK)
+	return
+}
+
+// warnLowStock logs a warning if stock has fallen below its low stock threshold.
+func (s *server) warnLowStock(r *http.Request, stock inventory.Stock) {
+	if !stock.LowStock {
+		return
+	}
+
+	s.requestLogger(r.Context()).
+		Warnf("%s is low on stock: %s %s available, threshold is %s", stock.Code, stock.Available, stock.Unit, stock.LowStockThreshold)
+}
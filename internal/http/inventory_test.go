@@ -0,0 +1,254 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/davidlick/supermarket-api/internal/inventory"
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_handleGetAllStock(t *testing.T) {
+	tests := []struct {
+		test       string
+		query      string
+		expectFunc func(mockStockSvc *MockInventoryService)
+		assertFunc func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			test: "it should respond with the stock of every item",
+			expectFunc: func(mockStockSvc *MockInventoryService) {
+				mockStockSvc.EXPECT().All().Return([]inventory.Stock{
+					{Code: "test-code", Unit: produce.UnitPound, OnHand: 2500, Reserved: 500, Available: 2000, Version: 2},
+				}, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+
+				b, err := ioutil.ReadAll(w.Body)
+				if err != nil {
+					t.Error(err)
+				}
+
+				assert.Equal(t, "[{\"code\":\"test-code\",\"unit\":\"lb\",\"on_hand\":2.5,\"reserved\":0.5,\"available\":2,\"low_stock_threshold\":0,\"low_stock\":false,\"version\":2}]\n", string(b))
+			},
+		},
+		{
+			test:  "it should respond with only the items low on stock",
+			query: "?low=true",
+			expectFunc: func(mockStockSvc *MockInventoryService) {
+				mockStockSvc.EXPECT().Low().Return(nil, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Equal(t, "[]\n", w.Body.String())
+			},
+		},
+		{
+			test: "it should respond internal server error if reading the stock fails",
+			expectFunc: func(mockStockSvc *MockInventoryService) {
+				mockStockSvc.EXPECT().All().Return(nil, errors.New("test error"))
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, w.Code)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			r := httptest.NewRequest(http.MethodGet, "/v1/inventory"+tc.query, nil)
+			w := httptest.NewRecorder()
+
+			noopLogger := logrus.New()
+			noopLogger.SetOutput(ioutil.Discard)
+
+			mockStockSvc := NewMockInventoryService(ctrl)
+			tc.expectFunc(mockStockSvc)
+
+			s := NewServer(3000, noopLogger, "test", nil, WithInventoryService(mockStockSvc))
+
+			handler := http.HandlerFunc(s.handleGetAllStock)
+			handler.ServeHTTP(w, r)
+
+			tc.assertFunc(t, w)
+		})
+	}
+}
+
+func TestServer_changeStock(t *testing.T) {
+	tests := []struct {
+		test       string
+		handler    func(s *server) http.HandlerFunc
+		body       string
+		expectFunc func(mockStockSvc *MockInventoryService)
+		assertFunc func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			test:    "it should receive stock",
+			handler: func(s *server) http.HandlerFunc { return s.handleReceiveStock },
+			body:    `{"quantity":10}`,
+			expectFunc: func(mockStockSvc *MockInventoryService) {
+				mockStockSvc.EXPECT().Receive("test-code", produce.NewQuantity(10)).
+					Return(inventory.Stock{Code: "test-code", OnHand: 10000, Available: 10000, Version: 1}, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			test:    "it should adjust stock down with a negative quantity",
+			handler: func(s *server) http.HandlerFunc { return s.handleAdjustStock },
+			body:    `{"quantity":-1.5}`,
+			expectFunc: func(mockStockSvc *MockInventoryService) {
+				mockStockSvc.EXPECT().Adjust("test-code", produce.Quantity(-1500)).Return(inventory.Stock{Code: "test-code"}, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			test:    "it should respond conflict if there isn't enough stock to reserve",
+			handler: func(s *server) http.HandlerFunc { return s.handleReserveStock },
+			body:    `{"quantity":5}`,
+			expectFunc: func(mockStockSvc *MockInventoryService) {
+				mockStockSvc.EXPECT().Reserve("test-code", produce.NewQuantity(5)).
+					Return(inventory.Stock{}, fmt.Errorf("%w: 4 available", inventory.ErrInsufficientStock))
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusConflict, w.Code)
+				assert.Contains(t, w.Body.String(), `"code":"insufficient_stock"`)
+			},
+		},
+		{
+			test:    "it should respond unprocessable entity if more is released than is reserved",
+			handler: func(s *server) http.HandlerFunc { return s.handleReleaseStock },
+			body:    `{"quantity":5}`,
+			expectFunc: func(mockStockSvc *MockInventoryService) {
+				mockStockSvc.EXPECT().Release("test-code", produce.NewQuantity(5)).
+					Return(inventory.Stock{}, fmt.Errorf("%w: only 1 is reserved", inventory.ErrInvalidQuantity))
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+			},
+		},
+		{
+			test:    "it should set the low stock threshold",
+			handler: func(s *server) http.HandlerFunc { return s.handleSetStockThreshold },
+			body:    `{"threshold":5}`,
+			expectFunc: func(mockStockSvc *MockInventoryService) {
+				mockStockSvc.EXPECT().SetThreshold("test-code", produce.NewQuantity(5)).
+					Return(inventory.Stock{Code: "test-code", LowStockThreshold: 5000, LowStock: true}, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Contains(t, w.Body.String(), `"low_stock":true`)
+			},
+		},
+		{
+			test:       "it should respond bad request if the body isn't a quantity",
+			handler:    func(s *server) http.HandlerFunc { return s.handleReceiveStock },
+			body:       `{"quantity":"ten"}`,
+			expectFunc: func(mockStockSvc *MockInventoryService) {},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			r := httptest.NewRequest(http.MethodPost, "/v1/inventory/test-code", strings.NewReader(tc.body))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("produceCode", "test-code")
+			r = r.WithContext(context.WithValue(context.Background(), chi.RouteCtxKey, rctx))
+			w := httptest.NewRecorder()
+
+			noopLogger := logrus.New()
+			noopLogger.SetOutput(ioutil.Discard)
+
+			mockStockSvc := NewMockInventoryService(ctrl)
+			tc.expectFunc(mockStockSvc)
+
+			s := NewServer(3000, noopLogger, "test", nil, WithInventoryService(mockStockSvc))
+
+			tc.handler(s).ServeHTTP(w, r)
+
+			tc.assertFunc(t, w)
+		})
+	}
+}
+
+func TestServer_handleDeleteProduce_stock(t *testing.T) {
+	tests := []struct {
+		test       string
+		query      string
+		expectFunc func(mockProduceSvc *MockProduceService)
+		assertFunc func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			test: "it should respond conflict if the item is still in stock",
+			expectFunc: func(mockProduceSvc *MockProduceService) {
+				err := fmt.Errorf("%w: 3 each on hand", inventory.ErrInStock)
+				mockProduceSvc.EXPECT().Remove(produce.Item{Code: "test-code"}).Return(err)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusConflict, w.Code)
+				assert.Contains(t, w.Body.String(), `"code":"in_stock"`)
+				assert.Contains(t, w.Body.String(), "?force=true")
+			},
+		},
+		{
+			test:  "it should force the removal of an item still in stock",
+			query: "?force=true",
+			expectFunc: func(mockProduceSvc *MockProduceService) {
+				mockProduceSvc.EXPECT().ForceRemove(produce.Item{Code: "test-code"}).Return(nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNoContent, w.Code)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			r := httptest.NewRequest(http.MethodDelete, "/v1/produce/test-code"+tc.query, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("produceCode", "test-code")
+			r = r.WithContext(context.WithValue(context.Background(), chi.RouteCtxKey, rctx))
+			w := httptest.NewRecorder()
+
+			noopLogger := logrus.New()
+			noopLogger.SetOutput(ioutil.Discard)
+
+			mockProduceSvc := NewMockProduceService(ctrl)
+			tc.expectFunc(mockProduceSvc)
+
+			s := NewServer(3000, noopLogger, "test", mockProduceSvc)
+
+			handler := http.HandlerFunc(s.handleDeleteProduce)
+			handler.ServeHTTP(w, r)
+
+			tc.assertFunc(t, w)
+		})
+	}
+}
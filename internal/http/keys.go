@@ -0,0 +1,108 @@
+package http
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/davidlick/supermarket-api/internal/auth"
+	"github.com/go-chi/chi"
+)
+
+func (s *server) keyGroup(r chi.Router) {
+	r.Route("/keys", func(r chi.Router) {
+		r.Use(s.authorize(auth.RoleAdmin, auth.RoleAdmin))
+		r.Get("/", s.handleGetAllKeys)
+		r.Post("/", s.handleAddKey)
+		r.Route("/{keyID}", func(r chi.Router) {
+			r.Get("/", s.handleGetKey)
+			r.Post("/rotate", s.handleRotateKey)
+			r.Post("/revoke", s.handleRevokeKey)
+		})
+	})
+}
+
+func (s *server) handleGetAllKeys(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	keys, err := s.keySvc.All()
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	if keys == nil {
+		keys = []auth.Key{}
+	}
+
+	s.writeSuccess(ctx, w, keys, http.StatusOK)
+	return
+}
+
+func (s *server) handleAddKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		s.writeError(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	var key auth.Key
+	err = json.Unmarshal(body, &key)
+	if err != nil {
+		s.writeError(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	issued, err := s.keySvc.Create(key)
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	s.requestLogger(ctx).Infof("created API key %s with role %s", issued.ID, issued.Role)
+	s.writeSuccess(ctx, w, issued, http.StatusCreated)
+	return
+}
+
+func (s *server) handleGetKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	key, err := s.keySvc.Get(chi.URLParam(r, "keyID"))
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	s.writeSuccess(ctx, w, key, http.StatusOK)
+	return
+}
+
+func (s *server) handleRotateKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	issued, err := s.keySvc.Rotate(chi.URLParam(r, "keyID"))
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	s.requestLogger(ctx).Infof("rotated API key %s", issued.ID)
+	s.writeSuccess(ctx, w, issued, http.StatusOK)
+	return
+}
+
+func (s *server) handleRevokeKey(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	key, err := s.keySvc.Revoke(chi.URLParam(r, "keyID"))
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	s.requestLogger(ctx).Infof("revoked API key %s", key.ID)
+	s.writeSuccess(ctx, w, key, http.StatusOK)
+	return
+}
@@ -0,0 +1,147 @@
+package http
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/davidlick/supermarket-api/internal/auth"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_keys(t *testing.T) {
+	createdAt := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		test       string
+		method     string
+		body       string
+		handler    func(s *server) http.HandlerFunc
+		expectFunc func(mockKeySvc *MockKeyService)
+		assertFunc func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			test:    "it should create a key and respond with its token",
+			method:  http.MethodPost,
+			body:    `{"name":"pricing-team","role":"pricing-editor"}`,
+			handler: func(s *server) http.HandlerFunc { return s.handleAddKey },
+			expectFunc: func(mockKeySvc *MockKeyService) {
+				mockKeySvc.EXPECT().Create(auth.Key{Name: "pricing-team", Role: auth.RolePricingEditor}).Return(auth.IssuedKey{
+					Key:   auth.Key{ID: "test-key", Name: "pricing-team", Role: auth.RolePricingEditor, CreatedAt: createdAt, Version: 1},
+					Token: "sk_test-key_secret",
+				}, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusCreated, w.Code)
+				assert.Equal(t, "{\"id\":\"test-key\",\"name\":\"pricing-team\",\"role\":\"pricing-editor\",\"created_at\":\"2021-03-01T12:00:00Z\",\"version\":1,\"token\":\"sk_test-key_secret\"}\n", w.Body.String())
+			},
+		},
+		{
+			test:    "it should respond unprocessable entity if the key is invalid",
+			method:  http.MethodPost,
+			body:    `{"name":"pricing-team","role":"owner"}`,
+			handler: func(s *server) http.HandlerFunc { return s.handleAddKey },
+			expectFunc: func(mockKeySvc *MockKeyService) {
+				mockKeySvc.EXPECT().Create(gomock.Any()).Return(auth.IssuedKey{}, fmt.Errorf("%w: role must be reader, pricing-editor or admin", auth.ErrInvalidKey))
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				assert.Contains(t, w.Body.String(), `"code":"invalid_key"`)
+			},
+		},
+		{
+			test:    "it should list keys as an empty array if there are none",
+			method:  http.MethodGet,
+			handler: func(s *server) http.HandlerFunc { return s.handleGetAllKeys },
+			expectFunc: func(mockKeySvc *MockKeyService) {
+				mockKeySvc.EXPECT().All().Return(nil, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Equal(t, "[]\n", w.Body.String())
+			},
+		},
+		{
+			test:    "it should respond not found if the key doesn't exist",
+			method:  http.MethodGet,
+			handler: func(s *server) http.HandlerFunc { return s.handleGetKey },
+			expectFunc: func(mockKeySvc *MockKeyService) {
+				mockKeySvc.EXPECT().Get("test-key").Return(auth.Key{}, ramdb.ErrNoRecord)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, w.Code)
+			},
+		},
+		{
+			test:    "it should rotate a key and respond with its new token",
+			method:  http.MethodPost,
+			handler: func(s *server) http.HandlerFunc { return s.handleRotateKey },
+			expectFunc: func(mockKeySvc *MockKeyService) {
+				mockKeySvc.EXPECT().Rotate("test-key").Return(auth.IssuedKey{Key: auth.Key{ID: "test-key"}, Token: "sk_test-key_new"}, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Contains(t, w.Body.String(), `"token":"sk_test-key_new"`)
+			},
+		},
+		{
+			test:    "it should respond conflict when rotating a revoked key",
+			method:  http.MethodPost,
+			handler: func(s *server) http.HandlerFunc { return s.handleRotateKey },
+			expectFunc: func(mockKeySvc *MockKeyService) {
+				mockKeySvc.EXPECT().Rotate("test-key").Return(auth.IssuedKey{}, auth.ErrKeyRevoked)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusConflict, w.Code)
+				assert.Contains(t, w.Body.String(), `"code":"key_revoked"`)
+			},
+		},
+		{
+			test:    "it should revoke a key",
+			method:  http.MethodPost,
+			handler: func(s *server) http.HandlerFunc { return s.handleRevokeKey },
+			expectFunc: func(mockKeySvc *MockKeyService) {
+				mockKeySvc.EXPECT().Revoke("test-key").Return(auth.Key{ID: "test-key", RevokedAt: &createdAt, Version: 2}, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Contains(t, w.Body.String(), `"revoked_at":"2021-03-01T12:00:00Z"`)
+				assert.NotContains(t, w.Body.String(), `"token"`)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			r := httptest.NewRequest(tc.method, "/v1/keys/test-key", strings.NewReader(tc.body))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("keyID", "test-key")
+			r = r.WithContext(context.WithValue(context.Background(), chi.RouteCtxKey, rctx))
+			w := httptest.NewRecorder()
+
+			noopLogger := logrus.New()
+			noopLogger.SetOutput(ioutil.Discard)
+
+			mockKeySvc := NewMockKeyService(ctrl)
+			tc.expectFunc(mockKeySvc)
+
+			s := NewServer(3000, noopLogger, "test", nil, WithKeyService(mockKeySvc))
+
+			tc.handler(s).ServeHTTP(w, r)
+
+			tc.assertFunc(t, w)
+		})
+	}
+}
@@ -5,9 +5,20 @@
 package http
 
 import (
+	context "context"
+	money "github.com/Rhymond/go-money"
+	auth "github.com/davidlick/supermarket-api/internal/auth"
+	checkout "github.com/davidlick/supermarket-api/internal/checkout"
+	exchange "github.com/davidlick/supermarket-api/internal/exchange"
+	inventory "github.com/davidlick/supermarket-api/internal/inventory"
+	prices "github.com/davidlick/supermarket-api/internal/prices"
 	produce "github.com/davidlick/supermarket-api/internal/produce"
+	promotions "github.com/davidlick/supermarket-api/internal/promotions"
+	stores "github.com/davidlick/supermarket-api/internal/stores"
+	webhooks "github.com/davidlick/supermarket-api/internal/webhooks"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
+	time "time"
 )
 
 // MockProduceService is a mock of ProduceService interface
@@ -47,6 +58,82 @@ func (mr *MockProduceServiceMockRecorder) Add(items interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Add", reflect.TypeOf((*MockProduceService)(nil).Add), items)
 }
 
+// Update mocks base method
+func (m *MockProduceService) Update(item produce.Item) (produce.Item, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", item)
+	ret0, _ := ret[0].(produce.Item)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update
+func (mr *MockProduceServiceMockRecorder) Update(item interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockProduceService)(nil).Update), item)
+}
+
+// Patch mocks base method
+func (m *MockProduceService) Patch(produceCode string, patch produce.ItemPatch) (produce.Item, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Patch", produceCode, patch)
+	ret0, _ := ret[0].(produce.Item)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Patch indicates an expected call of Patch
+func (mr *MockProduceServiceMockRecorder) Patch(produceCode, patch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Patch", reflect.TypeOf((*MockProduceService)(nil).Patch), produceCode, patch)
+}
+
+// List mocks base method
+func (m *MockProduceService) List(opts produce.ListOptions) ([]produce.Item, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", opts)
+	ret0, _ := ret[0].([]produce.Item)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// List indicates an expected call of List
+func (mr *MockProduceServiceMockRecorder) List(opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockProduceService)(nil).List), opts)
+}
+
+// Search mocks base method
+func (m *MockProduceService) Search(query string, limit int) ([]produce.Item, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", query, limit)
+	ret0, _ := ret[0].([]produce.Item)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search
+func (mr *MockProduceServiceMockRecorder) Search(query, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockProduceService)(nil).Search), query, limit)
+}
+
+// Watch mocks base method
+func (m *MockProduceService) Watch(ctx context.Context, from uint64) (<-chan produce.Event, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Watch", ctx, from)
+	ret0, _ := ret[0].(<-chan produce.Event)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Watch indicates an expected call of Watch
+func (mr *MockProduceServiceMockRecorder) Watch(ctx, from interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Watch", reflect.TypeOf((*MockProduceService)(nil).Watch), ctx, from)
+}
+
 // Remove mocks base method
 func (m *MockProduceService) Remove(item produce.Item) error {
 	m.ctrl.T.Helper()
@@ -61,6 +148,20 @@ func (mr *MockProduceServiceMockRecorder) Remove(item interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MockProduceService)(nil).Remove), item)
 }
 
+// ForceRemove mocks base method
+func (m *MockProduceService) ForceRemove(item produce.Item) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForceRemove", item)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForceRemove indicates an expected call of ForceRemove
+func (mr *MockProduceServiceMockRecorder) ForceRemove(item interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForceRemove", reflect.TypeOf((*MockProduceService)(nil).ForceRemove), item)
+}
+
 // Get mocks base method
 func (m *MockProduceService) Get(produceCode string) (produce.Item, error) {
 	m.ctrl.T.Helper()
@@ -90,3 +191,867 @@ func (mr *MockProduceServiceMockRecorder) All() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "All", reflect.TypeOf((*MockProduceService)(nil).All))
 }
+
+// MockPriceService is a mock of PriceService interface
+type MockPriceService struct {
+	ctrl     *gomock.Controller
+	recorder *MockPriceServiceMockRecorder
+}
+
+// MockPriceServiceMockRecorder is the mock recorder for MockPriceService
+type MockPriceServiceMockRecorder struct {
+	mock *MockPriceService
+}
+
+// NewMockPriceService creates a new mock instance
+func NewMockPriceService(ctrl *gomock.Controller) *MockPriceService {
+	mock := &MockPriceService{ctrl: ctrl}
+	mock.recorder = &MockPriceServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockPriceService) EXPECT() *MockPriceServiceMockRecorder {
+	return m.recorder
+}
+
+// Record mocks base method
+func (m *MockPriceService) Record(produceCode string, price *money.Money, effectiveFrom time.Time, author string) (prices.Change, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Record", produceCode, price, effectiveFrom, author)
+	ret0, _ := ret[0].(prices.Change)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Record indicates an expected call of Record
+func (mr *MockPriceServiceMockRecorder) Record(produceCode, price, effectiveFrom, author interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Record", reflect.TypeOf((*MockPriceService)(nil).Record), produceCode, price, effectiveFrom, author)
+}
+
+// RecordCurrent mocks base method
+func (m *MockPriceService) RecordCurrent(item produce.Item, author string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordCurrent", item, author)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RecordCurrent indicates an expected call of RecordCurrent
+func (mr *MockPriceServiceMockRecorder) RecordCurrent(item, author interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordCurrent", reflect.TypeOf((*MockPriceService)(nil).RecordCurrent), item, author)
+}
+
+// History mocks base method
+func (m *MockPriceService) History(produceCode string) ([]prices.Change, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "History", produceCode)
+	ret0, _ := ret[0].([]prices.Change)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// History indicates an expected call of History
+func (mr *MockPriceServiceMockRecorder) History(produceCode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "History", reflect.TypeOf((*MockPriceService)(nil).History), produceCode)
+}
+
+// At mocks base method
+func (m *MockPriceService) At(produceCode string, at time.Time) (prices.Change, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "At", produceCode, at)
+	ret0, _ := ret[0].(prices.Change)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// At indicates an expected call of At
+func (mr *MockPriceServiceMockRecorder) At(produceCode, at interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "At", reflect.TypeOf((*MockPriceService)(nil).At), produceCode, at)
+}
+
+// MockCheckoutService is a mock of CheckoutService interface
+type MockCheckoutService struct {
+	ctrl     *gomock.Controller
+	recorder *MockCheckoutServiceMockRecorder
+}
+
+// MockCheckoutServiceMockRecorder is the mock recorder for MockCheckoutService
+type MockCheckoutServiceMockRecorder struct {
+	mock *MockCheckoutService
+}
+
+// NewMockCheckoutService creates a new mock instance
+func NewMockCheckoutService(ctrl *gomock.Controller) *MockCheckoutService {
+	mock := &MockCheckoutService{ctrl: ctrl}
+	mock.recorder = &MockCheckoutServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockCheckoutService) EXPECT() *MockCheckoutServiceMockRecorder {
+	return m.recorder
+}
+
+// Checkout mocks base method
+func (m *MockCheckoutService) Checkout(basket []checkout.BasketItem) (checkout.Receipt, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Checkout", basket)
+	ret0, _ := ret[0].(checkout.Receipt)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Checkout indicates an expected call of Checkout
+func (mr *MockCheckoutServiceMockRecorder) Checkout(basket interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Checkout", reflect.TypeOf((*MockCheckoutService)(nil).Checkout), basket)
+}
+
+// MockPromotionService is a mock of PromotionService interface
+type MockPromotionService struct {
+	ctrl     *gomock.Controller
+	recorder *MockPromotionServiceMockRecorder
+}
+
+// MockPromotionServiceMockRecorder is the mock recorder for MockPromotionService
+type MockPromotionServiceMockRecorder struct {
+	mock *MockPromotionService
+}
+
+// NewMockPromotionService creates a new mock instance
+func NewMockPromotionService(ctrl *gomock.Controller) *MockPromotionService {
+	mock := &MockPromotionService{ctrl: ctrl}
+	mock.recorder = &MockPromotionServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockPromotionService) EXPECT() *MockPromotionServiceMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method
+func (m *MockPromotionService) Create(rule promotions.Rule) (promotions.Rule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", rule)
+	ret0, _ := ret[0].(promotions.Rule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create
+func (mr *MockPromotionServiceMockRecorder) Create(rule interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockPromotionService)(nil).Create), rule)
+}
+
+// Update mocks base method
+func (m *MockPromotionService) Update(rule promotions.Rule) (promotions.Rule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", rule)
+	ret0, _ := ret[0].(promotions.Rule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update
+func (mr *MockPromotionServiceMockRecorder) Update(rule interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockPromotionService)(nil).Update), rule)
+}
+
+// Remove mocks base method
+func (m *MockPromotionService) Remove(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Remove", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Remove indicates an expected call of Remove
+func (mr *MockPromotionServiceMockRecorder) Remove(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MockPromotionService)(nil).Remove), id)
+}
+
+// Get mocks base method
+func (m *MockPromotionService) Get(id string) (promotions.Rule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", id)
+	ret0, _ := ret[0].(promotions.Rule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get
+func (mr *MockPromotionServiceMockRecorder) Get(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockPromotionService)(nil).Get), id)
+}
+
+// All mocks base method
+func (m *MockPromotionService) All() ([]promotions.Rule, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "All")
+	ret0, _ := ret[0].([]promotions.Rule)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// All indicates an expected call of All
+func (mr *MockPromotionServiceMockRecorder) All() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "All", reflect.TypeOf((*MockPromotionService)(nil).All))
+}
+
+// MockRateService is a mock of RateService interface
+type MockRateService struct {
+	ctrl     *gomock.Controller
+	recorder *MockRateServiceMockRecorder
+}
+
+// MockRateServiceMockRecorder is the mock recorder for MockRateService
+type MockRateServiceMockRecorder struct {
+	mock *MockRateService
+}
+
+// NewMockRateService creates a new mock instance
+func NewMockRateService(ctrl *gomock.Controller) *MockRateService {
+	mock := &MockRateService{ctrl: ctrl}
+	mock.recorder = &MockRateServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockRateService) EXPECT() *MockRateServiceMockRecorder {
+	return m.recorder
+}
+
+// Set mocks base method
+func (m *MockRateService) Set(rate exchange.Rate) (exchange.Rate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Set", rate)
+	ret0, _ := ret[0].(exchange.Rate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Set indicates an expected call of Set
+func (mr *MockRateServiceMockRecorder) Set(rate interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Set", reflect.TypeOf((*MockRateService)(nil).Set), rate)
+}
+
+// All mocks base method
+func (m *MockRateService) All() ([]exchange.Rate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "All")
+	ret0, _ := ret[0].([]exchange.Rate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// All indicates an expected call of All
+func (mr *MockRateServiceMockRecorder) All() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "All", reflect.TypeOf((*MockRateService)(nil).All))
+}
+
+// Convert mocks base method
+func (m *MockRateService) Convert(price *money.Money, to string) (*money.Money, exchange.Conversion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Convert", price, to)
+	ret0, _ := ret[0].(*money.Money)
+	ret1, _ := ret[1].(exchange.Conversion)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Convert indicates an expected call of Convert
+func (mr *MockRateServiceMockRecorder) Convert(price, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Convert", reflect.TypeOf((*MockRateService)(nil).Convert), price, to)
+}
+
+// MockInventoryService is a mock of InventoryService interface
+type MockInventoryService struct {
+	ctrl     *gomock.Controller
+	recorder *MockInventoryServiceMockRecorder
+}
+
+// MockInventoryServiceMockRecorder is the mock recorder for MockInventoryService
+type MockInventoryServiceMockRecorder struct {
+	mock *MockInventoryService
+}
+
+// NewMockInventoryService creates a new mock instance
+func NewMockInventoryService(ctrl *gomock.Controller) *MockInventoryService {
+	mock := &MockInventoryService{ctrl: ctrl}
+	mock.recorder = &MockInventoryServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockInventoryService) EXPECT() *MockInventoryServiceMockRecorder {
+	return m.recorder
+}
+
+// Get mocks base method
+func (m *MockInventoryService) Get(produceCode string) (inventory.Stock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", produceCode)
+	ret0, _ := ret[0].(inventory.Stock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get
+func (mr *MockInventoryServiceMockRecorder) Get(produceCode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockInventoryService)(nil).Get), produceCode)
+}
+
+// All mocks base method
+func (m *MockInventoryService) All() ([]inventory.Stock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "All")
+	ret0, _ := ret[0].([]inventory.Stock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// All indicates an expected call of All
+func (mr *MockInventoryServiceMockRecorder) All() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "All", reflect.TypeOf((*MockInventoryService)(nil).All))
+}
+
+// Low mocks base method
+func (m *MockInventoryService) Low() ([]inventory.Stock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Low")
+	ret0, _ := ret[0].([]inventory.Stock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Low indicates an expected call of Low
+func (mr *MockInventoryServiceMockRecorder) Low() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Low", reflect.TypeOf((*MockInventoryService)(nil).Low))
+}
+
+// Receive mocks base method
+func (m *MockInventoryService) Receive(produceCode string, quantity produce.Quantity) (inventory.Stock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Receive", produceCode, quantity)
+	ret0, _ := ret[0].(inventory.Stock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Receive indicates an expected call of Receive
+func (mr *MockInventoryServiceMockRecorder) Receive(produceCode, quantity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Receive", reflect.TypeOf((*MockInventoryService)(nil).Receive), produceCode, quantity)
+}
+
+// Adjust mocks base method
+func (m *MockInventoryService) Adjust(produceCode string, delta produce.Quantity) (inventory.Stock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Adjust", produceCode, delta)
+	ret0, _ := ret[0].(inventory.Stock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Adjust indicates an expected call of Adjust
+func (mr *MockInventoryServiceMockRecorder) Adjust(produceCode, delta interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Adjust", reflect.TypeOf((*MockInventoryService)(nil).Adjust), produceCode, delta)
+}
+
+// Reserve mocks base method
+func (m *MockInventoryService) Reserve(produceCode string, quantity produce.Quantity) (inventory.Stock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reserve", produceCode, quantity)
+	ret0, _ := ret[0].(inventory.Stock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reserve indicates an expected call of Reserve
+func (mr *MockInventoryServiceMockRecorder) Reserve(produceCode, quantity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reserve", reflect.TypeOf((*MockInventoryService)(nil).Reserve), produceCode, quantity)
+}
+
+// Release mocks base method
+func (m *MockInventoryService) Release(produceCode string, quantity produce.Quantity) (inventory.Stock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", produceCode, quantity)
+	ret0, _ := ret[0].(inventory.Stock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Release indicates an expected call of Release
+func (mr *MockInventoryServiceMockRecorder) Release(produceCode, quantity interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockInventoryService)(nil).Release), produceCode, quantity)
+}
+
+// SetThreshold mocks base method
+func (m *MockInventoryService) SetThreshold(produceCode string, threshold produce.Quantity) (inventory.Stock, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetThreshold", produceCode, threshold)
+	ret0, _ := ret[0].(inventory.Stock)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetThreshold indicates an expected call of SetThreshold
+func (mr *MockInventoryServiceMockRecorder) SetThreshold(produceCode, threshold interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetThreshold", reflect.TypeOf((*MockInventoryService)(nil).SetThreshold), produceCode, threshold)
+}
+
+// MockStoreService is a mock of StoreService interface
+type MockStoreService struct {
+	ctrl     *gomock.Controller
+	recorder *MockStoreServiceMockRecorder
+}
+
+// MockStoreServiceMockRecorder is the mock recorder for MockStoreService
+type MockStoreServiceMockRecorder struct {
+	mock *MockStoreService
+}
+
+// NewMockStoreService creates a new mock instance
+func NewMockStoreService(ctrl *gomock.Controller) *MockStoreService {
+	mock := &MockStoreService{ctrl: ctrl}
+	mock.recorder = &MockStoreServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockStoreService) EXPECT() *MockStoreServiceMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method
+func (m *MockStoreService) Create(store stores.Store) (stores.Store, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", store)
+	ret0, _ := ret[0].(stores.Store)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create
+func (mr *MockStoreServiceMockRecorder) Create(store interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockStoreService)(nil).Create), store)
+}
+
+// Update mocks base method
+func (m *MockStoreService) Update(store stores.Store) (stores.Store, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", store)
+	ret0, _ := ret[0].(stores.Store)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update
+func (mr *MockStoreServiceMockRecorder) Update(store interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockStoreService)(nil).Update), store)
+}
+
+// Remove mocks base method
+func (m *MockStoreService) Remove(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Remove", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Remove indicates an expected call of Remove
+func (mr *MockStoreServiceMockRecorder) Remove(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MockStoreService)(nil).Remove), id)
+}
+
+// Get mocks base method
+func (m *MockStoreService) Get(id string) (stores.Store, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", id)
+	ret0, _ := ret[0].(stores.Store)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get
+func (mr *MockStoreServiceMockRecorder) Get(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockStoreService)(nil).Get), id)
+}
+
+// All mocks base method
+func (m *MockStoreService) All() ([]stores.Store, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "All")
+	ret0, _ := ret[0].([]stores.Store)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// All indicates an expected call of All
+func (mr *MockStoreServiceMockRecorder) All() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "All", reflect.TypeOf((*MockStoreService)(nil).All))
+}
+
+// SetOverride mocks base method
+func (m *MockStoreService) SetOverride(override stores.Override) (stores.Item, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetOverride", override)
+	ret0, _ := ret[0].(stores.Item)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetOverride indicates an expected call of SetOverride
+func (mr *MockStoreServiceMockRecorder) SetOverride(override interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetOverride", reflect.TypeOf((*MockStoreService)(nil).SetOverride), override)
+}
+
+// RemoveOverride mocks base method
+func (m *MockStoreService) RemoveOverride(storeID, produceCode string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveOverride", storeID, produceCode)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RemoveOverride indicates an expected call of RemoveOverride
+func (mr *MockStoreServiceMockRecorder) RemoveOverride(storeID, produceCode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveOverride", reflect.TypeOf((*MockStoreService)(nil).RemoveOverride), storeID, produceCode)
+}
+
+// Item mocks base method
+func (m *MockStoreService) Item(storeID, produceCode string) (stores.Item, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Item", storeID, produceCode)
+	ret0, _ := ret[0].(stores.Item)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Item indicates an expected call of Item
+func (mr *MockStoreServiceMockRecorder) Item(storeID, produceCode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Item", reflect.TypeOf((*MockStoreService)(nil).Item), storeID, produceCode)
+}
+
+// Items mocks base method
+func (m *MockStoreService) Items(storeID string, opts produce.ListOptions) ([]stores.Item, string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Items", storeID, opts)
+	ret0, _ := ret[0].([]stores.Item)
+	ret1, _ := ret[1].(string)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Items indicates an expected call of Items
+func (mr *MockStoreServiceMockRecorder) Items(storeID, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Items", reflect.TypeOf((*MockStoreService)(nil).Items), storeID, opts)
+}
+
+// MockWebhookService is a mock of WebhookService interface
+type MockWebhookService struct {
+	ctrl     *gomock.Controller
+	recorder *MockWebhookServiceMockRecorder
+}
+
+// MockWebhookServiceMockRecorder is the mock recorder for MockWebhookService
+type MockWebhookServiceMockRecorder struct {
+	mock *MockWebhookService
+}
+
+// NewMockWebhookService creates a new mock instance
+func NewMockWebhookService(ctrl *gomock.Controller) *MockWebhookService {
+	mock := &MockWebhookService{ctrl: ctrl}
+	mock.recorder = &MockWebhookServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockWebhookService) EXPECT() *MockWebhookServiceMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method
+func (m *MockWebhookService) Create(sub webhooks.Subscription) (webhooks.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", sub)
+	ret0, _ := ret[0].(webhooks.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create
+func (mr *MockWebhookServiceMockRecorder) Create(sub interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockWebhookService)(nil).Create), sub)
+}
+
+// Update mocks base method
+func (m *MockWebhookService) Update(sub webhooks.Subscription) (webhooks.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", sub)
+	ret0, _ := ret[0].(webhooks.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Update indicates an expected call of Update
+func (mr *MockWebhookServiceMockRecorder) Update(sub interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockWebhookService)(nil).Update), sub)
+}
+
+// Remove mocks base method
+func (m *MockWebhookService) Remove(id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Remove", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Remove indicates an expected call of Remove
+func (mr *MockWebhookServiceMockRecorder) Remove(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Remove", reflect.TypeOf((*MockWebhookService)(nil).Remove), id)
+}
+
+// Get mocks base method
+func (m *MockWebhookService) Get(id string) (webhooks.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", id)
+	ret0, _ := ret[0].(webhooks.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get
+func (mr *MockWebhookServiceMockRecorder) Get(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockWebhookService)(nil).Get), id)
+}
+
+// All mocks base method
+func (m *MockWebhookService) All() ([]webhooks.Subscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "All")
+	ret0, _ := ret[0].([]webhooks.Subscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// All indicates an expected call of All
+func (mr *MockWebhookServiceMockRecorder) All() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "All", reflect.TypeOf((*MockWebhookService)(nil).All))
+}
+
+// Deliveries mocks base method
+func (m *MockWebhookService) Deliveries(id string) ([]webhooks.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Deliveries", id)
+	ret0, _ := ret[0].([]webhooks.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Deliveries indicates an expected call of Deliveries
+func (mr *MockWebhookServiceMockRecorder) Deliveries(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Deliveries", reflect.TypeOf((*MockWebhookService)(nil).Deliveries), id)
+}
+
+// DeadLetters mocks base method
+func (m *MockWebhookService) DeadLetters() ([]webhooks.Delivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeadLetters")
+	ret0, _ := ret[0].([]webhooks.Delivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeadLetters indicates an expected call of DeadLetters
+func (mr *MockWebhookServiceMockRecorder) DeadLetters() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeadLetters", reflect.TypeOf((*MockWebhookService)(nil).DeadLetters))
+}
+
+// MockKeyService is a mock of KeyService interface
+type MockKeyService struct {
+	ctrl     *gomock.Controller
+	recorder *MockKeyServiceMockRecorder
+}
+
+// MockKeyServiceMockRecorder is the mock recorder for MockKeyService
+type MockKeyServiceMockRecorder struct {
+	mock *MockKeyService
+}
+
+// NewMockKeyService creates a new mock instance
+func NewMockKeyService(ctrl *gomock.Controller) *MockKeyService {
+	mock := &MockKeyService{ctrl: ctrl}
+	mock.recorder = &MockKeyServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockKeyService) EXPECT() *MockKeyServiceMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method
+func (m *MockKeyService) Create(key auth.Key) (auth.IssuedKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", key)
+	ret0, _ := ret[0].(auth.IssuedKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create
+func (mr *MockKeyServiceMockRecorder) Create(key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockKeyService)(nil).Create), key)
+}
+
+// Rotate mocks base method
+func (m *MockKeyService) Rotate(id string) (auth.IssuedKey, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rotate", id)
+	ret0, _ := ret[0].(auth.IssuedKey)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Rotate indicates an expected call of Rotate
+func (mr *MockKeyServiceMockRecorder) Rotate(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rotate", reflect.TypeOf((*MockKeyService)(nil).Rotate), id)
+}
+
+// Revoke mocks base method
+func (m *MockKeyService) Revoke(id string) (auth.Key, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Revoke", id)
+	ret0, _ := ret[0].(auth.Key)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Revoke indicates an expected call of Revoke
+func (mr *MockKeyServiceMockRecorder) Revoke(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Revoke", reflect.TypeOf((*MockKeyService)(nil).Revoke), id)
+}
+
+// Get mocks base method
+func (m *MockKeyService) Get(id string) (auth.Key, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Get", id)
+	ret0, _ := ret[0].(auth.Key)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Get indicates an expected call of Get
+func (mr *MockKeyServiceMockRecorder) Get(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockKeyService)(nil).Get), id)
+}
+
+// All mocks base method
+func (m *MockKeyService) All() ([]auth.Key, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "All")
+	ret0, _ := ret[0].([]auth.Key)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// All indicates an expected call of All
+func (mr *MockKeyServiceMockRecorder) All() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "All", reflect.TypeOf((*MockKeyService)(nil).All))
+}
+
+// Authenticate mocks base method
+func (m *MockKeyService) Authenticate(token string) (auth.Identity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authenticate", token)
+	ret0, _ := ret[0].(auth.Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authenticate indicates an expected call of Authenticate
+func (mr *MockKeyServiceMockRecorder) Authenticate(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authenticate", reflect.TypeOf((*MockKeyService)(nil).Authenticate), token)
+}
+
+// MockTokenVerifier is a mock of TokenVerifier interface
+type MockTokenVerifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockTokenVerifierMockRecorder
+}
+
+// MockTokenVerifierMockRecorder is the mock recorder for MockTokenVerifier
+type MockTokenVerifierMockRecorder struct {
+	mock *MockTokenVerifier
+}
+
+// NewMockTokenVerifier creates a new mock instance
+func NewMockTokenVerifier(ctrl *gomock.Controller) *MockTokenVerifier {
+	mock := &MockTokenVerifier{ctrl: ctrl}
+	mock.recorder = &MockTokenVerifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockTokenVerifier) EXPECT() *MockTokenVerifierMockRecorder {
+	return m.recorder
+}
+
+// Verify mocks base method
+func (m *MockTokenVerifier) Verify(token string) (auth.Identity, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", token)
+	ret0, _ := ret[0].(auth.Identity)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Verify indicates an expected call of Verify
+func (mr *MockTokenVerifierMockRecorder) Verify(token interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockTokenVerifier)(nil).Verify), token)
+}
@@ -0,0 +1,122 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+
+	"github.com/davidlick/supermarket-api/internal/prices"
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/go-chi/chi"
+)
+
+// authorHeader is the request header naming who made a change.
+const authorHeader = "X-Author"
+
+// priceRequest is the body of a request to record a price change. A missing effective_from is effective now.
+type priceRequest struct {
+	Price         json.RawMessage `json:"price"`
+	EffectiveFrom time.Time       `json:"effective_from"`
+}
+
+func (s *server) priceGroup(r chi.Router) {
+	r.Route("/prices", func(r chi.Router) {
+		r.Get("/", s.handleGetPrices)
+		r.Post("/", s.handleAddPrice)
+	})
+}
+
+func (s *server) handleGetPrices(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	produceCode := chi.URLParam(r, "produceCode")
+	if produceCode == "" {
+		s.writeError(ctx, w, ErrUnrecognizedCode, http.StatusBadRequest)
+		return
+	}
+
+	if v := r.URL.Query().Get("at"); v != "" {
+		at, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			s.writeError(ctx, w, fmt.Errorf("%w: at must be an RFC 3339 time", ErrInvalidQuery), http.StatusBadRequest)
+			return
+		}
+
+		change, err := s.priceSvc.At(produceCode, at)
+		if err != nil {
+			s.writeError(ctx, w, err, errorStatus(err))
+			return
+		}
+
+		s.writeSuccess(ctx, w, change, http.StatusOK)
+		return
+	}
+
+	changes, err := s.priceSvc.History(produceCode)
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	if changes == nil {
+		changes = []prices.Change{}
+	}
+
+	s.writeSuccess(ctx, w, changes, http.StatusOK)
+	return
+}
+
+func (s *server) handleAddPrice(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	produceCode := chi.URLParam(r, "produceCode")
+	if produceCode == "" {
+		s.writeError(ctx, w, ErrUnrecognizedCode, http.StatusBadRequest)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		s.writeError(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	var req priceRequest
+	err = json.Unmarshal(body, &req)
+	if err != nil {
+		s.writeError(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	price, ve := produce.DecodePrice(req.Price)
+	if len(ve) > 0 {
+		s.writeError(ctx, w, ve, http.StatusUnprocessableEntity)
+		return
+	}
+
+	change, err := s.priceSvc.Record(produceCode, price, req.EffectiveFrom, author(r))
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	s.writeSuccess(ctx, w, change, http.StatusCreated)
+	return
+}
+
+// recordPrices records the prices of items set through the produce endpoints in their price history. The items have
+// already been stored, so failures are logged rather than returned.
+func (s *server) recordPrices(r *http.Request, items ...produce.Item) {
+	if s.priceSvc == nil {
+		return
+	}
+
+	for _, item := range items {
+		err := s.priceSvc.RecordCurrent(item, author(r))
+		if err != nil {
+			s.requestLogger(r.Context()).Errorf("could not record price of %s: %v", item.Code, err)
+		}
+	}
+}
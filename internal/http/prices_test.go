@@ -0,0 +1,282 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/davidlick/supermarket-api/internal/prices"
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_handleGetPrices(t *testing.T) {
+	effectiveFrom := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	change := prices.Change{
+		Code:          "test-code",
+		Price:         money.New(101, "USD"),
+		EffectiveFrom: effectiveFrom,
+		Author:        "author",
+		RecordedAt:    effectiveFrom,
+		Applied:       true,
+	}
+
+	tests := []struct {
+		test       string
+		query      string
+		expectFunc func(mockPriceSvc *MockPriceService)
+		assertFunc func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			test: "it should respond with the price history",
+			expectFunc: func(mockPriceSvc *MockPriceService) {
+				mockPriceSvc.EXPECT().History("test-code").Return([]prices.Change{change}, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+
+				b, err := ioutil.ReadAll(w.Body)
+				if err != nil {
+					t.Error(err)
+				}
+
+				assert.Equal(t, "[{\"code\":\"test-code\",\"price\":{\"amount\":101,\"currency\":\"USD\"},\"effective_from\":\"2021-03-01T12:00:00Z\",\"author\":\"author\",\"recorded_at\":\"2021-03-01T12:00:00Z\",\"applied\":true}]\n", string(b))
+			},
+		},
+		{
+			test: "it should respond with an empty array if there is no history",
+			expectFunc: func(mockPriceSvc *MockPriceService) {
+				mockPriceSvc.EXPECT().History("test-code").Return(nil, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+
+				b, err := ioutil.ReadAll(w.Body)
+				if err != nil {
+					t.Error(err)
+				}
+
+				assert.Equal(t, "[]\n", string(b))
+			},
+		},
+		{
+			test:  "it should respond with the price at the given time",
+			query: "?at=2021-03-01T13:00:00%2B01:00",
+			expectFunc: func(mockPriceSvc *MockPriceService) {
+				mockPriceSvc.EXPECT().At("test-code", gomock.Any()).DoAndReturn(func(_ string, at time.Time) (prices.Change, error) {
+					assert.True(t, at.Equal(effectiveFrom))
+					return change, nil
+				})
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			test:       "it should respond bad request if the time isn't RFC 3339",
+			query:      "?at=yesterday",
+			expectFunc: func(mockPriceSvc *MockPriceService) {},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			test:  "it should respond not found if there was no price at the given time",
+			query: "?at=2000-01-01T00:00:00Z",
+			expectFunc: func(mockPriceSvc *MockPriceService) {
+				mockPriceSvc.EXPECT().At("test-code", gomock.Any()).Return(prices.Change{}, prices.ErrNoPrice)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, w.Code)
+			},
+		},
+		{
+			test: "it should respond internal server error if reading the history fails",
+			expectFunc: func(mockPriceSvc *MockPriceService) {
+				mockPriceSvc.EXPECT().History("test-code").Return(nil, errors.New("test error"))
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, w.Code)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			r := httptest.NewRequest(http.MethodGet, "/v1/produce/test-code/prices"+tc.query, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("produceCode", "test-code")
+			ctx := context.WithValue(context.Background(), chi.RouteCtxKey, rctx)
+
+			r = r.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			noopLogger := logrus.New()
+			noopLogger.SetOutput(ioutil.Discard)
+
+			mockPriceSvc := NewMockPriceService(ctrl)
+			tc.expectFunc(mockPriceSvc)
+
+			s := NewServer(3000, noopLogger, "test", nil, WithPriceService(mockPriceSvc))
+
+			handler := http.HandlerFunc(s.handleGetPrices)
+			handler.ServeHTTP(w, r)
+
+			tc.assertFunc(t, w)
+		})
+	}
+}
+
+func TestServer_handleAddPrice(t *testing.T) {
+	effectiveFrom := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		test       string
+		body       string
+		expectFunc func(mockPriceSvc *MockPriceService)
+		assertFunc func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			test: "it should record a scheduled price change",
+			body: `{"price":{"amount":101,"currency":"USD"},"effective_from":"2021-03-01T12:00:00Z"}`,
+			expectFunc: func(mockPriceSvc *MockPriceService) {
+				mockPriceSvc.EXPECT().Record("test-code", money.New(101, "USD"), effectiveFrom, "author").
+					Return(prices.Change{Code: "test-code", Price: money.New(101, "USD"), EffectiveFrom: effectiveFrom, Author: "author"}, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusCreated, w.Code)
+			},
+		},
+		{
+			test: "it should record a price change effective now without an effective time",
+			body: `{"price":{"amount":101,"currency":"USD"}}`,
+			expectFunc: func(mockPriceSvc *MockPriceService) {
+				mockPriceSvc.EXPECT().Record("test-code", money.New(101, "USD"), time.Time{}, "author").Return(prices.Change{}, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusCreated, w.Code)
+			},
+		},
+		{
+			test:       "it should respond bad request if the body isn't a price change",
+			body:       `[]`,
+			expectFunc: func(mockPriceSvc *MockPriceService) {},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			test: "it should respond unprocessable entity if the price is invalid",
+			body: `{"price":{"amount":-1,"currency":"USD"}}`,
+			expectFunc: func(mockPriceSvc *MockPriceService) {
+				mockPriceSvc.EXPECT().Record("test-code", gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(prices.Change{}, produce.ValidationErrors{{Field: "price.amount", Message: "must be positive"}})
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+			},
+		},
+		{
+			test:       "it should respond unprocessable entity if the price has no currency",
+			body:       `{"price":{"amount":500}}`,
+			expectFunc: func(mockPriceSvc *MockPriceService) {},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				assert.Contains(t, w.Body.String(), "price.currency")
+			},
+		},
+		{
+			test:       "it should respond unprocessable entity if the price has no amount",
+			body:       `{"price":{"currency":"USD"}}`,
+			expectFunc: func(mockPriceSvc *MockPriceService) {},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				assert.Contains(t, w.Body.String(), "price.amount")
+			},
+		},
+		{
+			test: "it should respond not found if the item doesn't exist",
+			body: `{"price":{"amount":101,"currency":"USD"}}`,
+			expectFunc: func(mockPriceSvc *MockPriceService) {
+				mockPriceSvc.EXPECT().Record("test-code", gomock.Any(), gomock.Any(), gomock.Any()).Return(prices.Change{}, ramdb.ErrNoRecord)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, w.Code)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			r := httptest.NewRequest(http.MethodPost, fmt.Sprintf("/v1/produce/%s/prices", "test-code"), strings.NewReader(tc.body))
+			r.Header.Set("X-Author", "author")
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("produceCode", "test-code")
+			ctx := context.WithValue(context.Background(), chi.RouteCtxKey, rctx)
+
+			r = r.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			noopLogger := logrus.New()
+			noopLogger.SetOutput(ioutil.Discard)
+
+			mockPriceSvc := NewMockPriceService(ctrl)
+			tc.expectFunc(mockPriceSvc)
+
+			s := NewServer(3000, noopLogger, "test", nil, WithPriceService(mockPriceSvc))
+
+			handler := http.HandlerFunc(s.handleAddPrice)
+			handler.ServeHTTP(w, r)
+
+			tc.assertFunc(t, w)
+		})
+	}
+}
+
+func TestServer_recordPrices(t *testing.T) {
+	t.Run("it should record the price of a patched item without failing the request", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		item := produce.Item{Code: "test-code", Name: "test", Price: money.New(101, "USD"), Version: 2}
+
+		r := httptest.NewRequest(http.MethodPatch, "/v1/produce/test-code", strings.NewReader(`{"price":{"amount":101,"currency":"USD"}}`))
+		r.Header.Set("X-Author", "author")
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("produceCode", "test-code")
+		r = r.WithContext(context.WithValue(context.Background(), chi.RouteCtxKey, rctx))
+		w := httptest.NewRecorder()
+
+		noopLogger := logrus.New()
+		noopLogger.SetOutput(ioutil.Discard)
+
+		mockProduceSvc := NewMockProduceService(ctrl)
+		mockProduceSvc.EXPECT().Patch("test-code", gomock.Any()).Return(item, nil)
+		mockPriceSvc := NewMockPriceService(ctrl)
+		mockPriceSvc.EXPECT().RecordCurrent(item, "author").Return(errors.New("test error"))
+
+		s := NewServer(3000, noopLogger, "test", mockProduceSvc, WithPriceService(mockPriceSvc))
+
+		handler := http.HandlerFunc(s.handlePatchProduce)
+		handler.ServeHTTP(w, r)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
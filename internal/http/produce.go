@@ -2,9 +2,16 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
+	"github.com/davidlick/supermarket-api/internal/auth"
+	"github.com/davidlick/supermarket-api/internal/inventory"
 	"github.com/davidlick/supermarket-api/internal/produce"
 	"github.com/go-chi/chi"
 )
@@ -12,10 +19,20 @@ import (
 func (s *server) produceGroup(r chi.Router) {
 	r.Group(func(r chi.Router) {
 		r.Route("/produce", func(r chi.Router) {
+			r.Use(s.authorize(auth.RoleReader, auth.RolePricingEditor))
 			r.Get("/", s.handleGetAllProduce)
 			r.Post("/", s.handleAddProduce)
+			r.Get("/search", s.handleSearchProduce)
+			r.Get("/events", s.handleProduceEvents)
 			r.Route("/{produceCode}", func(r chi.Router) {
+				r.Get("/", s.handleGetProduce)
+				r.Put("/", s.handleUpdateProduce)
+				r.Patch("/", s.handlePatchProduce)
 				r.Delete("/", s.handleDeleteProduce)
+
+				if s.priceSvc != nil {
+					s.priceGroup(r)
+				}
 			})
 		})
 	})
@@ -30,19 +47,20 @@ func (s *server) handleAddProduce(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var items []produce.Item
-	err = json.Unmarshal(body, &items)
+	items, err := produce.DecodeItems(body)
 	if err != nil {
-		s.writeError(ctx, w, err, http.StatusBadRequest)
+		s.writeError(ctx, w, err, decodeStatus(err))
 		return
 	}
 
 	err = s.produceSvc.Add(items)
 	if err != nil {
-		s.writeError(ctx, w, err, http.StatusInternalServerError)
+		s.writeError(ctx, w, err, errorStatus(err))
 		return
 	}
 
+	s.recordPrices(r, items...)
+
 	s.writeSuccess(ctx, w, nil, http.StatusCreated)
 	return
 }
@@ -50,13 +68,241 @@ func (s *server) handleAddProduce(w http.ResponseWriter, r *http.Request) {
 func (s *server) handleGetAllProduce(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	items, err := s.produceSvc.All()
+	opts, err := listOptions(r.URL.Query())
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	currency, err := s.conversionCurrency(r.URL.Query())
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	items, next, err := s.produceSvc.List(opts)
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	if items == nil {
+		items = []produce.Item{}
+	}
+
+	var resp interface{} = items
+	if currency != "" {
+		resp, err = s.convertItems(items, currency)
+		if err != nil {
+			s.writeError(ctx, w, err, errorStatus(err))
+			return
+		}
+	}
+
+	if next != "" {
+		w.Header().Set("X-Next-Cursor", next)
+	}
+
+	s.writeSuccess(ctx, w, resp, http.StatusOK)
+	return
+}
+
+// listOptions parses the query parameters of a request to list produce. Sorting by a field prefixed with "-" sorts
+// in descending order.
+func listOptions(query url.Values) (opts produce.ListOptions, err error) {
+	opts = produce.ListOptions{
+		Cursor:       query.Get("cursor"),
+		Prefix:       query.Get("prefix"),
+		NameContains: query.Get("name"),
+		Currency:     query.Get("currency"),
+	}
+
+	opts.Sort = query.Get("sort")
+	if strings.HasPrefix(opts.Sort, "-") {
+		opts.Sort = opts.Sort[1:]
+		opts.Descending = true
+	}
+
+	for param, into := range map[string]*int64{"min_price": &opts.MinPrice, "max_price": &opts.MaxPrice} {
+		if v := query.Get(param); v != "" {
+			*into, err = strconv.ParseInt(v, 10, 64)
+			if err != nil || *into < 0 {
+				return opts, fmt.Errorf("%w: %s must be a non-negative integer", ErrInvalidQuery, param)
+			}
+		}
+	}
+
+	if v := query.Get("limit"); v != "" {
+		opts.Limit, err = strconv.Atoi(v)
+		if err != nil || opts.Limit <= 0 {
+			return opts, fmt.Errorf("%w: limit must be a positive integer", ErrInvalidQuery)
+		}
+	}
+
+	return opts, nil
+}
+
+func (s *server) handleSearchProduce(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	query := r.URL.Query()
+	q := strings.TrimSpace(query.Get("q"))
+	if q == "" {
+		err := fmt.Errorf("%w: q is required", ErrInvalidQuery)
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	var limit int
+	if v := query.Get("limit"); v != "" {
+		var err error
+		limit, err = strconv.Atoi(v)
+		if err != nil || limit <= 0 {
+			err = fmt.Errorf("%w: limit must be a positive integer", ErrInvalidQuery)
+			s.writeError(ctx, w, err, errorStatus(err))
+			return
+		}
+	}
+
+	currency, err := s.conversionCurrency(query)
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	items, err := s.produceSvc.Search(q, limit)
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	if items == nil {
+		items = []produce.Item{}
+	}
+
+	var resp interface{} = items
+	if currency != "" {
+		resp, err = s.convertItems(items, currency)
+		if err != nil {
+			s.writeError(ctx, w, err, errorStatus(err))
+			return
+		}
+	}
+
+	s.writeSuccess(ctx, w, resp, http.StatusOK)
+	return
+}
+
+func (s *server) handleGetProduce(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	produceCode := chi.URLParam(r, "produceCode")
+	if produceCode == "" {
+		s.writeError(ctx, w, ErrUnrecognizedCode, http.StatusBadRequest)
+		return
+	}
+
+	currency, err := s.conversionCurrency(r.URL.Query())
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	item, err := s.produceSvc.Get(produceCode)
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	if currency != "" {
+		converted, err := s.convertItems([]produce.Item{item}, currency)
+		if err != nil {
+			s.writeError(ctx, w, err, errorStatus(err))
+			return
+		}
+
+		s.writeSuccess(ctx, w, converted[0], http.StatusOK)
+		return
+	}
+
+	s.writeSuccess(ctx, w, item, http.StatusOK)
+	return
+}
+
+func (s *server) handleUpdateProduce(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	produceCode := chi.URLParam(r, "produceCode")
+	if produceCode == "" {
+		s.writeError(ctx, w, ErrUnrecognizedCode, http.StatusBadRequest)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		s.writeError(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	var item produce.Item
+	err = json.Unmarshal(body, &item)
+	if err != nil {
+		s.writeError(ctx, w, err, decodeStatus(err))
+		return
+	}
+
+	if item.Code == "" {
+		item.Code = produceCode
+	}
+
+	if !strings.EqualFold(item.Code, produceCode) {
+		s.writeError(ctx, w, ErrCodeMismatch, http.StatusBadRequest)
+		return
+	}
+
+	item, err = s.produceSvc.Update(item)
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	s.recordPrices(r, item)
+
+	s.writeSuccess(ctx, w, item, http.StatusOK)
+	return
+}
+
+func (s *server) handlePatchProduce(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	produceCode := chi.URLParam(r, "produceCode")
+	if produceCode == "" {
+		s.writeError(ctx, w, ErrUnrecognizedCode, http.StatusBadRequest)
+		return
+	}
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		s.writeError(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	var patch produce.ItemPatch
+	err = json.Unmarshal(body, &patch)
 	if err != nil {
-		s.writeError(ctx, w, err, http.StatusInternalServerError)
+		s.writeError(ctx, w, err, decodeStatus(err))
 		return
 	}
 
-	s.writeSuccess(ctx, w, items, http.StatusOK)
+	item, err := s.produceSvc.Patch(produceCode, patch)
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	s.recordPrices(r, item)
+
+	s.writeSuccess(ctx, w, item, http.StatusOK)
 	return
 }
 
@@ -69,11 +315,20 @@ func (s *server) handleDeleteProduce(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err := s.produceSvc.Remove(produce.Item{
+	// Produce still in stock is only removed when forced, and its stock is removed with it.
+	remove := s.produceSvc.Remove
+	if r.URL.Query().Get("force") == "true" {
+		remove = s.produceSvc.ForceRemove
+	}
+
+	err := remove(produce.Item{
 		Code: produceCode,
 	})
+	if errors.Is(err, inventory.ErrInStock) {
+		err = fmt.Errorf("%w, delete with ?force=true to remove it anyway", err)
+	}
 	if err != nil {
-		s.writeError(ctx, w, err, http.StatusInternalServerError)
+		s.writeError(ctx, w, err, errorStatus(err))
 		return
 	}
 
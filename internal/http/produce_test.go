@@ -12,6 +12,7 @@ import (
 
 	"github.com/Rhymond/go-money"
 	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
 	"github.com/go-chi/chi"
 	"github.com/golang/mock/gomock"
 	"github.com/sirupsen/logrus"
@@ -49,6 +50,43 @@ func TestServer_handleAddProduce(t *testing.T) {
 				assert.Equal(t, http.StatusBadRequest, w.Code)
 			},
 		},
+		{
+			test:       "it should respond unprocessable entity if a price is missing its fields",
+			body:       `[{"code":"test","Name":"test","price":{}}]`,
+			expectFunc: func(mockProduceSvc *MockProduceService) {},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+			},
+		},
+		{
+			test: "it should respond conflict if an item already exists",
+			body: `[{"code":"test","Name":"test","price":{"amount":101,"currency":"USD"}}]`,
+			expectFunc: func(mockProduceSvc *MockProduceService) {
+				mockProduceSvc.EXPECT().Add(gomock.Any()).Return(ramdb.ErrRecordExists)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusConflict, w.Code)
+			},
+		},
+		{
+			test: "it should respond unprocessable entity with field details if an item is invalid",
+			body: `[{"Name":"test","price":{"amount":101,"currency":"USD"}}]`,
+			expectFunc: func(mockProduceSvc *MockProduceService) {
+				mockProduceSvc.EXPECT().Add(gomock.Any()).Return(produce.ValidationErrors{
+					{Index: 0, Field: "code", Message: "must be four groups of four letters or digits separated by dashes"},
+				})
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+
+				b, err := ioutil.ReadAll(w.Body)
+				if err != nil {
+					t.Error(err)
+				}
+
+				assert.Equal(t, "{\"type\":\"about:blank\",\"title\":\"Unprocessable Entity\",\"status\":422,\"detail\":\"invalid produce item: item 0: code must be four groups of four letters or digits separated by dashes\",\"code\":\"invalid_item\",\"errors\":[{\"index\":0,\"field\":\"code\",\"message\":\"must be four groups of four letters or digits separated by dashes\"}]}\n", string(b))
+			},
+		},
 		{
 			test: "it should respond internal server error if adding to service fails",
 			body: `[{"code":"test","Name":"test","price":{"amount":101,"currency":"USD"}}]`,
@@ -88,20 +126,22 @@ func TestServer_handleAddProduce(t *testing.T) {
 func TestServer_handleGetAllProduce(t *testing.T) {
 	tests := []struct {
 		test       string
+		query      string
 		expectFunc func(mockProduceSvc *MockProduceService)
 		assertFunc func(t *testing.T, w *httptest.ResponseRecorder)
 	}{
 		{
 			test: "it should successfully get all produce",
 			expectFunc: func(mockProduceSvc *MockProduceService) {
-				mockProduceSvc.EXPECT().All().Return([]produce.Item{
+				mockProduceSvc.EXPECT().List(produce.ListOptions{}).Return([]produce.Item{
 					{Code: "code-1", Name: "name-1", Price: money.New(101, "USD")},
 					{Code: "code-2", Name: "name-2", Price: money.New(202, "USD")},
 					{Code: "code-3", Name: "name-3", Price: money.New(303, "USD")},
-				}, nil)
+				}, "", nil)
 			},
 			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
 				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Empty(t, w.Header().Get("X-Next-Cursor"))
 
 				b, err := ioutil.ReadAll(w.Body)
 				if err != nil {
@@ -111,10 +151,112 @@ func TestServer_handleGetAllProduce(t *testing.T) {
 				assert.Equal(t, "[{\"code\":\"code-1\",\"name\":\"name-1\",\"price\":{\"amount\":101,\"currency\":\"USD\"}},{\"code\":\"code-2\",\"name\":\"name-2\",\"price\":{\"amount\":202,\"currency\":\"USD\"}},{\"code\":\"code-3\",\"name\":\"name-3\",\"price\":{\"amount\":303,\"currency\":\"USD\"}}]\n", string(b))
 			},
 		},
+		{
+			test:  "it should get produce with codes beginning with the prefix",
+			query: "?prefix=A12T",
+			expectFunc: func(mockProduceSvc *MockProduceService) {
+				mockProduceSvc.EXPECT().List(produce.ListOptions{Prefix: "A12T"}).Return([]produce.Item{
+					{Code: "A12T-4GH7-QPL9-3N4M", Name: "Lettuce", Price: money.New(346, "USD")},
+				}, "", nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+
+				b, err := ioutil.ReadAll(w.Body)
+				if err != nil {
+					t.Error(err)
+				}
+
+				assert.Equal(t, "[{\"code\":\"A12T-4GH7-QPL9-3N4M\",\"name\":\"Lettuce\",\"price\":{\"amount\":346,\"currency\":\"USD\"}}]\n", string(b))
+			},
+		},
+		{
+			test:  "it should pass sorting, filtering and paging to the service and respond with the next cursor",
+			query: "?sort=-price&limit=2&cursor=abc&name=pepper&currency=USD&min_price=50&max_price=500",
+			expectFunc: func(mockProduceSvc *MockProduceService) {
+				mockProduceSvc.EXPECT().List(produce.ListOptions{
+					Sort:         "price",
+					Descending:   true,
+					Limit:        2,
+					Cursor:       "abc",
+					NameContains: "pepper",
+					Currency:     "USD",
+					MinPrice:     50,
+					MaxPrice:     500,
+				}).Return([]produce.Item{}, "next-cursor", nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Equal(t, "next-cursor", w.Header().Get("X-Next-Cursor"))
+
+				b, err := ioutil.ReadAll(w.Body)
+				if err != nil {
+					t.Error(err)
+				}
+
+				assert.Equal(t, "[]\n", string(b))
+			},
+		},
+		{
+			test:       "it should respond bad request if limit is not a positive integer",
+			query:      "?limit=-1",
+			expectFunc: func(mockProduceSvc *MockProduceService) {},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			test:       "it should respond bad request if a price bound is not an integer",
+			query:      "?min_price=1.50",
+			expectFunc: func(mockProduceSvc *MockProduceService) {},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			test:  "it should respond bad request if the sort field is unknown",
+			query: "?sort=weight",
+			expectFunc: func(mockProduceSvc *MockProduceService) {
+				mockProduceSvc.EXPECT().List(produce.ListOptions{Sort: "weight"}).Return(nil, "", produce.ErrInvalidSort)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			test:  "it should respond bad request if the cursor is invalid",
+			query: "?cursor=abc",
+			expectFunc: func(mockProduceSvc *MockProduceService) {
+				mockProduceSvc.EXPECT().List(produce.ListOptions{Cursor: "abc"}).Return(nil, "", ramdb.ErrInvalidCursor)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			test:  "it should respond bad request if the cursor is for another sort",
+			query: "?sort=name&cursor=abc",
+			expectFunc: func(mockProduceSvc *MockProduceService) {
+				mockProduceSvc.EXPECT().List(produce.ListOptions{Sort: "name", Cursor: "abc"}).Return(nil, "", ramdb.ErrCursorMismatch)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			test:  "it should respond bad request if a price bound has no currency",
+			query: "?min_price=100",
+			expectFunc: func(mockProduceSvc *MockProduceService) {
+				mockProduceSvc.EXPECT().List(produce.ListOptions{MinPrice: 100}).Return(nil, "", produce.ErrCurrencyRequired)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
 		{
 			test: "it should respond internal server error if adding to service fails",
 			expectFunc: func(mockProduceSvc *MockProduceService) {
-				mockProduceSvc.EXPECT().All().Return(nil, errors.New("test error"))
+				mockProduceSvc.EXPECT().List(produce.ListOptions{}).Return(nil, "", errors.New("test error"))
 			},
 			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
 				assert.Equal(t, http.StatusInternalServerError, w.Code)
@@ -127,7 +269,7 @@ func TestServer_handleGetAllProduce(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
-			r := httptest.NewRequest(http.MethodGet, "/v1/produce", nil)
+			r := httptest.NewRequest(http.MethodGet, "/v1/produce"+tc.query, nil)
 			w := httptest.NewRecorder()
 
 			noopLogger := logrus.New()
@@ -146,6 +288,183 @@ func TestServer_handleGetAllProduce(t *testing.T) {
 	}
 }
 
+func TestServer_handleSearchProduce(t *testing.T) {
+	tests := []struct {
+		test       string
+		query      string
+		expectFunc func(mockProduceSvc *MockProduceService)
+		assertFunc func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			test:  "it should respond with the matching items",
+			query: "?q=pepper&limit=5",
+			expectFunc: func(mockProduceSvc *MockProduceService) {
+				mockProduceSvc.EXPECT().Search("pepper", 5).Return([]produce.Item{
+					{Code: "YRT6-72AS-K736-L4AR", Name: "Green Pepper", Price: money.New(79, "USD")},
+				}, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+
+				b, err := ioutil.ReadAll(w.Body)
+				if err != nil {
+					t.Error(err)
+				}
+
+				assert.Equal(t, "[{\"code\":\"YRT6-72AS-K736-L4AR\",\"name\":\"Green Pepper\",\"price\":{\"amount\":79,\"currency\":\"USD\"}}]\n", string(b))
+			},
+		},
+		{
+			test:  "it should respond with an empty list if nothing matches",
+			query: "?q=zucchini",
+			expectFunc: func(mockProduceSvc *MockProduceService) {
+				mockProduceSvc.EXPECT().Search("zucchini", 0).Return(nil, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+
+				b, err := ioutil.ReadAll(w.Body)
+				if err != nil {
+					t.Error(err)
+				}
+
+				assert.Equal(t, "[]\n", string(b))
+			},
+		},
+		{
+			test:       "it should respond bad request if q is missing",
+			query:      "?q=%20",
+			expectFunc: func(mockProduceSvc *MockProduceService) {},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			test:       "it should respond bad request if the limit is not a positive integer",
+			query:      "?q=pepper&limit=0",
+			expectFunc: func(mockProduceSvc *MockProduceService) {},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			test:  "it should respond internal server error if searching fails",
+			query: "?q=pepper",
+			expectFunc: func(mockProduceSvc *MockProduceService) {
+				mockProduceSvc.EXPECT().Search("pepper", 0).Return(nil, errors.New("test error"))
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, w.Code)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			r := httptest.NewRequest(http.MethodGet, "/v1/produce/search"+tc.query, nil)
+			w := httptest.NewRecorder()
+
+			noopLogger := logrus.New()
+			noopLogger.SetOutput(ioutil.Discard)
+
+			mockProduceSvc := NewMockProduceService(ctrl)
+			tc.expectFunc(mockProduceSvc)
+
+			s := NewServer(3000, noopLogger, "test", mockProduceSvc)
+
+			handler := http.HandlerFunc(s.handleSearchProduce)
+			handler.ServeHTTP(w, r)
+
+			tc.assertFunc(t, w)
+		})
+	}
+}
+
+func TestServer_handleGetProduce(t *testing.T) {
+	tests := []struct {
+		test        string
+		produceCode string
+		expectFunc  func(mockProduceSvc *MockProduceService)
+		assertFunc  func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			test:        "it should respond with the item when successful",
+			produceCode: "test-code",
+			expectFunc: func(mockProduceSvc *MockProduceService) {
+				mockProduceSvc.EXPECT().Get("test-code").Return(produce.Item{Code: "test-code", Name: "test", Price: money.New(101, "USD"), Version: 1}, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+
+				b, err := ioutil.ReadAll(w.Body)
+				if err != nil {
+					t.Error(err)
+				}
+
+				assert.Equal(t, "{\"code\":\"test-code\",\"name\":\"test\",\"price\":{\"amount\":101,\"currency\":\"USD\"},\"version\":1}\n", string(b))
+			},
+		},
+		{
+			test:        "it should respond not found if the item does not exist",
+			produceCode: "test-code",
+			expectFunc: func(mockProduceSvc *MockProduceService) {
+				mockProduceSvc.EXPECT().Get("test-code").Return(produce.Item{}, ramdb.ErrNoRecord)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, w.Code)
+			},
+		},
+		{
+			test:        "it should respond internal server error if getting from the service fails",
+			produceCode: "test-code",
+			expectFunc: func(mockProduceSvc *MockProduceService) {
+				mockProduceSvc.EXPECT().Get("test-code").Return(produce.Item{}, errors.New("test error"))
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, w.Code)
+			},
+		},
+		{
+			test:       "it should respond bad request if no produce code is supplied",
+			expectFunc: func(mockProduceSvc *MockProduceService) {},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			r := httptest.NewRequest(http.MethodGet, fmt.Sprintf("/v1/produce/%s", tc.produceCode), nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("produceCode", tc.produceCode)
+			ctx := context.WithValue(context.Background(), chi.RouteCtxKey, rctx)
+
+			r = r.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			noopLogger := logrus.New()
+			noopLogger.SetOutput(ioutil.Discard)
+
+			mockProduceSvc := NewMockProduceService(ctrl)
+			tc.expectFunc(mockProduceSvc)
+
+			s := NewServer(3000, noopLogger, "test", mockProduceSvc)
+
+			handler := http.HandlerFunc(s.handleGetProduce)
+			handler.ServeHTTP(w, r)
+
+			tc.assertFunc(t, w)
+		})
+	}
+}
+
 func TestServer_handleDeleteProduce(t *testing.T) {
 	tests := []struct {
 		test        string
@@ -163,6 +482,16 @@ func TestServer_handleDeleteProduce(t *testing.T) {
 				assert.Equal(t, http.StatusNoContent, w.Code)
 			},
 		},
+		{
+			test:        "it should respond not found if the item does not exist",
+			produceCode: "test-code",
+			expectFunc: func(mockProduceSvc *MockProduceService) {
+				mockProduceSvc.EXPECT().Remove(produce.Item{Code: "test-code"}).Return(ramdb.ErrNoRecord)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, w.Code)
+			},
+		},
 		{
 			test:        "it should respond internal server error if adding to service fails",
 			produceCode: "test-code",
@@ -210,3 +539,216 @@ func TestServer_handleDeleteProduce(t *testing.T) {
 		})
 	}
 }
+
+func TestServer_handleUpdateProduce(t *testing.T) {
+	tests := []struct {
+		test        string
+		produceCode string
+		body        string
+		expectFunc  func(mockProduceSvc *MockProduceService)
+		assertFunc  func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			test:        "it should respond with the updated item when successful",
+			produceCode: "test-code",
+			body:        `{"name":"test","price":{"amount":101,"currency":"USD"},"version":1}`,
+			expectFunc: func(mockProduceSvc *MockProduceService) {
+				mockProduceSvc.EXPECT().Update(produce.Item{
+					Code:    "test-code",
+					Name:    "test",
+					Price:   money.New(101, "USD"),
+					Version: 1,
+				}).Return(produce.Item{Code: "test-code", Name: "test", Price: money.New(101, "USD"), Version: 2}, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+
+				b, err := ioutil.ReadAll(w.Body)
+				if err != nil {
+					t.Error(err)
+				}
+
+				assert.Equal(t, "{\"code\":\"test-code\",\"name\":\"test\",\"price\":{\"amount\":101,\"currency\":\"USD\"},\"version\":2}\n", string(b))
+			},
+		},
+		{
+			test:        "it should respond bad request if the body code does not match the url",
+			produceCode: "test-code",
+			body:        `{"code":"other-code","name":"test","price":{"amount":101,"currency":"USD"}}`,
+			expectFunc:  func(mockProduceSvc *MockProduceService) {},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			test:        "it should respond bad request if the body isn't a produce item",
+			produceCode: "test-code",
+			body:        `[]`,
+			expectFunc:  func(mockProduceSvc *MockProduceService) {},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			test:        "it should respond not found if the item does not exist",
+			produceCode: "test-code",
+			body:        `{"name":"test","price":{"amount":101,"currency":"USD"}}`,
+			expectFunc: func(mockProduceSvc *MockProduceService) {
+				mockProduceSvc.EXPECT().Update(gomock.Any()).Return(produce.Item{}, ramdb.ErrNoRecord)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, w.Code)
+			},
+		},
+		{
+			test:        "it should respond conflict if the version does not match",
+			produceCode: "test-code",
+			body:        `{"name":"test","price":{"amount":101,"currency":"USD"},"version":1}`,
+			expectFunc: func(mockProduceSvc *MockProduceService) {
+				mockProduceSvc.EXPECT().Update(gomock.Any()).Return(produce.Item{}, ramdb.ErrVersionMismatch)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusConflict, w.Code)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			r := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/v1/produce/%s", tc.produceCode), strings.NewReader(tc.body))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("produceCode", tc.produceCode)
+			ctx := context.WithValue(context.Background(), chi.RouteCtxKey, rctx)
+
+			r = r.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			noopLogger := logrus.New()
+			noopLogger.SetOutput(ioutil.Discard)
+
+			mockProduceSvc := NewMockProduceService(ctrl)
+			tc.expectFunc(mockProduceSvc)
+
+			s := NewServer(3000, noopLogger, "test", mockProduceSvc)
+
+			handler := http.HandlerFunc(s.handleUpdateProduce)
+			handler.ServeHTTP(w, r)
+
+			tc.assertFunc(t, w)
+		})
+	}
+}
+
+func TestServer_handlePatchProduce(t *testing.T) {
+	name := "test"
+	pound := produce.UnitPound
+
+	tests := []struct {
+		test        string
+		produceCode string
+		body        string
+		expectFunc  func(mockProduceSvc *MockProduceService)
+		assertFunc  func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			test:        "it should respond with the patched item when successful",
+			produceCode: "test-code",
+			body:        `{"name":"test"}`,
+			expectFunc: func(mockProduceSvc *MockProduceService) {
+				mockProduceSvc.EXPECT().Patch("test-code", produce.ItemPatch{Name: &name}).
+					Return(produce.Item{Code: "test-code", Name: "test", Price: money.New(101, "USD"), Version: 2}, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+
+				b, err := ioutil.ReadAll(w.Body)
+				if err != nil {
+					t.Error(err)
+				}
+
+				assert.Equal(t, "{\"code\":\"test-code\",\"name\":\"test\",\"price\":{\"amount\":101,\"currency\":\"USD\"},\"version\":2}\n", string(b))
+			},
+		},
+		{
+			test:        "it should respond with the item's unit when it is changed",
+			produceCode: "test-code",
+			body:        `{"unit":"lb"}`,
+			expectFunc: func(mockProduceSvc *MockProduceService) {
+				mockProduceSvc.EXPECT().Patch("test-code", produce.ItemPatch{Unit: &pound}).
+					Return(produce.Item{Code: "test-code", Name: "test", Price: money.New(101, "USD"), Unit: produce.UnitPound, Version: 2}, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+
+				b, err := ioutil.ReadAll(w.Body)
+				if err != nil {
+					t.Error(err)
+				}
+
+				assert.Equal(t, "{\"code\":\"test-code\",\"name\":\"test\",\"price\":{\"amount\":101,\"currency\":\"USD\"},\"unit\":\"lb\",\"version\":2}\n", string(b))
+			},
+		},
+		{
+			test:        "it should respond bad request if the body isn't a patch",
+			produceCode: "test-code",
+			body:        `[]`,
+			expectFunc:  func(mockProduceSvc *MockProduceService) {},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			test:        "it should respond conflict if the version does not match",
+			produceCode: "test-code",
+			body:        `{"price":{"amount":101,"currency":"USD"},"version":3}`,
+			expectFunc: func(mockProduceSvc *MockProduceService) {
+				mockProduceSvc.EXPECT().Patch("test-code", gomock.Any()).Return(produce.Item{}, ramdb.ErrVersionMismatch)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusConflict, w.Code)
+			},
+		},
+		{
+			test:        "it should respond internal server error if patching fails",
+			produceCode: "test-code",
+			body:        `{"name":"test"}`,
+			expectFunc: func(mockProduceSvc *MockProduceService) {
+				mockProduceSvc.EXPECT().Patch("test-code", gomock.Any()).Return(produce.Item{}, errors.New("test error"))
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, w.Code)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			r := httptest.NewRequest(http.MethodPatch, fmt.Sprintf("/v1/produce/%s", tc.produceCode), strings.NewReader(tc.body))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("produceCode", tc.produceCode)
+			ctx := context.WithValue(context.Background(), chi.RouteCtxKey, rctx)
+
+			r = r.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			noopLogger := logrus.New()
+			noopLogger.SetOutput(ioutil.Discard)
+
+			mockProduceSvc := NewMockProduceService(ctrl)
+			tc.expectFunc(mockProduceSvc)
+
+			s := NewServer(3000, noopLogger, "test", mockProduceSvc)
+
+			handler := http.HandlerFunc(s.handlePatchProduce)
+			handler.ServeHTTP(w, r)
+
+			tc.assertFunc(t, w)
+		})
+	}
+}
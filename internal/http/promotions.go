@@ -0,0 +1,130 @@
+package http
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/davidlick/supermarket-api/internal/auth"
+	"github.com/davidlick/supermarket-api/internal/promotions"
+	"github.com/go-chi/chi"
+)
+
+func (s *server) promotionGroup(r chi.Router) {
+	r.Route("/promotions", func(r chi.Router) {
+		r.Use(s.authorize(auth.RoleReader, auth.RolePricingEditor))
+		r.Get("/", s.handleGetAllPromotions)
+		r.Post("/", s.handleAddPromotion)
+		r.Route("/{promotionID}", func(r chi.Router) {
+			r.Get("/", s.handleGetPromotion)
+			r.Put("/", s.handleUpdatePromotion)
+			r.Delete("/", s.handleDeletePromotion)
+		})
+	})
+}
+
+func (s *server) handleGetAllPromotions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	rules, err := s.promoSvc.All()
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	if rules == nil {
+		rules = []promotions.Rule{}
+	}
+
+	s.writeSuccess(ctx, w, rules, http.StatusOK)
+	return
+}
+
+func (s *server) handleAddPromotion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		s.writeError(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	var rule promotions.Rule
+	err = json.Unmarshal(body, &rule)
+	if err != nil {
+		s.writeError(ctx, w, err, decodeStatus(err))
+		return
+	}
+
+	rule, err = s.promoSvc.Create(rule)
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	s.writeSuccess(ctx, w, rule, http.StatusCreated)
+	return
+}
+
+func (s *server) handleGetPromotion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	rule, err := s.promoSvc.Get(chi.URLParam(r, "promotionID"))
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	s.writeSuccess(ctx, w, rule, http.StatusOK)
+	return
+}
+
+func (s *server) handleUpdatePromotion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	promotionID := chi.URLParam(r, "promotionID")
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		s.writeError(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	var rule promotions.Rule
+	err = json.Unmarshal(body, &rule)
+	if err != nil {
+		s.writeError(ctx, w, err, decodeStatus(err))
+		return
+	}
+
+	if rule.ID == "" {
+		rule.ID = promotionID
+	}
+
+	if rule.ID != promotionID {
+		s.writeError(ctx, w, ErrIDMismatch, http.StatusBadRequest)
+		return
+	}
+
+	rule, err = s.promoSvc.Update(rule)
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	s.writeSuccess(ctx, w, rule, http.StatusOK)
+	return
+}
+
+func (s *server) handleDeletePromotion(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	err := s.promoSvc.Remove(chi.URLParam(r, "promotionID"))
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	s.writeSuccess(ctx, w, nil, http.StatusNoContent)
+	return
+}
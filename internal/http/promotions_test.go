@@ -0,0 +1,244 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/davidlick/supermarket-api/internal/promotions"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_handleAddPromotion(t *testing.T) {
+	tests := []struct {
+		test       string
+		body       string
+		expectFunc func(mockPromoSvc *MockPromotionService)
+		assertFunc func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			test: "it should respond with the created rule",
+			body: `{"name":"10% off","code":"test-code","type":"percent_off","percent":10}`,
+			expectFunc: func(mockPromoSvc *MockPromotionService) {
+				rule := promotions.Rule{Name: "10% off", Code: "test-code", Type: promotions.PercentOff, Percent: 10}
+				created := rule
+				created.ID = "abc"
+				created.Version = 1
+				mockPromoSvc.EXPECT().Create(rule).Return(created, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusCreated, w.Code)
+
+				b, err := ioutil.ReadAll(w.Body)
+				if err != nil {
+					t.Error(err)
+				}
+
+				assert.Equal(t, "{\"id\":\"abc\",\"name\":\"10% off\",\"code\":\"test-code\",\"type\":\"percent_off\",\"percent\":10,\"priority\":0,\"stackable\":false,\"version\":1}\n", string(b))
+			},
+		},
+		{
+			test:       "it should respond bad request if the body isn't a rule",
+			body:       `[]`,
+			expectFunc: func(mockPromoSvc *MockPromotionService) {},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			test:       "it should respond unprocessable entity if the price has no currency",
+			body:       `{"name":"2 for $5","code":"test-code","type":"multi_buy","quantity":2,"price":{"amount":500}}`,
+			expectFunc: func(mockPromoSvc *MockPromotionService) {},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				assert.Contains(t, w.Body.String(), `"field":"price.currency"`)
+			},
+		},
+		{
+			test:       "it should respond unprocessable entity if the price has no amount",
+			body:       `{"name":"2 for $5","code":"test-code","type":"multi_buy","quantity":2,"price":{"currency":"USD"}}`,
+			expectFunc: func(mockPromoSvc *MockPromotionService) {},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				assert.Contains(t, w.Body.String(), `"field":"price.amount"`)
+			},
+		},
+		{
+			test: "it should respond unprocessable entity if the rule is invalid",
+			body: `{"name":"10% off","code":"test-code","type":"percent_off","percent":110}`,
+			expectFunc: func(mockPromoSvc *MockPromotionService) {
+				mockPromoSvc.EXPECT().Create(gomock.Any()).Return(promotions.Rule{}, fmt.Errorf("%w: percent must be from 1 to 100", promotions.ErrInvalidRule))
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				assert.Contains(t, w.Body.String(), `"code":"invalid_rule"`)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			r := httptest.NewRequest(http.MethodPost, "/v1/promotions", strings.NewReader(tc.body))
+			w := httptest.NewRecorder()
+
+			noopLogger := logrus.New()
+			noopLogger.SetOutput(ioutil.Discard)
+
+			mockPromoSvc := NewMockPromotionService(ctrl)
+			tc.expectFunc(mockPromoSvc)
+
+			s := NewServer(3000, noopLogger, "test", nil, WithPromotionService(mockPromoSvc))
+
+			handler := http.HandlerFunc(s.handleAddPromotion)
+			handler.ServeHTTP(w, r)
+
+			tc.assertFunc(t, w)
+		})
+	}
+}
+
+func TestServer_handleUpdatePromotion(t *testing.T) {
+	tests := []struct {
+		test       string
+		body       string
+		expectFunc func(mockPromoSvc *MockPromotionService)
+		assertFunc func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			test: "it should update the rule with the id in the url",
+			body: `{"name":"20% off","code":"test-code","type":"percent_off","percent":20,"version":1}`,
+			expectFunc: func(mockPromoSvc *MockPromotionService) {
+				rule := promotions.Rule{ID: "abc", Name: "20% off", Code: "test-code", Type: promotions.PercentOff, Percent: 20, Version: 1}
+				updated := rule
+				updated.Version = 2
+				mockPromoSvc.EXPECT().Update(rule).Return(updated, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			test:       "it should respond bad request if the id in the body doesn't match the url",
+			body:       `{"id":"def","name":"20% off","code":"test-code","type":"percent_off","percent":20}`,
+			expectFunc: func(mockPromoSvc *MockPromotionService) {},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), `"code":"id_mismatch"`)
+			},
+		},
+		{
+			test: "it should respond conflict if the version does not match",
+			body: `{"name":"20% off","code":"test-code","type":"percent_off","percent":20,"version":1}`,
+			expectFunc: func(mockPromoSvc *MockPromotionService) {
+				mockPromoSvc.EXPECT().Update(gomock.Any()).Return(promotions.Rule{}, ramdb.ErrVersionMismatch)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusConflict, w.Code)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			r := httptest.NewRequest(http.MethodPut, "/v1/promotions/abc", strings.NewReader(tc.body))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("promotionID", "abc")
+			ctx := context.WithValue(context.Background(), chi.RouteCtxKey, rctx)
+
+			r = r.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			noopLogger := logrus.New()
+			noopLogger.SetOutput(ioutil.Discard)
+
+			mockPromoSvc := NewMockPromotionService(ctrl)
+			tc.expectFunc(mockPromoSvc)
+
+			s := NewServer(3000, noopLogger, "test", nil, WithPromotionService(mockPromoSvc))
+
+			handler := http.HandlerFunc(s.handleUpdatePromotion)
+			handler.ServeHTTP(w, r)
+
+			tc.assertFunc(t, w)
+		})
+	}
+}
+
+func TestServer_handleDeletePromotion(t *testing.T) {
+	tests := []struct {
+		test       string
+		expectFunc func(mockPromoSvc *MockPromotionService)
+		assertFunc func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			test: "it should respond no content when the rule is removed",
+			expectFunc: func(mockPromoSvc *MockPromotionService) {
+				mockPromoSvc.EXPECT().Remove("abc").Return(nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNoContent, w.Code)
+			},
+		},
+		{
+			test: "it should respond not found if the rule doesn't exist",
+			expectFunc: func(mockPromoSvc *MockPromotionService) {
+				mockPromoSvc.EXPECT().Remove("abc").Return(ramdb.ErrNoRecord)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, w.Code)
+			},
+		},
+		{
+			test: "it should respond internal server error if removing fails",
+			expectFunc: func(mockPromoSvc *MockPromotionService) {
+				mockPromoSvc.EXPECT().Remove("abc").Return(errors.New("test error"))
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, w.Code)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			r := httptest.NewRequest(http.MethodDelete, "/v1/promotions/abc", nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("promotionID", "abc")
+			ctx := context.WithValue(context.Background(), chi.RouteCtxKey, rctx)
+
+			r = r.WithContext(ctx)
+			w := httptest.NewRecorder()
+
+			noopLogger := logrus.New()
+			noopLogger.SetOutput(ioutil.Discard)
+
+			mockPromoSvc := NewMockPromotionService(ctrl)
+			tc.expectFunc(mockPromoSvc)
+
+			s := NewServer(3000, noopLogger, "test", nil, WithPromotionService(mockPromoSvc))
+
+			handler := http.HandlerFunc(s.handleDeletePromotion)
+			handler.ServeHTTP(w, r)
+
+			tc.assertFunc(t, w)
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	"github.com/davidlick/supermarket-api/internal/auth"
+	"github.com/davidlick/supermarket-api/internal/exchange"
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/go-chi/chi"
+)
+
+// convertedItem is a produce item with its price converted into the currency asked for, and the conversion made.
+type convertedItem struct {
+	produce.Item
+	Conversion *exchange.Conversion `json:"conversion,omitempty"`
+}
+
+func (s *server) rateGroup(r chi.Router) {
+	r.Route("/rates", func(r chi.Router) {
+		r.Use(s.authorize(auth.RoleReader, auth.RolePricingEditor))
+		r.Get("/", s.handleGetRates)
+		r.Put("/{from}/{to}", s.handleSetRate)
+	})
+}
+
+func (s *server) handleGetRates(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	rates, err := s.rateSvc.All()
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	if rates == nil {
+		rates = []exchange.Rate{}
+	}
+
+	s.writeSuccess(ctx, w, rates, http.StatusOK)
+	return
+}
+
+func (s *server) handleSetRate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		s.writeError(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	var rate exchange.Rate
+	err = json.Unmarshal(body, &rate)
+	if err != nil {
+		s.writeError(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	// The currencies are taken from the url rather than the body.
+	rate.From = chi.URLParam(r, "from")
+	rate.To = chi.URLParam(r, "to")
+
+	rate, err = s.rateSvc.Set(rate)
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	s.writeSuccess(ctx, w, rate, http.StatusOK)
+	return
+}
+
+// conversionCurrency returns the currency prices are to be converted into from the convert_to query parameter, or ""
+// if they are to be left as they are.
+func (s *server) conversionCurrency(query url.Values) (string, error) {
+	currency := query.Get("convert_to")
+	if currency != "" && s.rateSvc == nil {
+		return "", fmt.Errorf("%w: currency conversion is not available", ErrInvalidQuery)
+	}
+
+	return currency, nil
+}
+
+// convertItems converts the price of each item into currency. Items without a price are left as they are.
+func (s *server) convertItems(items []produce.Item, currency string) ([]convertedItem, error) {
+	converted := make([]convertedItem, 0, len(items))
+	for _, item := range items {
+		c := convertedItem{Item: item}
+		if item.Price != nil {
+			price, conversion, err := s.rateSvc.Convert(item.Price, currency)
+			if err != nil {
+				return nil, err
+			}
+
+			c.Price = price
+			c.Conversion = &conversion
+		}
+
+		converted = append(converted, c)
+	}
+
+	return converted, nil
+}
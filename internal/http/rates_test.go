@@ -0,0 +1,269 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/davidlick/supermarket-api/internal/exchange"
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_handleGetRates(t *testing.T) {
+	updatedAt := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		test       string
+		expectFunc func(mockRateSvc *MockRateService)
+		assertFunc func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			test: "it should respond with every rate",
+			expectFunc: func(mockRateSvc *MockRateService) {
+				mockRateSvc.EXPECT().All().Return([]exchange.Rate{{From: "USD", To: "EUR", Rate: "0.92", UpdatedAt: updatedAt}}, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+
+				b, err := ioutil.ReadAll(w.Body)
+				if err != nil {
+					t.Error(err)
+				}
+
+				assert.Equal(t, "[{\"from\":\"USD\",\"to\":\"EUR\",\"rate\":\"0.92\",\"updated_at\":\"2021-03-01T12:00:00Z\"}]\n", string(b))
+			},
+		},
+		{
+			test: "it should respond with an empty array if there are no rates",
+			expectFunc: func(mockRateSvc *MockRateService) {
+				mockRateSvc.EXPECT().All().Return(nil, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Equal(t, "[]\n", w.Body.String())
+			},
+		},
+		{
+			test: "it should respond internal server error if reading the rates fails",
+			expectFunc: func(mockRateSvc *MockRateService) {
+				mockRateSvc.EXPECT().All().Return(nil, errors.New("test error"))
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, w.Code)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			r := httptest.NewRequest(http.MethodGet, "/v1/rates", nil)
+			w := httptest.NewRecorder()
+
+			noopLogger := logrus.New()
+			noopLogger.SetOutput(ioutil.Discard)
+
+			mockRateSvc := NewMockRateService(ctrl)
+			tc.expectFunc(mockRateSvc)
+
+			s := NewServer(3000, noopLogger, "test", nil, WithRateService(mockRateSvc))
+
+			handler := http.HandlerFunc(s.handleGetRates)
+			handler.ServeHTTP(w, r)
+
+			tc.assertFunc(t, w)
+		})
+	}
+}
+
+func TestServer_handleSetRate(t *testing.T) {
+	tests := []struct {
+		test       string
+		body       string
+		expectFunc func(mockRateSvc *MockRateService)
+		assertFunc func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			test: "it should set the rate between the currencies in the url",
+			body: `{"from":"GBP","rate":"0.92"}`,
+			expectFunc: func(mockRateSvc *MockRateService) {
+				mockRateSvc.EXPECT().Set(exchange.Rate{From: "usd", To: "eur", Rate: "0.92"}).
+					Return(exchange.Rate{From: "USD", To: "EUR", Rate: "0.92"}, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			test:       "it should respond bad request if the body isn't a rate",
+			body:       `[]`,
+			expectFunc: func(mockRateSvc *MockRateService) {},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			test: "it should respond unprocessable entity if the rate is invalid",
+			body: `{"rate":"-1"}`,
+			expectFunc: func(mockRateSvc *MockRateService) {
+				mockRateSvc.EXPECT().Set(gomock.Any()).Return(exchange.Rate{}, fmt.Errorf("%w: \"-1\" must be a positive decimal", exchange.ErrInvalidRate))
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				assert.Contains(t, w.Body.String(), `"code":"invalid_rate"`)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			r := httptest.NewRequest(http.MethodPut, "/v1/rates/usd/eur", strings.NewReader(tc.body))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("from", "usd")
+			rctx.URLParams.Add("to", "eur")
+			r = r.WithContext(context.WithValue(context.Background(), chi.RouteCtxKey, rctx))
+			w := httptest.NewRecorder()
+
+			noopLogger := logrus.New()
+			noopLogger.SetOutput(ioutil.Discard)
+
+			mockRateSvc := NewMockRateService(ctrl)
+			tc.expectFunc(mockRateSvc)
+
+			s := NewServer(3000, noopLogger, "test", nil, WithRateService(mockRateSvc))
+
+			handler := http.HandlerFunc(s.handleSetRate)
+			handler.ServeHTTP(w, r)
+
+			tc.assertFunc(t, w)
+		})
+	}
+}
+
+func TestServer_convertProduce(t *testing.T) {
+	updatedAt := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	item := produce.Item{Code: "test-code", Name: "test", Price: money.New(346, "USD"), Version: 1}
+	conversion := exchange.Conversion{From: "USD", To: "EUR", Rate: "0.92", Original: money.New(346, "USD"), RateUpdatedAt: &updatedAt}
+
+	tests := []struct {
+		test       string
+		path       string
+		withRates  bool
+		handler    func(s *server) http.HandlerFunc
+		expectFunc func(mockProduceSvc *MockProduceService, mockRateSvc *MockRateService)
+		assertFunc func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			test:      "it should convert the price of an item and record the conversion",
+			path:      "/v1/produce/test-code?convert_to=EUR",
+			withRates: true,
+			handler:   func(s *server) http.HandlerFunc { return s.handleGetProduce },
+			expectFunc: func(mockProduceSvc *MockProduceService, mockRateSvc *MockRateService) {
+				mockProduceSvc.EXPECT().Get("test-code").Return(item, nil)
+				mockRateSvc.EXPECT().Convert(money.New(346, "USD"), "EUR").Return(money.New(318, "EUR"), conversion, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+
+				b, err := ioutil.ReadAll(w.Body)
+				if err != nil {
+					t.Error(err)
+				}
+
+				assert.Equal(t, "{\"code\":\"test-code\",\"name\":\"test\",\"price\":{\"amount\":318,\"currency\":\"EUR\"},\"version\":1,\"conversion\":{\"from\":\"USD\",\"to\":\"EUR\",\"rate\":\"0.92\",\"original\":{\"amount\":346,\"currency\":\"USD\"},\"rate_updated_at\":\"2021-03-01T12:00:00Z\"}}\n", string(b))
+			},
+		},
+		{
+			test:      "it should convert the prices of listed items",
+			path:      "/v1/produce?convert_to=EUR",
+			withRates: true,
+			handler:   func(s *server) http.HandlerFunc { return s.handleGetAllProduce },
+			expectFunc: func(mockProduceSvc *MockProduceService, mockRateSvc *MockRateService) {
+				mockProduceSvc.EXPECT().List(produce.ListOptions{}).Return([]produce.Item{item, {Code: "unpriced", Name: "unpriced"}}, "next-cursor", nil)
+				mockRateSvc.EXPECT().Convert(money.New(346, "USD"), "EUR").Return(money.New(318, "EUR"), conversion, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Equal(t, "next-cursor", w.Header().Get("X-Next-Cursor"))
+
+				b, err := ioutil.ReadAll(w.Body)
+				if err != nil {
+					t.Error(err)
+				}
+
+				assert.Equal(t, "[{\"code\":\"test-code\",\"name\":\"test\",\"price\":{\"amount\":318,\"currency\":\"EUR\"},\"version\":1,\"conversion\":{\"from\":\"USD\",\"to\":\"EUR\",\"rate\":\"0.92\",\"original\":{\"amount\":346,\"currency\":\"USD\"},\"rate_updated_at\":\"2021-03-01T12:00:00Z\"}},{\"code\":\"unpriced\",\"name\":\"unpriced\",\"price\":null}]\n", string(b))
+			},
+		},
+		{
+			test:      "it should respond unprocessable entity if there is no rate to the currency",
+			path:      "/v1/produce/test-code?convert_to=JPY",
+			withRates: true,
+			handler:   func(s *server) http.HandlerFunc { return s.handleGetProduce },
+			expectFunc: func(mockProduceSvc *MockProduceService, mockRateSvc *MockRateService) {
+				mockProduceSvc.EXPECT().Get("test-code").Return(item, nil)
+				mockRateSvc.EXPECT().Convert(gomock.Any(), "JPY").Return(nil, exchange.Conversion{}, fmt.Errorf("%w: USD to JPY", exchange.ErrNoRate))
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				assert.Contains(t, w.Body.String(), `"code":"no_exchange_rate"`)
+			},
+		},
+		{
+			test:       "it should respond bad request if the server has no rates to convert with",
+			path:       "/v1/produce?convert_to=EUR",
+			handler:    func(s *server) http.HandlerFunc { return s.handleGetAllProduce },
+			expectFunc: func(mockProduceSvc *MockProduceService, mockRateSvc *MockRateService) {},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), `"code":"invalid_query"`)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			r := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("produceCode", "test-code")
+			r = r.WithContext(context.WithValue(context.Background(), chi.RouteCtxKey, rctx))
+			w := httptest.NewRecorder()
+
+			noopLogger := logrus.New()
+			noopLogger.SetOutput(ioutil.Discard)
+
+			mockProduceSvc := NewMockProduceService(ctrl)
+			mockRateSvc := NewMockRateService(ctrl)
+			tc.expectFunc(mockProduceSvc, mockRateSvc)
+
+			var opts []ServerOption
+			if tc.withRates {
+				opts = append(opts, WithRateService(mockRateSvc))
+			}
+
+			s := NewServer(3000, noopLogger, "test", mockProduceSvc, opts...)
+
+			tc.handler(s).ServeHTTP(w, r)
+
+			tc.assertFunc(t, w)
+		})
+	}
+}
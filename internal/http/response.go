@@ -3,7 +3,13 @@ package http
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+
+	"github.com/davidlick/supermarket-api/internal/auth"
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/go-chi/chi/middleware"
+	"github.com/sirupsen/logrus"
 )
 
 func (s *server) writeSuccess(ctx context.Context, w http.ResponseWriter, data interface{}, status int) error {
@@ -21,6 +27,26 @@ func (s *server) writeSuccess(ctx context.Context, w http.ResponseWriter, data i
 	return nil
 }
 
+// problem is an RFC 7807 problem details response.
+type problem struct {
+	Type      string                   `json:"type"`
+	Title     string                   `json:"title"`
+	Status    int                      `json:"status"`
+	Detail    string                   `json:"detail,omitempty"`
+	Code      string                   `json:"code"`
+	RequestID string                   `json:"request_id,omitempty"`
+	Errors    produce.ValidationErrors `json:"errors,omitempty"`
+}
+
+// debugEnvironments are the environments the details of internal errors are sent to clients in.
+var debugEnvironments = map[string]bool{
+	"dev":   true,
+	"local": true,
+	"test":  true,
+}
+
+// writeError logs err and responds with it as an application/problem+json document. The details of errors with a 5xx
+// code are only sent in the debugEnvironments.
 func (s *server) writeError(ctx context.Context, w http.ResponseWriter, err error, code int) error {
 	if http.StatusText(code) == "" {
 		return ErrUnrecognizedCode
@@ -30,14 +56,40 @@ func (s *server) writeError(ctx context.Context, w http.ResponseWriter, err erro
 		err = ErrUnknownError
 	}
 
-	s.logger.Errorf("request failed: %v", err.Error())
-	w.WriteHeader(code)
+	requestID := middleware.GetReqID(ctx)
+	s.requestLogger(ctx).Errorf("request failed: %v", err.Error())
+
+	res := problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(code),
+		Status:    code,
+		Detail:    err.Error(),
+		Code:      errorCode(err, code),
+		RequestID: requestID,
+	}
 
-	res := struct {
-		Message string `json:"message"`
-	}{
-		Message: http.StatusText(code),
+	if code >= http.StatusInternalServerError && !debugEnvironments[s.environment] {
+		res.Detail = ""
 	}
 
+	// Validation errors only describe the request, so they are safe to send back to the client.
+	var ve produce.ValidationErrors
+	if errors.As(err, &ve) {
+		res.Errors = ve
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(code)
 	return json.NewEncoder(w).Encode(res)
 }
+
+// requestLogger returns the server's logger with the ID of the request ctx is for and, if it was authenticated, the
+// identity it was made by.
+func (s *server) requestLogger(ctx context.Context) *logrus.Entry {
+	entry := s.logger.WithField("request_id", middleware.GetReqID(ctx))
+	if id, ok := auth.FromContext(ctx); ok {
+		entry = entry.WithField("identity", id.ID)
+	}
+
+	return entry
+}
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+	"github.com/go-chi/chi/middleware"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_writeError(t *testing.T) {
+	tests := []struct {
+		test            string
+		environment     string
+		err             error
+		code            int
+		expectedProblem problem
+	}{
+		{
+			test:        "it should respond with the code and detail of a known error",
+			environment: "production",
+			err:         ramdb.ErrVersionMismatch,
+			code:        http.StatusConflict,
+			expectedProblem: problem{
+				Type:      "about:blank",
+				Title:     "Conflict",
+				Status:    http.StatusConflict,
+				Detail:    "record version does not match",
+				Code:      "version_mismatch",
+				RequestID: "test-request-id",
+			},
+		},
+		{
+			test:        "it should respond with field details of validation errors",
+			environment: "production",
+			err:         produce.ValidationErrors{{Index: 1, Field: "name", Message: "is required"}},
+			code:        http.StatusUnprocessableEntity,
+			expectedProblem: problem{
+				Type:      "about:blank",
+				Title:     "Unprocessable Entity",
+				Status:    http.StatusUnprocessableEntity,
+				Detail:    "invalid produce item: item 1: name is required",
+				Code:      "invalid_item",
+				RequestID: "test-request-id",
+				Errors:    produce.ValidationErrors{{Index: 1, Field: "name", Message: "is required"}},
+			},
+		},
+		{
+			test:        "it should redact the detail of internal errors in production",
+			environment: "production",
+			err:         errors.New("disk on fire"),
+			code:        http.StatusInternalServerError,
+			expectedProblem: problem{
+				Type:      "about:blank",
+				Title:     "Internal Server Error",
+				Status:    http.StatusInternalServerError,
+				Code:      "internal_server_error",
+				RequestID: "test-request-id",
+			},
+		},
+		{
+			test:        "it should send the detail of internal errors in dev",
+			environment: "dev",
+			err:         errors.New("disk on fire"),
+			code:        http.StatusInternalServerError,
+			expectedProblem: problem{
+				Type:      "about:blank",
+				Title:     "Internal Server Error",
+				Status:    http.StatusInternalServerError,
+				Detail:    "disk on fire",
+				Code:      "internal_server_error",
+				RequestID: "test-request-id",
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), middleware.RequestIDKey, "test-request-id")
+			w := httptest.NewRecorder()
+
+			noopLogger := logrus.New()
+			noopLogger.SetOutput(ioutil.Discard)
+
+			s := NewServer(3000, noopLogger, tc.environment, nil)
+			err := s.writeError(ctx, w, tc.err, tc.code)
+			assert.Nil(t, err)
+
+			var p problem
+			err = json.NewDecoder(w.Body).Decode(&p)
+			assert.Nil(t, err)
+
+			assert.Equal(t, tc.code, w.Code)
+			assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+			assert.Equal(t, tc.expectedProblem, p)
+		})
+	}
+}
@@ -0,0 +1,220 @@
+package http
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/davidlick/supermarket-api/internal/auth"
+	"github.com/davidlick/supermarket-api/internal/stores"
+	"github.com/go-chi/chi"
+)
+
+func (s *server) storeGroup(r chi.Router) {
+	// Overriding the produce a store sells is pricing, but managing the stores themselves is left to admins.
+	manage := s.authorize(auth.RoleReader, auth.RoleAdmin)
+
+	r.Route("/stores", func(r chi.Router) {
+		r.With(manage).Get("/", s.handleGetAllStores)
+		r.With(manage).Post("/", s.handleAddStore)
+		r.Route("/{storeID}", func(r chi.Router) {
+			r.With(manage).Get("/", s.handleGetStore)
+			r.With(manage).Put("/", s.handleUpdateStore)
+			r.With(manage).Delete("/", s.handleDeleteStore)
+			r.Route("/produce", func(r chi.Router) {
+				r.Use(s.authorize(auth.RoleReader, auth.RolePricingEditor))
+				r.Get("/", s.handleGetAllStoreProduce)
+				r.Route("/{produceCode}", func(r chi.Router) {
+					r.Get("/", s.handleGetStoreProduce)
+					r.Put("/", s.handleSetStoreProduce)
+					r.Delete("/", s.handleDeleteStoreProduce)
+				})
+			})
+		})
+	})
+}
+
+func (s *server) handleGetAllStores(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	all, err := s.storeSvc.All()
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	if all == nil {
+		all = []stores.Store{}
+	}
+
+	s.writeSuccess(ctx, w, all, http.StatusOK)
+	return
+}
+
+func (s *server) handleAddStore(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		s.writeError(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	var store stores.Store
+	err = json.Unmarshal(body, &store)
+	if err != nil {
+		s.writeError(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	store, err = s.storeSvc.Create(store)
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	s.writeSuccess(ctx, w, store, http.StatusCreated)
+	return
+}
+
+func (s *server) handleGetStore(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	store, err := s.storeSvc.Get(chi.URLParam(r, "storeID"))
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	s.writeSuccess(ctx, w, store, http.StatusOK)
+	return
+}
+
+func (s *server) handleUpdateStore(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	storeID := chi.URLParam(r, "storeID")
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		s.writeError(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	var store stores.Store
+	err = json.Unmarshal(body, &store)
+	if err != nil {
+		s.writeError(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	if store.ID == "" {
+		store.ID = storeID
+	}
+
+	if store.ID != storeID {
+		s.writeError(ctx, w, ErrIDMismatch, http.StatusBadRequest)
+		return
+	}
+
+	store, err = s.storeSvc.Update(store)
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	s.writeSuccess(ctx, w, store, http.StatusOK)
+	return
+}
+
+func (s *server) handleDeleteStore(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	err := s.storeSvc.Remove(chi.URLParam(r, "storeID"))
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	s.writeSuccess(ctx, w, nil, http.StatusNoContent)
+	return
+}
+
+func (s *server) handleGetAllStoreProduce(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	opts, err := listOptions(r.URL.Query())
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	items, next, err := s.storeSvc.Items(chi.URLParam(r, "storeID"), opts)
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	if next != "" {
+		w.Header().Set("X-Next-Cursor", next)
+	}
+
+	s.writeSuccess(ctx, w, items, http.StatusOK)
+	return
+}
+
+func (s *server) handleGetStoreProduce(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	item, err := s.storeSvc.Item(chi.URLParam(r, "storeID"), chi.URLParam(r, "produceCode"))
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	s.writeSuccess(ctx, w, item, http.StatusOK)
+	return
+}
+
+func (s *server) handleSetStoreProduce(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		s.writeError(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	var override stores.Override
+	err = json.Unmarshal(body, &override)
+	if err != nil {
+		s.writeError(ctx, w, err, decodeStatus(err))
+		return
+	}
+
+	// The store and item are taken from the url rather than the body.
+	override.StoreID = chi.URLParam(r, "storeID")
+	override.Code = chi.URLParam(r, "produceCode")
+
+	item, err := s.storeSvc.SetOverride(override)
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	s.writeSuccess(ctx, w, item, http.StatusOK)
+	return
+}
+
+func (s *server) handleDeleteStoreProduce(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	err := s.storeSvc.RemoveOverride(chi.URLParam(r, "storeID"), chi.URLParam(r, "produceCode"))
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	s.writeSuccess(ctx, w, nil, http.StatusNoContent)
+	return
+}
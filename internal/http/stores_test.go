@@ -0,0 +1,214 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/Rhymond/go-money"
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/davidlick/supermarket-api/internal/stores"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_stores(t *testing.T) {
+	tests := []struct {
+		test       string
+		method     string
+		body       string
+		handler    func(s *server) http.HandlerFunc
+		expectFunc func(mockStoreSvc *MockStoreService)
+		assertFunc func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			test:    "it should create a store",
+			method:  http.MethodPost,
+			body:    `{"name":"Dublin"}`,
+			handler: func(s *server) http.HandlerFunc { return s.handleAddStore },
+			expectFunc: func(mockStoreSvc *MockStoreService) {
+				mockStoreSvc.EXPECT().Create(stores.Store{Name: "Dublin"}).Return(stores.Store{ID: "test-store", Name: "Dublin", Version: 1}, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusCreated, w.Code)
+				assert.Equal(t, "{\"id\":\"test-store\",\"name\":\"Dublin\",\"version\":1}\n", w.Body.String())
+			},
+		},
+		{
+			test:    "it should respond unprocessable entity if the store is invalid",
+			method:  http.MethodPost,
+			body:    `{}`,
+			handler: func(s *server) http.HandlerFunc { return s.handleAddStore },
+			expectFunc: func(mockStoreSvc *MockStoreService) {
+				mockStoreSvc.EXPECT().Create(stores.Store{}).Return(stores.Store{}, fmt.Errorf("%w: name is required", stores.ErrInvalidStore))
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				assert.Contains(t, w.Body.String(), `"code":"invalid_store"`)
+			},
+		},
+		{
+			test:       "it should respond bad request if the store in the body isn't the one in the url",
+			method:     http.MethodPut,
+			body:       `{"id":"other-store","name":"Dublin"}`,
+			handler:    func(s *server) http.HandlerFunc { return s.handleUpdateStore },
+			expectFunc: func(mockStoreSvc *MockStoreService) {},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			test:    "it should respond not found if the store doesn't exist",
+			method:  http.MethodGet,
+			handler: func(s *server) http.HandlerFunc { return s.handleGetStore },
+			expectFunc: func(mockStoreSvc *MockStoreService) {
+				mockStoreSvc.EXPECT().Get("test-store").Return(stores.Store{}, ramdb.ErrNoRecord)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, w.Code)
+			},
+		},
+		{
+			test:    "it should list the produce sold at a store",
+			method:  http.MethodGet,
+			handler: func(s *server) http.HandlerFunc { return s.handleGetAllStoreProduce },
+			expectFunc: func(mockStoreSvc *MockStoreService) {
+				mockStoreSvc.EXPECT().Items("test-store", produce.ListOptions{}).Return([]stores.Item{
+					{
+						Item:      produce.Item{Code: "test-code", Name: "test", Price: money.New(320, "EUR"), Version: 1},
+						StoreID:   "test-store",
+						Available: true,
+						BasePrice: money.New(346, "USD"),
+					},
+				}, "next-cursor", nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Equal(t, "next-cursor", w.Header().Get("X-Next-Cursor"))
+
+				b, err := ioutil.ReadAll(w.Body)
+				if err != nil {
+					t.Error(err)
+				}
+
+				assert.Equal(t, "[{\"code\":\"test-code\",\"name\":\"test\",\"price\":{\"amount\":320,\"currency\":\"EUR\"},\"version\":1,\"store_id\":\"test-store\",\"available\":true,\"base_price\":{\"amount\":346,\"currency\":\"USD\"}}]\n", string(b))
+			},
+		},
+		{
+			test:    "it should get a produce item as sold at a store",
+			method:  http.MethodGet,
+			handler: func(s *server) http.HandlerFunc { return s.handleGetStoreProduce },
+			expectFunc: func(mockStoreSvc *MockStoreService) {
+				mockStoreSvc.EXPECT().Item("test-store", "test-code").Return(stores.Item{StoreID: "test-store"}, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			test:    "it should override a produce item at a store with the store and item in the url",
+			method:  http.MethodPut,
+			body:    `{"store_id":"other-store","code":"other-code","price":{"amount":320,"currency":"EUR"},"available":false}`,
+			handler: func(s *server) http.HandlerFunc { return s.handleSetStoreProduce },
+			expectFunc: func(mockStoreSvc *MockStoreService) {
+				available := false
+				mockStoreSvc.EXPECT().SetOverride(stores.Override{StoreID: "test-store", Code: "test-code", Price: money.New(320, "EUR"), Available: &available}).
+					Return(stores.Item{StoreID: "test-store"}, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			test:       "it should respond unprocessable entity if the override price has no currency",
+			method:     http.MethodPut,
+			body:       `{"price":{"amount":320}}`,
+			handler:    func(s *server) http.HandlerFunc { return s.handleSetStoreProduce },
+			expectFunc: func(mockStoreSvc *MockStoreService) {},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				assert.Contains(t, w.Body.String(), `"field":"price.currency"`)
+			},
+		},
+		{
+			test:       "it should respond unprocessable entity if the override price has no amount",
+			method:     http.MethodPut,
+			body:       `{"price":{"currency":"EUR"}}`,
+			handler:    func(s *server) http.HandlerFunc { return s.handleSetStoreProduce },
+			expectFunc: func(mockStoreSvc *MockStoreService) {},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				assert.Contains(t, w.Body.String(), `"field":"price.amount"`)
+			},
+		},
+		{
+			test:    "it should respond unprocessable entity if the override price is invalid",
+			method:  http.MethodPut,
+			body:    `{"price":{"amount":-1,"currency":"EUR"}}`,
+			handler: func(s *server) http.HandlerFunc { return s.handleSetStoreProduce },
+			expectFunc: func(mockStoreSvc *MockStoreService) {
+				mockStoreSvc.EXPECT().SetOverride(gomock.Any()).
+					Return(stores.Item{}, produce.ValidationErrors{{Field: "price.amount", Message: "must be positive"}})
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+			},
+		},
+		{
+			test:    "it should remove the override of a produce item at a store",
+			method:  http.MethodDelete,
+			handler: func(s *server) http.HandlerFunc { return s.handleDeleteStoreProduce },
+			expectFunc: func(mockStoreSvc *MockStoreService) {
+				mockStoreSvc.EXPECT().RemoveOverride("test-store", "test-code").Return(nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNoContent, w.Code)
+			},
+		},
+		{
+			test:    "it should respond internal server error if removing a store fails",
+			method:  http.MethodDelete,
+			handler: func(s *server) http.HandlerFunc { return s.handleDeleteStore },
+			expectFunc: func(mockStoreSvc *MockStoreService) {
+				mockStoreSvc.EXPECT().Remove("test-store").Return(errors.New("test error"))
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, w.Code)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			r := httptest.NewRequest(tc.method, "/v1/stores/test-store", strings.NewReader(tc.body))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("storeID", "test-store")
+			rctx.URLParams.Add("produceCode", "test-code")
+			r = r.WithContext(context.WithValue(context.Background(), chi.RouteCtxKey, rctx))
+			w := httptest.NewRecorder()
+
+			noopLogger := logrus.New()
+			noopLogger.SetOutput(ioutil.Discard)
+
+			mockStoreSvc := NewMockStoreService(ctrl)
+			tc.expectFunc(mockStoreSvc)
+
+			s := NewServer(3000, noopLogger, "test", nil, WithStoreService(mockStoreSvc))
+
+			tc.handler(s).ServeHTTP(w, r)
+
+			tc.assertFunc(t, w)
+		})
+	}
+}
@@ -0,0 +1,175 @@
+package http
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/davidlick/supermarket-api/internal/auth"
+	"github.com/davidlick/supermarket-api/internal/webhooks"
+	"github.com/go-chi/chi"
+)
+
+func (s *server) webhookGroup(r chi.Router) {
+	r.Route("/webhooks", func(r chi.Router) {
+		r.Use(s.authorize(auth.RoleAdmin, auth.RoleAdmin))
+		r.Get("/", s.handleGetAllWebhooks)
+		r.Post("/", s.handleAddWebhook)
+		r.Get("/dead-letters", s.handleGetDeadLetters)
+		r.Route("/{webhookID}", func(r chi.Router) {
+			r.Get("/", s.handleGetWebhook)
+			r.Put("/", s.handleUpdateWebhook)
+			r.Delete("/", s.handleDeleteWebhook)
+			r.Get("/deliveries", s.handleGetWebhookDeliveries)
+		})
+	})
+}
+
+func (s *server) handleGetAllWebhooks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	subs, err := s.webhookSvc.All()
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	if subs == nil {
+		subs = []webhooks.Subscription{}
+	}
+
+	s.writeSuccess(ctx, w, subs, http.StatusOK)
+	return
+}
+
+func (s *server) handleAddWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		s.writeError(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	var sub webhooks.Subscription
+	err = json.Unmarshal(body, &sub)
+	if err != nil {
+		s.writeError(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	sub, err = s.webhookSvc.Create(sub)
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	s.writeSuccess(ctx, w, sub, http.StatusCreated)
+	return
+}
+
+func (s *server) handleGetDeadLetters(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	deliveries, err := s.webhookSvc.DeadLetters()
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	if deliveries == nil {
+		deliveries = []webhooks.Delivery{}
+	}
+
+	s.writeSuccess(ctx, w, deliveries, http.StatusOK)
+	return
+}
+
+func (s *server) handleGetWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	sub, err := s.webhookSvc.Get(chi.URLParam(r, "webhookID"))
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	s.writeSuccess(ctx, w, sub, http.StatusOK)
+	return
+}
+
+func (s *server) handleUpdateWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	webhookID := chi.URLParam(r, "webhookID")
+
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		s.writeError(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	var sub webhooks.Subscription
+	err = json.Unmarshal(body, &sub)
+	if err != nil {
+		s.writeError(ctx, w, err, http.StatusBadRequest)
+		return
+	}
+
+	if sub.ID == "" {
+		sub.ID = webhookID
+	}
+
+	if sub.ID != webhookID {
+		s.writeError(ctx, w, ErrIDMismatch, http.StatusBadRequest)
+		return
+	}
+
+	sub, err = s.webhookSvc.Update(sub)
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	s.writeSuccess(ctx, w, sub, http.StatusOK)
+	return
+}
+
+func (s *server) handleDeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	err := s.webhookSvc.Remove(chi.URLParam(r, "webhookID"))
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	s.writeSuccess(ctx, w, nil, http.StatusNoContent)
+	return
+}
+
+func (s *server) handleGetWebhookDeliveries(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	webhookID := chi.URLParam(r, "webhookID")
+
+	// Deliveries of a subscription that doesn't exist would always be empty, so it is reported as not found instead.
+	_, err := s.webhookSvc.Get(webhookID)
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	deliveries, err := s.webhookSvc.Deliveries(webhookID)
+	if err != nil {
+		s.writeError(ctx, w, err, errorStatus(err))
+		return
+	}
+
+	if deliveries == nil {
+		deliveries = []webhooks.Delivery{}
+	}
+
+	s.writeSuccess(ctx, w, deliveries, http.StatusOK)
+	return
+}
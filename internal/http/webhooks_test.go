@@ -0,0 +1,204 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/davidlick/supermarket-api/internal/webhooks"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+	"github.com/go-chi/chi"
+	"github.com/golang/mock/gomock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServer_webhooks(t *testing.T) {
+	createdAt := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		test       string
+		method     string
+		body       string
+		handler    func(s *server) http.HandlerFunc
+		expectFunc func(mockWebhookSvc *MockWebhookService)
+		assertFunc func(t *testing.T, w *httptest.ResponseRecorder)
+	}{
+		{
+			test:    "it should create a subscription without responding with its secret",
+			method:  http.MethodPost,
+			body:    `{"url":"https://erp.example.com/hooks","events":["produce.added"],"secret":"0123456789abcdef"}`,
+			handler: func(s *server) http.HandlerFunc { return s.handleAddWebhook },
+			expectFunc: func(mockWebhookSvc *MockWebhookService) {
+				mockWebhookSvc.EXPECT().Create(webhooks.Subscription{URL: "https://erp.example.com/hooks", Events: []string{"produce.added"}, Secret: "0123456789abcdef"}).
+					Return(webhooks.Subscription{ID: "test-webhook", URL: "https://erp.example.com/hooks", Events: []string{"produce.added"}, CreatedAt: createdAt, Version: 1}, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusCreated, w.Code)
+				assert.Equal(t, "{\"id\":\"test-webhook\",\"url\":\"https://erp.example.com/hooks\",\"events\":[\"produce.added\"],\"created_at\":\"2021-03-01T12:00:00Z\",\"version\":1}\n", w.Body.String())
+			},
+		},
+		{
+			test:    "it should respond unprocessable entity if the subscription is invalid",
+			method:  http.MethodPost,
+			body:    `{"url":"ftp://erp.example.com"}`,
+			handler: func(s *server) http.HandlerFunc { return s.handleAddWebhook },
+			expectFunc: func(mockWebhookSvc *MockWebhookService) {
+				mockWebhookSvc.EXPECT().Create(gomock.Any()).Return(webhooks.Subscription{}, fmt.Errorf("%w: url must be http or https", webhooks.ErrInvalidSubscription))
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+				assert.Contains(t, w.Body.String(), `"code":"invalid_subscription"`)
+			},
+		},
+		{
+			test:       "it should respond bad request if the body is not json",
+			method:     http.MethodPost,
+			body:       `{`,
+			handler:    func(s *server) http.HandlerFunc { return s.handleAddWebhook },
+			expectFunc: func(mockWebhookSvc *MockWebhookService) {},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+			},
+		},
+		{
+			test:    "it should list subscriptions as an empty array if there are none",
+			method:  http.MethodGet,
+			handler: func(s *server) http.HandlerFunc { return s.handleGetAllWebhooks },
+			expectFunc: func(mockWebhookSvc *MockWebhookService) {
+				mockWebhookSvc.EXPECT().All().Return(nil, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Equal(t, "[]\n", w.Body.String())
+			},
+		},
+		{
+			test:    "it should update a subscription with the id in the url",
+			method:  http.MethodPut,
+			body:    `{"url":"https://erp.example.com/hooks","events":["produce.removed"],"version":1}`,
+			handler: func(s *server) http.HandlerFunc { return s.handleUpdateWebhook },
+			expectFunc: func(mockWebhookSvc *MockWebhookService) {
+				mockWebhookSvc.EXPECT().Update(webhooks.Subscription{ID: "test-webhook", URL: "https://erp.example.com/hooks", Events: []string{"produce.removed"}, Version: 1}).
+					Return(webhooks.Subscription{ID: "test-webhook", Version: 2}, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+			},
+		},
+		{
+			test:       "it should respond bad request if the subscription in the body isn't the one in the url",
+			method:     http.MethodPut,
+			body:       `{"id":"other-webhook"}`,
+			handler:    func(s *server) http.HandlerFunc { return s.handleUpdateWebhook },
+			expectFunc: func(mockWebhookSvc *MockWebhookService) {},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusBadRequest, w.Code)
+				assert.Contains(t, w.Body.String(), `"code":"id_mismatch"`)
+			},
+		},
+		{
+			test:    "it should respond not found if the subscription doesn't exist",
+			method:  http.MethodGet,
+			handler: func(s *server) http.HandlerFunc { return s.handleGetWebhook },
+			expectFunc: func(mockWebhookSvc *MockWebhookService) {
+				mockWebhookSvc.EXPECT().Get("test-webhook").Return(webhooks.Subscription{}, ramdb.ErrNoRecord)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, w.Code)
+			},
+		},
+		{
+			test:    "it should remove a subscription",
+			method:  http.MethodDelete,
+			handler: func(s *server) http.HandlerFunc { return s.handleDeleteWebhook },
+			expectFunc: func(mockWebhookSvc *MockWebhookService) {
+				mockWebhookSvc.EXPECT().Remove("test-webhook").Return(nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNoContent, w.Code)
+			},
+		},
+		{
+			test:    "it should list the deliveries of a subscription",
+			method:  http.MethodGet,
+			handler: func(s *server) http.HandlerFunc { return s.handleGetWebhookDeliveries },
+			expectFunc: func(mockWebhookSvc *MockWebhookService) {
+				mockWebhookSvc.EXPECT().Get("test-webhook").Return(webhooks.Subscription{ID: "test-webhook"}, nil)
+				mockWebhookSvc.EXPECT().Deliveries("test-webhook").Return([]webhooks.Delivery{
+					{ID: "test-delivery", SubscriptionID: "test-webhook", Status: webhooks.StatusDelivered},
+				}, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Contains(t, w.Body.String(), `"id":"test-delivery"`)
+				assert.Contains(t, w.Body.String(), `"status":"delivered"`)
+			},
+		},
+		{
+			test:    "it should respond not found for the deliveries of a subscription that doesn't exist",
+			method:  http.MethodGet,
+			handler: func(s *server) http.HandlerFunc { return s.handleGetWebhookDeliveries },
+			expectFunc: func(mockWebhookSvc *MockWebhookService) {
+				mockWebhookSvc.EXPECT().Get("test-webhook").Return(webhooks.Subscription{}, ramdb.ErrNoRecord)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusNotFound, w.Code)
+			},
+		},
+		{
+			test:    "it should list dead letters as an empty array if there are none",
+			method:  http.MethodGet,
+			handler: func(s *server) http.HandlerFunc { return s.handleGetDeadLetters },
+			expectFunc: func(mockWebhookSvc *MockWebhookService) {
+				mockWebhookSvc.EXPECT().DeadLetters().Return(nil, nil)
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusOK, w.Code)
+				assert.Equal(t, "[]\n", w.Body.String())
+			},
+		},
+		{
+			test:    "it should respond internal server error if listing dead letters fails",
+			method:  http.MethodGet,
+			handler: func(s *server) http.HandlerFunc { return s.handleGetDeadLetters },
+			expectFunc: func(mockWebhookSvc *MockWebhookService) {
+				mockWebhookSvc.EXPECT().DeadLetters().Return(nil, errors.New("test error"))
+			},
+			assertFunc: func(t *testing.T, w *httptest.ResponseRecorder) {
+				assert.Equal(t, http.StatusInternalServerError, w.Code)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			r := httptest.NewRequest(tc.method, "/v1/webhooks/test-webhook", strings.NewReader(tc.body))
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("webhookID", "test-webhook")
+			r = r.WithContext(context.WithValue(context.Background(), chi.RouteCtxKey, rctx))
+			w := httptest.NewRecorder()
+
+			noopLogger := logrus.New()
+			noopLogger.SetOutput(ioutil.Discard)
+
+			mockWebhookSvc := NewMockWebhookService(ctrl)
+			tc.expectFunc(mockWebhookSvc)
+
+			s := NewServer(3000, noopLogger, "test", nil, WithWebhookService(mockWebhookSvc))
+
+			tc.handler(s).ServeHTTP(w, r)
+
+			tc.assertFunc(t, w)
+		})
+	}
+}
@@ -1,10 +1,24 @@
 package interfaces
 
-import "github.com/davidlick/supermarket-api/pkg/ramdb"
+import (
+	"context"
+
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+)
 
 type RamDB interface {
 	Get(column, key string) (r *ramdb.Record, err error)
+	Lookup(column, key string) (rr []*ramdb.Record, err error)
 	Select(column string) (rr []*ramdb.Record, err error)
+	Prefix(column, prefix string) (rr []*ramdb.Record, err error)
+	Scan(column string, opts ramdb.ScanOptions) (rr []*ramdb.Record, err error)
+	Search(column, query string, opts ramdb.SearchOptions) (results []ramdb.SearchResult, err error)
 	Insert(r *ramdb.Record) error
+	Update(r *ramdb.Record) error
+	Upsert(r *ramdb.Record) error
+	CompareAndSwap(r *ramdb.Record, version uint64) error
 	Delete(r *ramdb.Record) error
+	Begin() *ramdb.Tx
+	Join(tx *ramdb.Tx) *ramdb.Tx
+	Watch(ctx context.Context, opts ramdb.WatchOptions) (*ramdb.Watcher, error)
 }
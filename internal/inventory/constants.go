@@ -0,0 +1,9 @@
+package inventory
+
+const (
+	KeyStockCode = "produce_code"
+)
+
+// maxSwapAttempts is the number of times a stock change is retried when the stock is changed by another caller between
+// being read and written. It is higher than for produce because stock is changed concurrently by every checkout.
+const maxSwapAttempts = 50
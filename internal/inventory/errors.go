@@ -0,0 +1,9 @@
+package inventory
+
+import "errors"
+
+var (
+	ErrInvalidQuantity   = errors.New("invalid stock quantity")
+	ErrInsufficientStock = errors.New("insufficient stock")
+	ErrInStock           = errors.New("produce item is still in stock")
+)
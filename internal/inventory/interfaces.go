@@ -0,0 +1,11 @@
+package inventory
+
+import (
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+)
+
+type ProduceService interface {
+	Get(produceCode string) (item produce.Item, err error)
+	Check(tx *ramdb.Tx, item produce.Item) error
+}
@@ -0,0 +1,375 @@
+package inventory
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/davidlick/supermarket-api/internal/interfaces"
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+)
+
+type service struct {
+	db         interfaces.RamDB
+	produceSvc ProduceService
+}
+
+// NewService creates a new inventory service storing the stock of the produce items in produceSvc in db.
+func NewService(db interfaces.RamDB, produceSvc ProduceService) *service {
+	return &service{
+		db:         db,
+		produceSvc: produceSvc,
+	}
+}
+
+// Get returns the stock of the produce item with produceCode. Items that have never been stocked have none.
+func (s *service) Get(produceCode string) (Stock, error) {
+	stock, err := s.get(produceCode)
+	if err == ramdb.ErrNoRecord {
+		item, err := s.produceSvc.Get(produceCode)
+		if err != nil {
+			return Stock{}, err
+		}
+
+		return newStock(item), nil
+	}
+
+	return stock, err
+}
+
+// All returns the stock of every produce item that has been stocked, sorted by produce code.
+func (s *service) All() (stocks []Stock, err error) {
+	return s.scan(ramdb.ScanOptions{})
+}
+
+// Low returns the stock of every produce item that is low on stock, sorted by produce code.
+func (s *service) Low() (stocks []Stock, err error) {
+	return s.scan(ramdb.ScanOptions{
+		Filter: func(r *ramdb.Record) bool {
+			stock, err := deserializeStock(r)
+			return err == nil && stock.LowStock
+		},
+	})
+}
+
+// Receive adds quantity to the stock on hand.
+func (s *service) Receive(produceCode string, quantity produce.Quantity) (Stock, error) {
+	return s.swap(produceCode, func(stock *Stock) error {
+		err := checkQuantity(stock.Unit, quantity)
+		if err != nil {
+			return err
+		}
+
+		stock.OnHand, err = add(stock.OnHand, quantity)
+		return err
+	})
+}
+
+// Adjust adds delta, which is negative to take stock away, to the stock on hand, such as after a stock take. It returns
+// an error matching ErrInsufficientStock if the stock on hand would fall below the stock reserved.
+func (s *service) Adjust(produceCode string, delta produce.Quantity) (Stock, error) {
+	return s.swap(produceCode, func(stock *Stock) error {
+		err := checkQuantity(stock.Unit, abs(delta))
+		if err != nil {
+			return err
+		}
+
+		onHand, err := add(stock.OnHand, delta)
+		if err != nil {
+			return err
+		}
+
+		if onHand < stock.Reserved {
+			return fmt.Errorf("%w: %s on hand of which %s is reserved", ErrInsufficientStock, stock.OnHand, stock.Reserved)
+		}
+
+		stock.OnHand = onHand
+		return nil
+	})
+}
+
+// Reserve sets quantity of the available stock aside. It returns an error matching ErrInsufficientStock if less than
+// quantity is available, so stock is never reserved twice however many callers reserve it at once.
+func (s *service) Reserve(produceCode string, quantity produce.Quantity) (Stock, error) {
+	return s.swap(produceCode, func(stock *Stock) error {
+		err := checkQuantity(stock.Unit, quantity)
+		if err != nil {
+			return err
+		}
+
+		if stock.OnHand-stock.Reserved < quantity {
+			return fmt.Errorf("%w: %s available", ErrInsufficientStock, stock.OnHand-stock.Reserved)
+		}
+
+		stock.Reserved += quantity
+		return nil
+	})
+}
+
+// Release makes quantity of the reserved stock available again. It returns an error matching ErrInvalidQuantity if less
+// than quantity is reserved.
+func (s *service) Release(produceCode string, quantity produce.Quantity) (Stock, error) {
+	return s.swap(produceCode, func(stock *Stock) error {
+		err := checkQuantity(stock.Unit, quantity)
+		if err != nil {
+			return err
+		}
+
+		if stock.Reserved < quantity {
+			return fmt.Errorf("%w: only %s is reserved", ErrInvalidQuantity, stock.Reserved)
+		}
+
+		stock.Reserved -= quantity
+		return nil
+	})
+}
+
+// SetThreshold sets the available quantity below which the produce item is low on stock. A threshold of 0 turns the
+// warning off.
+func (s *service) SetThreshold(produceCode string, threshold produce.Quantity) (Stock, error) {
+	return s.swap(produceCode, func(stock *Stock) error {
+		if threshold < 0 {
+			return fmt.Errorf("%w: threshold must not be negative", ErrInvalidQuantity)
+		}
+
+		stock.LowStockThreshold = threshold
+		return nil
+	})
+}
+
+// UpdateProduce converts the stock of item into the unit it is priced per as part of tx, when that changes from the
+// unit stored is priced per. Stock can only be converted between pounds and kilograms, so any other change returns an
+// error matching ErrInStock while some is on hand, and otherwise clears the low stock threshold.
+func (s *service) UpdateProduce(tx *ramdb.Tx, stored, item produce.Item) error {
+	from, to := newStock(stored).Unit, newStock(item).Unit
+	if from == to {
+		return nil
+	}
+
+	stock, err := s.get(item.Code)
+	if err == ramdb.ErrNoRecord {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	onHand, err := produce.ConvertQuantity(stock.OnHand, from, to)
+	switch {
+	case errors.Is(err, produce.ErrIncompatibleUnits) && stock.OnHand > 0:
+		return fmt.Errorf("%w: %s %s on hand can't be converted to %s", ErrInStock, stock.OnHand, from, to)
+	case errors.Is(err, produce.ErrIncompatibleUnits):
+		stock.LowStockThreshold = 0
+	case err != nil:
+		return err
+	default:
+		stock.OnHand = onHand
+		stock.Reserved, _ = produce.ConvertQuantity(stock.Reserved, from, to)
+		stock.LowStockThreshold, _ = produce.ConvertQuantity(stock.LowStockThreshold, from, to)
+	}
+
+	stock.Unit = to
+	rec, err := newRecord(stock)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Join(tx).CompareAndSwap(rec, stock.Version)
+}
+
+// RemoveProduce removes the stock of the produce item with produceCode as part of tx, which removes the item. It
+// returns an error matching ErrInStock if any is on hand, unless force is true. Items that have never been stocked
+// are ignored.
+func (s *service) RemoveProduce(tx *ramdb.Tx, produceCode string, force bool) error {
+	stock, err := s.get(produceCode)
+	if err == ramdb.ErrNoRecord {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	if stock.OnHand > 0 && !force {
+		return fmt.Errorf("%w: %s %s on hand", ErrInStock, stock.OnHand, stock.Unit)
+	}
+
+	rec, err := newRecord(stock)
+	if err != nil {
+		return err
+	}
+
+	// Swapping the stock for itself first makes the transaction fail with ramdb.ErrVersionMismatch if stock is
+	// received after it was checked.
+	stx := s.db.Join(tx)
+	err = stx.CompareAndSwap(rec, stock.Version)
+	if err != nil {
+		return err
+	}
+
+	return stx.Delete(rec)
+}
+
+// swap reads the stock of the produce item with produceCode, changes it and writes it back if it has not been changed
+// in between, so concurrent changes are never lost. A concurrent change causes the stock to be read and changed again,
+// up to maxSwapAttempts times. Stock is recorded for items on their first change, in a transaction that checks the item
+// is unchanged, so no stock is left behind for an item removed in the meantime.
+func (s *service) swap(produceCode string, change func(stock *Stock) error) (Stock, error) {
+	for attempt := 0; attempt < maxSwapAttempts; attempt++ {
+		// The item is read every time so quantities are checked against its current unit.
+		item, err := s.produceSvc.Get(produceCode)
+		if err != nil {
+			return Stock{}, err
+		}
+
+		stock, err := s.get(produceCode)
+		if err == ramdb.ErrNoRecord {
+			stock = newStock(item)
+		} else if err != nil {
+			return Stock{}, err
+		}
+
+		stock.Code = item.Code
+		stock.Unit = newStock(item).Unit
+
+		err = change(&stock)
+		if err != nil {
+			return Stock{}, err
+		}
+
+		rec, err := newRecord(stock)
+		if err != nil {
+			return Stock{}, err
+		}
+
+		if stock.Version == 0 {
+			err = s.insert(rec, item)
+		} else {
+			err = s.db.CompareAndSwap(rec, stock.Version)
+		}
+		if err == ramdb.ErrVersionMismatch || err == ramdb.ErrRecordExists || err == ramdb.ErrNoRecord {
+			continue
+		}
+		if err != nil {
+			return Stock{}, err
+		}
+
+		stock.Version++
+		return derive(stock), nil
+	}
+
+	return Stock{}, ramdb.ErrVersionMismatch
+}
+
+// insert inserts rec, the first stock of item, if item has not been changed or removed since it was read.
+func (s *service) insert(rec *ramdb.Record, item produce.Item) error {
+	tx := s.db.Begin()
+	err := tx.Insert(rec)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = s.produceSvc.Check(tx, item)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// get returns the stored stock of the produce item with produceCode.
+func (s *service) get(produceCode string) (Stock, error) {
+	rec, err := s.db.Get(KeyStockCode, strings.ToLower(produceCode))
+	if err != nil {
+		return Stock{}, err
+	}
+
+	return deserializeStock(rec)
+}
+
+// scan returns the stored stock matching opts.
+func (s *service) scan(opts ramdb.ScanOptions) (stocks []Stock, err error) {
+	recs, err := s.db.Scan(KeyStockCode, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rec := range recs {
+		stock, err := deserializeStock(rec)
+		if err != nil {
+			return nil, err
+		}
+
+		stocks = append(stocks, stock)
+	}
+
+	return stocks, nil
+}
+
+// newStock returns an empty stock of item.
+func newStock(item produce.Item) Stock {
+	unit := item.Unit
+	if unit == "" {
+		unit = produce.UnitEach
+	}
+
+	return Stock{Code: item.Code, Unit: unit}
+}
+
+// newRecord returns the record storing stock, keyed by lowercased produce code.
+func newRecord(stock Stock) (*ramdb.Record, error) {
+	stock.Version = 0
+	return ramdb.NewRecord(strings.ToLower(stock.Code), KeyStockCode, stock)
+}
+
+// deserializeStock deserializes rec into a Stock.
+func deserializeStock(rec *ramdb.Record) (stock Stock, err error) {
+	err = rec.Deserialize(&stock)
+	if err != nil {
+		return Stock{}, err
+	}
+
+	stock.Version = rec.Version()
+	return derive(stock), nil
+}
+
+// derive works out the fields of stock that aren't stored.
+func derive(stock Stock) Stock {
+	stock.Available = stock.OnHand - stock.Reserved
+	stock.LowStock = stock.LowStockThreshold > 0 && stock.Available < stock.LowStockThreshold
+	return stock
+}
+
+// checkQuantity returns an error matching ErrInvalidQuantity unless quantity is positive, and whole for items that
+// aren't sold by weight.
+func checkQuantity(unit produce.Unit, quantity produce.Quantity) error {
+	switch {
+	case quantity <= 0:
+		return fmt.Errorf("%w: %s must be more than zero", ErrInvalidQuantity, quantity)
+	case !unit.Weighed() && !quantity.IsWhole():
+		return fmt.Errorf("%w: %s must be a whole number of %s", ErrInvalidQuantity, quantity, unit)
+	}
+
+	return nil
+}
+
+// add returns a+b, or an error matching ErrInvalidQuantity if the sum is too large to hold.
+func add(a, b produce.Quantity) (produce.Quantity, error) {
+	if b > 0 && a > math.MaxInt64-b {
+		return 0, fmt.Errorf("%w: %s more than %s is too much to hold", ErrInvalidQuantity, b, a)
+	}
+
+	return a + b, nil
+}
+
+// abs returns the absolute value of q.
+func abs(q produce.Quantity) produce.Quantity {
+	if q < 0 {
+		return -q
+	}
+
+	return q
+}
@@ -0,0 +1,420 @@
+package inventory
+
+import (
+	"errors"
+	"math"
+	"sync"
+	"testing"
+
+	"github.com/Rhymond/go-money"
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testCode        = "A12T-4GH7-QPL9-3N4M"
+	testWeighedCode = "TQ4C-VV6T-75ZX-1RMR"
+)
+
+// testProduceService is the produce service the inventory service is tested with.
+type testProduceService interface {
+	ProduceService
+	Patch(produceCode string, patch produce.ItemPatch) (produce.Item, error)
+	Remove(item produce.Item) error
+	ForceRemove(item produce.Item) error
+}
+
+// newTestService returns an inventory service on new tables, with lettuce sold each and apples sold by the pound, and
+// the produce service it is a dependent of.
+func newTestService(t *testing.T) (*service, testProduceService) {
+	db := ramdb.NewDatabase()
+	assert.Nil(t, db.CreateTable("produce"))
+	assert.Nil(t, db.From("produce").CreateOrderedIndex(produce.KeyProduceCode))
+	assert.Nil(t, db.From("produce").CreateOrderedIndex(produce.KeyProduceName))
+	assert.Nil(t, db.From("produce").CreateOrderedIndex(produce.KeyProducePrice))
+	assert.Nil(t, db.CreateTable("inventory"))
+	assert.Nil(t, db.From("inventory").CreateOrderedIndex(KeyStockCode))
+
+	produceSvc := produce.NewService(db.From("produce"))
+	assert.Nil(t, produceSvc.Add([]produce.Item{
+		{Code: testCode, Name: "Lettuce", Price: money.New(346, "USD")},
+		{Code: testWeighedCode, Name: "Gala Apple", Price: money.New(359, "USD"), Unit: produce.UnitPound},
+	}))
+
+	s := NewService(db.From("inventory"), produceSvc)
+	produceSvc.AddDependent(s)
+	return s, produceSvc
+}
+
+func TestService_Operations(t *testing.T) {
+	tests := []struct {
+		test          string
+		code          string
+		operate       func(s *service, code string) (Stock, error)
+		expectedStock Stock
+		expectedError error
+	}{
+		{
+			test: "it should receive stock for an item that has never been stocked",
+			code: testCode,
+			operate: func(s *service, code string) (Stock, error) {
+				return s.Receive(code, produce.NewQuantity(10))
+			},
+			expectedStock: Stock{Code: testCode, Unit: produce.UnitEach, OnHand: 10000, Available: 10000, Version: 1},
+		},
+		{
+			test: "it should receive fractional stock of an item sold by weight",
+			code: testWeighedCode,
+			operate: func(s *service, code string) (Stock, error) {
+				return s.Receive(code, 2500)
+			},
+			expectedStock: Stock{Code: testWeighedCode, Unit: produce.UnitPound, OnHand: 2500, Available: 2500, Version: 1},
+		},
+		{
+			test: "it should return ErrInvalidQuantity for fractional stock of an item sold each",
+			code: testCode,
+			operate: func(s *service, code string) (Stock, error) {
+				return s.Receive(code, 2500)
+			},
+			expectedError: ErrInvalidQuantity,
+		},
+		{
+			test: "it should return ErrNoRecord for an item that isn't catalogued",
+			code: "NONE-NONE-NONE-NONE",
+			operate: func(s *service, code string) (Stock, error) {
+				return s.Receive(code, produce.NewQuantity(1))
+			},
+			expectedError: ramdb.ErrNoRecord,
+		},
+		{
+			test: "it should reserve available stock",
+			code: testCode,
+			operate: func(s *service, code string) (Stock, error) {
+				_, err := s.Receive(code, produce.NewQuantity(10))
+				assert.Nil(t, err)
+				return s.Reserve(code, produce.NewQuantity(4))
+			},
+			expectedStock: Stock{Code: testCode, Unit: produce.UnitEach, OnHand: 10000, Reserved: 4000, Available: 6000, Version: 2},
+		},
+		{
+			test: "it should return ErrInsufficientStock when reserving more than is available",
+			code: testCode,
+			operate: func(s *service, code string) (Stock, error) {
+				_, err := s.Receive(code, produce.NewQuantity(3))
+				assert.Nil(t, err)
+				return s.Reserve(code, produce.NewQuantity(4))
+			},
+			expectedError: ErrInsufficientStock,
+		},
+		{
+			test: "it should release reserved stock",
+			code: testCode,
+			operate: func(s *service, code string) (Stock, error) {
+				_, err := s.Receive(code, produce.NewQuantity(10))
+				assert.Nil(t, err)
+				_, err = s.Reserve(code, produce.NewQuantity(4))
+				assert.Nil(t, err)
+				return s.Release(code, produce.NewQuantity(3))
+			},
+			expectedStock: Stock{Code: testCode, Unit: produce.UnitEach, OnHand: 10000, Reserved: 1000, Available: 9000, Version: 3},
+		},
+		{
+			test: "it should return ErrInvalidQuantity when releasing more than is reserved",
+			code: testCode,
+			operate: func(s *service, code string) (Stock, error) {
+				_, err := s.Receive(code, produce.NewQuantity(10))
+				assert.Nil(t, err)
+				return s.Release(code, produce.NewQuantity(1))
+			},
+			expectedError: ErrInvalidQuantity,
+		},
+		{
+			test: "it should adjust stock down",
+			code: testCode,
+			operate: func(s *service, code string) (Stock, error) {
+				_, err := s.Receive(code, produce.NewQuantity(10))
+				assert.Nil(t, err)
+				return s.Adjust(code, produce.NewQuantity(-2))
+			},
+			expectedStock: Stock{Code: testCode, Unit: produce.UnitEach, OnHand: 8000, Available: 8000, Version: 2},
+		},
+		{
+			test: "it should return ErrInsufficientStock when adjusting stock below what is reserved",
+			code: testCode,
+			operate: func(s *service, code string) (Stock, error) {
+				_, err := s.Receive(code, produce.NewQuantity(10))
+				assert.Nil(t, err)
+				_, err = s.Reserve(code, produce.NewQuantity(9))
+				assert.Nil(t, err)
+				return s.Adjust(code, produce.NewQuantity(-2))
+			},
+			expectedError: ErrInsufficientStock,
+		},
+		{
+			test: "it should return ErrInvalidQuantity when receiving more stock than can be held",
+			code: testWeighedCode,
+			operate: func(s *service, code string) (Stock, error) {
+				_, err := s.Receive(code, produce.NewQuantity(10))
+				assert.Nil(t, err)
+				return s.Receive(code, math.MaxInt64)
+			},
+			expectedError: ErrInvalidQuantity,
+		},
+		{
+			test: "it should return ErrInvalidQuantity when adjusting stock up by more than can be held",
+			code: testWeighedCode,
+			operate: func(s *service, code string) (Stock, error) {
+				_, err := s.Receive(code, produce.NewQuantity(10))
+				assert.Nil(t, err)
+				return s.Adjust(code, math.MaxInt64)
+			},
+			expectedError: ErrInvalidQuantity,
+		},
+		{
+			test: "it should report low stock once available stock falls below the threshold",
+			code: testCode,
+			operate: func(s *service, code string) (Stock, error) {
+				_, err := s.SetThreshold(code, produce.NewQuantity(5))
+				assert.Nil(t, err)
+				_, err = s.Receive(code, produce.NewQuantity(10))
+				assert.Nil(t, err)
+				return s.Reserve(code, produce.NewQuantity(6))
+			},
+			expectedStock: Stock{
+				Code: testCode, Unit: produce.UnitEach, OnHand: 10000, Reserved: 6000, Available: 4000,
+				LowStockThreshold: 5000, LowStock: true, Version: 3,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			s, _ := newTestService(t)
+
+			stock, err := tc.operate(s, tc.code)
+			assert.True(t, errors.Is(err, tc.expectedError), "expected %v, got %v", tc.expectedError, err)
+			if tc.expectedError != nil {
+				return
+			}
+
+			assert.Equal(t, tc.expectedStock, stock)
+
+			stored, err := s.Get(tc.code)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedStock, stored)
+		})
+	}
+}
+
+func TestService_Reserve_Concurrent(t *testing.T) {
+	s, _ := newTestService(t)
+	_, err := s.Receive(testCode, produce.NewQuantity(10))
+	assert.Nil(t, err)
+
+	var (
+		wg           sync.WaitGroup
+		mu           sync.Mutex
+		reserved     int
+		insufficient int
+	)
+
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			_, err := s.Reserve(testCode, produce.NewQuantity(1))
+
+			mu.Lock()
+			defer mu.Unlock()
+			switch {
+			case err == nil:
+				reserved++
+			case errors.Is(err, ErrInsufficientStock):
+				insufficient++
+			default:
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 10, reserved)
+	assert.Equal(t, 10, insufficient)
+
+	stock, err := s.Get(testCode)
+	assert.Nil(t, err)
+	assert.Equal(t, produce.Quantity(0), stock.Available)
+}
+
+func TestService_Low(t *testing.T) {
+	s, _ := newTestService(t)
+
+	_, err := s.SetThreshold(testCode, produce.NewQuantity(5))
+	assert.Nil(t, err)
+	_, err = s.SetThreshold(testWeighedCode, produce.NewQuantity(5))
+	assert.Nil(t, err)
+	_, err = s.Receive(testWeighedCode, produce.NewQuantity(5))
+	assert.Nil(t, err)
+
+	stocks, err := s.Low()
+	assert.Nil(t, err)
+	assert.Len(t, stocks, 1)
+	assert.Equal(t, testCode, stocks[0].Code)
+
+	all, err := s.All()
+	assert.Nil(t, err)
+	assert.Len(t, all, 2)
+}
+
+func TestService_RemoveProduce(t *testing.T) {
+	tests := []struct {
+		test          string
+		stocked       bool
+		received      produce.Quantity
+		force         bool
+		expectedError error
+	}{
+		{
+			test: "it should remove an item that has never been stocked",
+		},
+		{
+			test:    "it should remove an item that is out of stock and its stock",
+			stocked: true,
+		},
+		{
+			test:          "it should refuse to remove an item that is in stock",
+			stocked:       true,
+			received:      produce.NewQuantity(3),
+			expectedError: ErrInStock,
+		},
+		{
+			test:     "it should remove an item that is in stock and its stock when forced",
+			stocked:  true,
+			received: produce.NewQuantity(3),
+			force:    true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			s, produceSvc := newTestService(t)
+
+			if tc.stocked {
+				_, err := s.SetThreshold(testCode, produce.NewQuantity(1))
+				assert.Nil(t, err)
+			}
+
+			if tc.received > 0 {
+				_, err := s.Receive(testCode, tc.received)
+				assert.Nil(t, err)
+			}
+
+			remove := produceSvc.Remove
+			if tc.force {
+				remove = produceSvc.ForceRemove
+			}
+
+			err := remove(produce.Item{Code: testCode})
+			assert.True(t, errors.Is(err, tc.expectedError), err)
+
+			_, itemErr := produceSvc.Get(testCode)
+			_, stockErr := s.get(testCode)
+			if tc.expectedError != nil {
+				assert.Nil(t, itemErr)
+				assert.Nil(t, stockErr)
+				return
+			}
+
+			assert.Equal(t, ramdb.ErrNoRecord, itemErr)
+			assert.Equal(t, ramdb.ErrNoRecord, stockErr)
+		})
+	}
+}
+
+func TestService_insert(t *testing.T) {
+	t.Run("it should not stock an item removed since it was read", func(t *testing.T) {
+		s, produceSvc := newTestService(t)
+
+		item, err := produceSvc.Get(testCode)
+		assert.Nil(t, err)
+		assert.Nil(t, produceSvc.Remove(item))
+
+		rec, err := newRecord(newStock(item))
+		assert.Nil(t, err)
+		assert.Equal(t, ramdb.ErrNoRecord, s.insert(rec, item))
+
+		_, err = s.get(testCode)
+		assert.Equal(t, ramdb.ErrNoRecord, err)
+	})
+}
+
+func TestService_UpdateProduce(t *testing.T) {
+	tests := []struct {
+		test          string
+		code          string
+		received      produce.Quantity
+		unit          produce.Unit
+		expectedStock Stock
+		expectedError error
+	}{
+		{
+			test:     "it should convert the stock of an item weighed in another unit",
+			code:     testWeighedCode,
+			received: produce.NewQuantity(10),
+			unit:     produce.UnitKilogram,
+			expectedStock: Stock{
+				Code:              testWeighedCode,
+				Unit:              produce.UnitKilogram,
+				OnHand:            4536,
+				Available:         4536,
+				LowStockThreshold: 907,
+				Version:           3,
+			},
+		},
+		{
+			test:          "it should refuse a unit stock can't be converted into while in stock",
+			code:          testCode,
+			received:      produce.NewQuantity(10),
+			unit:          produce.UnitPound,
+			expectedError: ErrInStock,
+		},
+		{
+			test: "it should clear the threshold of an item out of stock changed to a unit it can't be converted into",
+			code: testCode,
+			unit: produce.UnitPound,
+			expectedStock: Stock{
+				Code:    testCode,
+				Unit:    produce.UnitPound,
+				Version: 2,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			s, produceSvc := newTestService(t)
+
+			_, err := s.SetThreshold(tc.code, produce.NewQuantity(2))
+			assert.Nil(t, err)
+			if tc.received > 0 {
+				_, err = s.Receive(tc.code, tc.received)
+				assert.Nil(t, err)
+			}
+
+			_, err = produceSvc.Patch(tc.code, produce.ItemPatch{Unit: &tc.unit})
+			assert.True(t, errors.Is(err, tc.expectedError), err)
+			if tc.expectedError != nil {
+				item, err := produceSvc.Get(tc.code)
+				assert.Nil(t, err)
+				assert.NotEqual(t, tc.unit, item.Unit)
+				return
+			}
+
+			stock, err := s.get(tc.code)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedStock, stock)
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package inventory
+
+import "github.com/davidlick/supermarket-api/internal/produce"
+
+// Stock is how much of a produce item is held, in the unit the item is priced per.
+type Stock struct {
+	Code string       `json:"code"`
+	Unit produce.Unit `json:"unit"`
+	// OnHand is the quantity held, including the quantity reserved.
+	OnHand   produce.Quantity `json:"on_hand"`
+	Reserved produce.Quantity `json:"reserved"`
+	// Available is the quantity held that isn't reserved. It is worked out when the stock is read.
+	Available produce.Quantity `json:"available"`
+	// LowStockThreshold is the available quantity below which the item is low on stock. A threshold of 0 is never
+	// crossed.
+	LowStockThreshold produce.Quantity `json:"low_stock_threshold"`
+	// LowStock is true if Available is below LowStockThreshold. It is worked out when the stock is read.
+	LowStock bool   `json:"low_stock"`
+	Version  uint64 `json:"version,omitempty"`
+}
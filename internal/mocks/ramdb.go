@@ -5,6 +5,7 @@
 package mocks
 
 import (
+	context "context"
 	ramdb "github.com/davidlick/supermarket-api/pkg/ramdb"
 	gomock "github.com/golang/mock/gomock"
 	reflect "reflect"
@@ -48,6 +49,21 @@ func (mr *MockRamDBMockRecorder) Get(column, key interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Get", reflect.TypeOf((*MockRamDB)(nil).Get), column, key)
 }
 
+// Lookup mocks base method
+func (m *MockRamDB) Lookup(column, key string) ([]*ramdb.Record, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lookup", column, key)
+	ret0, _ := ret[0].([]*ramdb.Record)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Lookup indicates an expected call of Lookup
+func (mr *MockRamDBMockRecorder) Lookup(column, key interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lookup", reflect.TypeOf((*MockRamDB)(nil).Lookup), column, key)
+}
+
 // Select mocks base method
 func (m *MockRamDB) Select(column string) ([]*ramdb.Record, error) {
 	m.ctrl.T.Helper()
@@ -63,6 +79,51 @@ func (mr *MockRamDBMockRecorder) Select(column interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Select", reflect.TypeOf((*MockRamDB)(nil).Select), column)
 }
 
+// Prefix mocks base method
+func (m *MockRamDB) Prefix(column, prefix string) ([]*ramdb.Record, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Prefix", column, prefix)
+	ret0, _ := ret[0].([]*ramdb.Record)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Prefix indicates an expected call of Prefix
+func (mr *MockRamDBMockRecorder) Prefix(column, prefix interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Prefix", reflect.TypeOf((*MockRamDB)(nil).Prefix), column, prefix)
+}
+
+// Scan mocks base method
+func (m *MockRamDB) Scan(column string, opts ramdb.ScanOptions) ([]*ramdb.Record, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Scan", column, opts)
+	ret0, _ := ret[0].([]*ramdb.Record)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Scan indicates an expected call of Scan
+func (mr *MockRamDBMockRecorder) Scan(column, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Scan", reflect.TypeOf((*MockRamDB)(nil).Scan), column, opts)
+}
+
+// Search mocks base method
+func (m *MockRamDB) Search(column, query string, opts ramdb.SearchOptions) ([]ramdb.SearchResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", column, query, opts)
+	ret0, _ := ret[0].([]ramdb.SearchResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search
+func (mr *MockRamDBMockRecorder) Search(column, query, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockRamDB)(nil).Search), column, query, opts)
+}
+
 // Insert mocks base method
 func (m *MockRamDB) Insert(r *ramdb.Record) error {
 	m.ctrl.T.Helper()
@@ -77,6 +138,48 @@ func (mr *MockRamDBMockRecorder) Insert(r interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Insert", reflect.TypeOf((*MockRamDB)(nil).Insert), r)
 }
 
+// Update mocks base method
+func (m *MockRamDB) Update(r *ramdb.Record) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", r)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update
+func (mr *MockRamDBMockRecorder) Update(r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockRamDB)(nil).Update), r)
+}
+
+// Upsert mocks base method
+func (m *MockRamDB) Upsert(r *ramdb.Record) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Upsert", r)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Upsert indicates an expected call of Upsert
+func (mr *MockRamDBMockRecorder) Upsert(r interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Upsert", reflect.TypeOf((*MockRamDB)(nil).Upsert), r)
+}
+
+// CompareAndSwap mocks base method
+func (m *MockRamDB) CompareAndSwap(r *ramdb.Record, version uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompareAndSwap", r, version)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CompareAndSwap indicates an expected call of CompareAndSwap
+func (mr *MockRamDBMockRecorder) CompareAndSwap(r, version interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompareAndSwap", reflect.TypeOf((*MockRamDB)(nil).CompareAndSwap), r, version)
+}
+
 // Delete mocks base method
 func (m *MockRamDB) Delete(r *ramdb.Record) error {
 	m.ctrl.T.Helper()
@@ -90,3 +193,46 @@ func (mr *MockRamDBMockRecorder) Delete(r interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRamDB)(nil).Delete), r)
 }
+
+// Begin mocks base method
+func (m *MockRamDB) Begin() *ramdb.Tx {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Begin")
+	ret0, _ := ret[0].(*ramdb.Tx)
+	return ret0
+}
+
+// Begin indicates an expected call of Begin
+func (mr *MockRamDBMockRecorder) Begin() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Begin", reflect.TypeOf((*MockRamDB)(nil).Begin))
+}
+
+// Join mocks base method
+func (m *MockRamDB) Join(tx *ramdb.Tx) *ramdb.Tx {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Join", tx)
+	ret0, _ := ret[0].(*ramdb.Tx)
+	return ret0
+}
+
+// Join indicates an expected call of Join
+func (mr *MockRamDBMockRecorder) Join(tx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Join", reflect.TypeOf((*MockRamDB)(nil).Join), tx)
+}
+
+// Watch mocks base method
+func (m *MockRamDB) Watch(ctx context.Context, opts ramdb.WatchOptions) (*ramdb.Watcher, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Watch", ctx, opts)
+	ret0, _ := ret[0].(*ramdb.Watcher)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Watch indicates an expected call of Watch
+func (mr *MockRamDBMockRecorder) Watch(ctx, opts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Watch", reflect.TypeOf((*MockRamDB)(nil).Watch), ctx, opts)
+}
@@ -0,0 +1,16 @@
+package prices
+
+const (
+	KeyPriceID  = "price_id"
+	KeyPriceDue = "due"
+)
+
+const (
+	// timeLayout formats effective times in keys. It is fixed width in UTC, so keys sort in time order.
+	timeLayout = "20060102T150405.000000000Z"
+
+	// duePending prefixes the due key of changes that have not been applied yet, and dueApplied is the due key of
+	// changes that have.
+	duePending = "pending@"
+	dueApplied = "applied"
+)
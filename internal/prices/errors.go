@@ -0,0 +1,7 @@
+package prices
+
+import "errors"
+
+var (
+	ErrNoPrice = errors.New("no price is effective at the given time")
+)
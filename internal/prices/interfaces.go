@@ -0,0 +1,8 @@
+package prices
+
+import "github.com/davidlick/supermarket-api/internal/produce"
+
+type ProduceService interface {
+	Get(produceCode string) (item produce.Item, err error)
+	Patch(produceCode string, patch produce.ItemPatch) (produce.Item, error)
+}
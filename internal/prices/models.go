@@ -0,0 +1,19 @@
+package prices
+
+import (
+	"time"
+
+	"github.com/Rhymond/go-money"
+)
+
+// Change is a price of a produce item that takes effect at EffectiveFrom.
+type Change struct {
+	Code          string       `json:"code"`
+	Price         *money.Money `json:"price"`
+	EffectiveFrom time.Time    `json:"effective_from"`
+	Author        string       `json:"author,omitempty"`
+	RecordedAt    time.Time    `json:"recorded_at"`
+	// Applied is true once the price has been set on the produce item, or for a backdated change that a later change
+	// was already in effect over.
+	Applied bool `json:"applied"`
+}
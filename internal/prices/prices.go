@@ -0,0 +1,240 @@
+package prices
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/davidlick/supermarket-api/internal/interfaces"
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+	"github.com/sirupsen/logrus"
+)
+
+type service struct {
+	db         interfaces.RamDB
+	produceSvc ProduceService
+	now        func() time.Time
+}
+
+// NewService creates a new price service recording the price changes of produce items in db.
+func NewService(db interfaces.RamDB, produceSvc ProduceService) *service {
+	return &service{
+		db:         db,
+		produceSvc: produceSvc,
+		now:        time.Now,
+	}
+}
+
+// Record records a change of the price of the produce item with produceCode, effective from effectiveFrom, or now if
+// effectiveFrom is zero. A change effective now or in the past is applied to the item immediately unless a later change
+// is already in effect, and a change in the future is scheduled and applied by ApplyDue. Recording a second change for
+// the same item and time replaces the first. It returns produce.ValidationErrors if the item can't be priced at price.
+func (s *service) Record(produceCode string, price *money.Money, effectiveFrom time.Time, author string) (Change, error) {
+	item, err := s.produceSvc.Get(produceCode)
+	if err != nil {
+		return Change{}, err
+	}
+
+	item.Price = price
+	err = produce.Validate(item)
+	if err != nil {
+		return Change{}, err
+	}
+
+	now := s.now()
+	if effectiveFrom.IsZero() {
+		effectiveFrom = now
+	}
+
+	change := Change{
+		Code:          item.Code,
+		Price:         price,
+		EffectiveFrom: effectiveFrom.UTC(),
+		Author:        author,
+		RecordedAt:    now.UTC(),
+	}
+
+	if effectiveFrom.After(now) {
+		return change, s.store(change)
+	}
+
+	// A backdated change only sets the item's price if no later change is already in effect.
+	latest, err := s.At(produceCode, now)
+	if err != nil && err != ErrNoPrice {
+		return Change{}, err
+	}
+
+	if err == ErrNoPrice || !latest.EffectiveFrom.After(change.EffectiveFrom) {
+		_, err = s.produceSvc.Patch(produceCode, produce.ItemPatch{Price: price})
+		if err != nil {
+			return Change{}, err
+		}
+	}
+
+	change.Applied = true
+	return change, s.store(change)
+}
+
+// RecordCurrent records the price of item as a change that has already been applied, for prices set directly on the
+// produce item. Nothing is recorded if the item has no price or its price is already the latest one recorded.
+func (s *service) RecordCurrent(item produce.Item, author string) error {
+	if item.Price == nil {
+		return nil
+	}
+
+	now := s.now()
+	latest, err := s.At(item.Code, now)
+	if err != nil && err != ErrNoPrice {
+		return err
+	}
+
+	if err == nil {
+		same, err := latest.Price.Equals(item.Price)
+		if err == nil && same {
+			return nil
+		}
+	}
+
+	return s.store(Change{
+		Code:          item.Code,
+		Price:         item.Price,
+		EffectiveFrom: now.UTC(),
+		Author:        author,
+		RecordedAt:    now.UTC(),
+		Applied:       true,
+	})
+}
+
+// History returns every change recorded for the produce item with produceCode, including scheduled ones, sorted by
+// the time they take effect.
+func (s *service) History(produceCode string) (changes []Change, err error) {
+	recs, err := s.db.Scan(KeyPriceID, ramdb.ScanOptions{Prefix: codePrefix(produceCode)})
+	if err != nil {
+		return nil, err
+	}
+
+	return deserializeChanges(recs)
+}
+
+// At returns the change setting the price of the produce item with produceCode at the time at. It returns ErrNoPrice
+// if no change was effective then.
+func (s *service) At(produceCode string, at time.Time) (Change, error) {
+	recs, err := s.db.Scan(KeyPriceID, ramdb.ScanOptions{
+		Prefix:     codePrefix(produceCode),
+		To:         changeKey(produceCode, at) + "\x00",
+		Descending: true,
+		Limit:      1,
+	})
+	if err != nil {
+		return Change{}, err
+	}
+
+	if len(recs) == 0 {
+		return Change{}, ErrNoPrice
+	}
+
+	var change Change
+	err = recs[0].Deserialize(&change)
+	return change, err
+}
+
+// ApplyDue applies every scheduled change effective at or before now to its produce item, in the order they take
+// effect. Changes for items that no longer exist, or that a later change in effect by now has replaced, are marked as
+// applied without changing the item.
+func (s *service) ApplyDue(now time.Time) error {
+	recs, err := s.db.Scan(KeyPriceDue, ramdb.ScanOptions{
+		Prefix: duePending,
+		To:     duePending + now.UTC().Format(timeLayout) + "\x00",
+	})
+	if err != nil {
+		return err
+	}
+
+	changes, err := deserializeChanges(recs)
+	if err != nil {
+		return err
+	}
+
+	for _, change := range changes {
+		latest, err := s.At(change.Code, now)
+		if err != nil {
+			return err
+		}
+
+		if !latest.EffectiveFrom.After(change.EffectiveFrom) {
+			_, err = s.produceSvc.Patch(change.Code, produce.ItemPatch{Price: change.Price})
+			if err != nil && err != ramdb.ErrNoRecord {
+				return err
+			}
+		}
+
+		change.Applied = true
+		err = s.store(change)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Run calls ApplyDue every interval until ctx is done. Errors are logged and retried on the next tick.
+func (s *service) Run(ctx context.Context, interval time.Duration, logger *logrus.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			err := s.ApplyDue(s.now())
+			if err != nil {
+				logger.Errorf("could not apply scheduled price changes: %v", err)
+			}
+		}
+	}
+}
+
+// store upserts change into the database.
+func (s *service) store(change Change) error {
+	rec, err := ramdb.NewRecord(changeKey(change.Code, change.EffectiveFrom), KeyPriceID, change)
+	if err != nil {
+		return err
+	}
+
+	due := dueApplied
+	if !change.Applied {
+		due = duePending + change.EffectiveFrom.UTC().Format(timeLayout)
+	}
+
+	return s.db.Upsert(rec.WithKey(KeyPriceDue, due))
+}
+
+// codePrefix returns the prefix of the keys of every change to the produce item with produceCode.
+func codePrefix(produceCode string) string {
+	return strings.ToLower(produceCode) + "@"
+}
+
+// changeKey returns the key of the change to the produce item with produceCode effective at t. Keys sort by code and
+// then by time.
+func changeKey(produceCode string, t time.Time) string {
+	return codePrefix(produceCode) + t.UTC().Format(timeLayout)
+}
+
+// deserializeChanges deserializes each record into a Change.
+func deserializeChanges(recs []*ramdb.Record) (changes []Change, err error) {
+	for _, rec := range recs {
+		var change Change
+		err = rec.Deserialize(&change)
+		if err != nil {
+			return nil, err
+		}
+
+		changes = append(changes, change)
+	}
+
+	return
+}
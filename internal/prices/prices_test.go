@@ -0,0 +1,290 @@
+package prices
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/davidlick/supermarket-api/internal/interfaces"
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+	"github.com/stretchr/testify/assert"
+)
+
+const testCode = "A12T-4GH7-QPL9-3N4M"
+
+var testNow = time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+var testItem = produce.Item{Code: testCode, Name: "Lettuce", Price: money.New(346, "USD")}
+
+// newTestService returns a price service on new produce and prices tables, with testNow as the current time and
+// testItem added to the produce table. It also returns the produce table.
+func newTestService(t *testing.T) (*service, interfaces.RamDB) {
+	db := ramdb.NewDatabase()
+	assert.Nil(t, db.CreateTable("produce"))
+	assert.Nil(t, db.From("produce").CreateOrderedIndex(produce.KeyProduceCode))
+	assert.Nil(t, db.From("produce").CreateOrderedIndex(produce.KeyProduceName))
+	assert.Nil(t, db.From("produce").CreateOrderedIndex(produce.KeyProducePrice))
+	assert.Nil(t, db.CreateTable("prices"))
+	assert.Nil(t, db.From("prices").CreateOrderedIndex(KeyPriceID))
+	assert.Nil(t, db.From("prices").CreateOrderedIndex(KeyPriceDue))
+
+	produceSvc := produce.NewService(db.From("produce"))
+	assert.Nil(t, produceSvc.Add([]produce.Item{testItem}))
+
+	svc := NewService(db.From("prices"), produceSvc)
+	svc.now = func() time.Time { return testNow }
+	return svc, db.From("produce")
+}
+
+func TestService_Record(t *testing.T) {
+	tests := []struct {
+		test           string
+		code           string
+		price          *money.Money
+		effectiveFrom  time.Time
+		expectedPrice  int64
+		expectedChange Change
+		expectedError  error
+	}{
+		{
+			test:          "it should apply a change effective now",
+			code:          testCode,
+			price:         money.New(299, "USD"),
+			expectedPrice: 299,
+			expectedChange: Change{
+				Code:          testCode,
+				Price:         money.New(299, "USD"),
+				EffectiveFrom: testNow,
+				Author:        "author",
+				RecordedAt:    testNow,
+				Applied:       true,
+			},
+		},
+		{
+			test:          "it should apply a change effective in the past",
+			code:          testCode,
+			price:         money.New(299, "USD"),
+			effectiveFrom: testNow.Add(-time.Hour),
+			expectedPrice: 299,
+			expectedChange: Change{
+				Code:          testCode,
+				Price:         money.New(299, "USD"),
+				EffectiveFrom: testNow.Add(-time.Hour),
+				Author:        "author",
+				RecordedAt:    testNow,
+				Applied:       true,
+			},
+		},
+		{
+			test:          "it should schedule a change effective in the future",
+			code:          testCode,
+			price:         money.New(299, "USD"),
+			effectiveFrom: testNow.Add(time.Hour),
+			expectedPrice: 346,
+			expectedChange: Change{
+				Code:          testCode,
+				Price:         money.New(299, "USD"),
+				EffectiveFrom: testNow.Add(time.Hour),
+				Author:        "author",
+				RecordedAt:    testNow,
+			},
+		},
+		{
+			test:          "it should return validation errors for an invalid price",
+			code:          testCode,
+			price:         money.New(-1, "USD"),
+			expectedPrice: 346,
+			expectedError: produce.ValidationErrors{{Code: testCode, Field: "price.amount", Message: "must be positive"}},
+		},
+		{
+			test:          "it should return ErrNoRecord for an unknown item",
+			code:          "ZZZZ-ZZZZ-ZZZZ-ZZZZ",
+			price:         money.New(299, "USD"),
+			expectedPrice: 346,
+			expectedError: ramdb.ErrNoRecord,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			svc, _ := newTestService(t)
+
+			change, err := svc.Record(tc.code, tc.price, tc.effectiveFrom, "author")
+
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedChange, change)
+
+			item, err := svc.produceSvc.Get(testCode)
+			assert.Nil(t, err)
+			assert.Equal(t, tc.expectedPrice, item.Price.Amount())
+		})
+	}
+}
+
+func TestService_Record_Backdated(t *testing.T) {
+	t.Run("it should not apply a change backdated behind the change in effect", func(t *testing.T) {
+		svc, _ := newTestService(t)
+
+		_, err := svc.Record(testCode, money.New(299, "USD"), testNow.Add(-time.Hour), "author")
+		assert.Nil(t, err)
+
+		change, err := svc.Record(testCode, money.New(250, "USD"), testNow.Add(-2*time.Hour), "author")
+		assert.Nil(t, err)
+		assert.True(t, change.Applied)
+
+		item, err := svc.produceSvc.Get(testCode)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(299), item.Price.Amount())
+
+		current, err := svc.At(testCode, testNow)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(299), current.Price.Amount())
+
+		earlier, err := svc.At(testCode, testNow.Add(-90*time.Minute))
+		assert.Nil(t, err)
+		assert.Equal(t, int64(250), earlier.Price.Amount())
+
+		assert.Nil(t, svc.ApplyDue(testNow))
+		item, err = svc.produceSvc.Get(testCode)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(299), item.Price.Amount())
+	})
+}
+
+func TestService_At(t *testing.T) {
+	svc, _ := newTestService(t)
+
+	_, err := svc.Record(testCode, money.New(299, "USD"), testNow.Add(-time.Hour), "author")
+	assert.Nil(t, err)
+	_, err = svc.Record(testCode, money.New(249, "USD"), testNow.Add(time.Hour), "author")
+	assert.Nil(t, err)
+
+	tests := []struct {
+		test          string
+		at            time.Time
+		expectedPrice int64
+		expectedError error
+	}{
+		{
+			test:          "it should return ErrNoPrice before the first change",
+			at:            testNow.Add(-2 * time.Hour),
+			expectedError: ErrNoPrice,
+		},
+		{
+			test:          "it should return a change from the time it is effective",
+			at:            testNow.Add(-time.Hour),
+			expectedPrice: 299,
+		},
+		{
+			test:          "it should return the latest change effective at the time",
+			at:            testNow,
+			expectedPrice: 299,
+		},
+		{
+			test:          "it should return scheduled changes effective at the time",
+			at:            testNow.Add(2 * time.Hour),
+			expectedPrice: 249,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			change, err := svc.At(testCode, tc.at)
+
+			assert.Equal(t, tc.expectedError, err)
+			if tc.expectedError == nil {
+				assert.Equal(t, tc.expectedPrice, change.Price.Amount())
+			}
+		})
+	}
+}
+
+func TestService_ApplyDue(t *testing.T) {
+	t.Run("it should apply only the changes that are due, in order", func(t *testing.T) {
+		svc, _ := newTestService(t)
+
+		_, err := svc.Record(testCode, money.New(299, "USD"), testNow.Add(time.Hour), "author")
+		assert.Nil(t, err)
+		_, err = svc.Record(testCode, money.New(249, "USD"), testNow.Add(2*time.Hour), "author")
+		assert.Nil(t, err)
+		_, err = svc.Record(testCode, money.New(199, "USD"), testNow.Add(3*time.Hour), "author")
+		assert.Nil(t, err)
+
+		err = svc.ApplyDue(testNow.Add(2 * time.Hour))
+		assert.Nil(t, err)
+
+		item, err := svc.produceSvc.Get(testCode)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(249), item.Price.Amount())
+
+		changes, err := svc.History(testCode)
+		assert.Nil(t, err)
+		assert.Len(t, changes, 3)
+		assert.True(t, changes[0].Applied)
+		assert.True(t, changes[1].Applied)
+		assert.False(t, changes[2].Applied)
+	})
+
+	t.Run("it should not apply a change replaced by a later one before it was due", func(t *testing.T) {
+		svc, _ := newTestService(t)
+
+		_, err := svc.Record(testCode, money.New(299, "USD"), testNow.Add(time.Hour), "author")
+		assert.Nil(t, err)
+
+		// The price is set directly after the scheduled change took effect, but before it was applied.
+		svc.now = func() time.Time { return testNow.Add(90 * time.Minute) }
+		_, err = svc.Record(testCode, money.New(249, "USD"), time.Time{}, "author")
+		assert.Nil(t, err)
+
+		err = svc.ApplyDue(testNow.Add(2 * time.Hour))
+		assert.Nil(t, err)
+
+		item, err := svc.produceSvc.Get(testCode)
+		assert.Nil(t, err)
+		assert.Equal(t, int64(249), item.Price.Amount())
+
+		changes, err := svc.History(testCode)
+		assert.Nil(t, err)
+		assert.Len(t, changes, 2)
+		assert.True(t, changes[0].Applied)
+		assert.True(t, changes[1].Applied)
+	})
+
+	t.Run("it should mark changes to removed items as applied", func(t *testing.T) {
+		svc, produceDB := newTestService(t)
+
+		_, err := svc.Record(testCode, money.New(299, "USD"), testNow.Add(time.Hour), "author")
+		assert.Nil(t, err)
+		assert.Nil(t, produce.NewService(produceDB).Remove(testItem))
+
+		err = svc.ApplyDue(testNow.Add(time.Hour))
+		assert.Nil(t, err)
+
+		changes, err := svc.History(testCode)
+		assert.Nil(t, err)
+		assert.Len(t, changes, 1)
+		assert.True(t, changes[0].Applied)
+	})
+}
+
+func TestService_RecordCurrent(t *testing.T) {
+	t.Run("it should record a changed price once", func(t *testing.T) {
+		svc, _ := newTestService(t)
+		item := testItem
+
+		assert.Nil(t, svc.RecordCurrent(item, "author"))
+		assert.Nil(t, svc.RecordCurrent(item, "author"))
+
+		svc.now = func() time.Time { return testNow.Add(time.Minute) }
+		item.Price = money.New(299, "USD")
+		assert.Nil(t, svc.RecordCurrent(item, "other"))
+
+		changes, err := svc.History(testCode)
+		assert.Nil(t, err)
+		assert.Len(t, changes, 2)
+		assert.Equal(t, int64(346), changes[0].Price.Amount())
+		assert.Equal(t, "other", changes[1].Author)
+		assert.True(t, changes[1].Applied)
+	})
+}
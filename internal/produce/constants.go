@@ -1,5 +1,30 @@
 package produce
 
 const (
-	KeyProduceCode = "produce_code"
+	KeyProduceCode  = "produce_code"
+	KeyProduceName  = "name"
+	KeyProducePrice = "price"
+	// KeyProduceSearch is the column of the text index on item names used by Search.
+	KeyProduceSearch = "search"
 )
+
+const (
+	// DefaultListLimit is the number of items List returns when no limit is given.
+	DefaultListLimit = 100
+	// MaxListLimit is the most items List returns at once.
+	MaxListLimit = 1000
+	// DefaultSearchLimit is the number of items Search returns when no limit is given.
+	DefaultSearchLimit = 20
+)
+
+// maxSwapAttempts is the number of times an update without a version is retried when the item is changed by another
+// caller between being read and written.
+const maxSwapAttempts = 5
+
+// SupportedCurrencies are the currencies produce can be priced in.
+var SupportedCurrencies = map[string]bool{
+	"USD": true,
+	"CAD": true,
+	"EUR": true,
+	"GBP": true,
+}
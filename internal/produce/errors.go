@@ -0,0 +1,12 @@
+package produce
+
+import "errors"
+
+var (
+	ErrInvalidItem = errors.New("invalid produce item")
+	ErrInvalidSort = errors.New("invalid sort field")
+
+	ErrCurrencyRequired = errors.New("a currency is required to filter by price")
+
+	ErrIncompatibleUnits = errors.New("units can't be converted between")
+)
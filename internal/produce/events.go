@@ -0,0 +1,78 @@
+package produce
+
+import (
+	"context"
+
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+)
+
+const (
+	EventAdd    = "add"
+	EventUpdate = "update"
+	EventDelete = "delete"
+)
+
+// eventTypes maps the operations of the produce table's events to the type of the Event.
+var eventTypes = map[string]string{
+	ramdb.EventInsert: EventAdd,
+	ramdb.EventUpdate: EventUpdate,
+	ramdb.EventDelete: EventDelete,
+}
+
+// Event is a change to the produce catalogue.
+type Event struct {
+	// ID is the sequence number of the change, which goes up by one for every change.
+	ID   uint64 `json:"id"`
+	Type string `json:"type"`
+	// Item is the item as it is after an add or update, or as it was before a delete.
+	Item Item `json:"item"`
+	// Previous is the item as it was before an update, and is nil for an add or delete.
+	Previous *Item `json:"previous,omitempty"`
+}
+
+// Watch returns a channel of changes to the catalogue, starting from the change with ID from, or from now if from is
+// 0. The channel is closed once ctx is done, or if the caller falls too far behind, in which case Watch can be called
+// again from the ID after the last Event received. It returns ramdb.ErrSequenceUnavailable if the change with ID from
+// is no longer kept.
+func (s *service) Watch(ctx context.Context, from uint64) (<-chan Event, error) {
+	w, err := s.db.Watch(ctx, ramdb.WatchOptions{From: from})
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan Event)
+	go func() {
+		defer close(events)
+
+		for e := range w.Events() {
+			rec := e.After
+			if rec == nil {
+				rec = e.Before
+			}
+
+			event := Event{ID: e.Seq, Type: eventTypes[e.Op]}
+			err := rec.Deserialize(&event.Item)
+			if err != nil {
+				continue
+			}
+			event.Item.Version = rec.Version()
+
+			if e.After != nil && e.Before != nil {
+				event.Previous = &Item{}
+				err = e.Before.Deserialize(event.Previous)
+				if err != nil {
+					continue
+				}
+				event.Previous.Version = e.Before.Version()
+			}
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return events, nil
+}
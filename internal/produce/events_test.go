@@ -0,0 +1,77 @@
+package produce
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_Watch(t *testing.T) {
+	lettuce := Item{Code: "A12T-4GH7-QPL9-3N4M", Name: "Lettuce", Price: money.New(346, "USD")}
+
+	t.Run("it should send adds, updates and deletes", func(t *testing.T) {
+		svc := NewService(newTestTable(t, nil))
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		events, err := svc.Watch(ctx, 0)
+		assert.Nil(t, err)
+
+		assert.Nil(t, svc.Add([]Item{lettuce}))
+		name := "Iceberg Lettuce"
+		_, err = svc.Patch(lettuce.Code, ItemPatch{Name: &name})
+		assert.Nil(t, err)
+		assert.Nil(t, svc.Remove(lettuce))
+
+		var received []Event
+		for len(received) < 3 {
+			select {
+			case e := <-events:
+				received = append(received, e)
+			case <-time.After(time.Second):
+				t.Fatalf("timed out after %d events", len(received))
+			}
+		}
+
+		assert.Equal(t, Event{ID: 1, Type: EventAdd, Item: Item{Code: lettuce.Code, Name: "Lettuce", Price: lettuce.Price, Unit: UnitEach, Version: 1}}, received[0])
+		assert.Equal(t, Event{ID: 2, Type: EventUpdate, Item: Item{Code: lettuce.Code, Name: name, Price: lettuce.Price, Unit: UnitEach, Version: 2}, Previous: &Item{Code: lettuce.Code, Name: "Lettuce", Price: lettuce.Price, Unit: UnitEach, Version: 1}}, received[1])
+		assert.Equal(t, Event{ID: 3, Type: EventDelete, Item: Item{Code: lettuce.Code, Name: name, Price: lettuce.Price, Unit: UnitEach, Version: 2}}, received[2])
+
+		cancel()
+		select {
+		case _, ok := <-events:
+			assert.False(t, ok)
+		case <-time.After(time.Second):
+			t.Fatal("events were not closed")
+		}
+	})
+
+	t.Run("it should resume from an ID", func(t *testing.T) {
+		svc := NewService(newTestTable(t, nil))
+		assert.Nil(t, svc.Add([]Item{lettuce}))
+		assert.Nil(t, svc.Remove(lettuce))
+
+		events, err := svc.Watch(context.Background(), 2)
+		assert.Nil(t, err)
+
+		select {
+		case e := <-events:
+			assert.Equal(t, uint64(2), e.ID)
+			assert.Equal(t, EventDelete, e.Type)
+		case <-time.After(time.Second):
+			t.Fatal("timed out")
+		}
+	})
+
+	t.Run("it should return ramdb.ErrSequenceUnavailable for an ID that is not kept", func(t *testing.T) {
+		svc := NewService(newTestTable(t, nil))
+
+		_, err := svc.Watch(context.Background(), 5)
+		assert.Equal(t, ramdb.ErrSequenceUnavailable, err)
+	})
+}
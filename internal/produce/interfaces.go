@@ -0,0 +1,20 @@
+package produce
+
+import "github.com/davidlick/supermarket-api/pkg/ramdb"
+
+// Dependent is a service storing data about produce items in tables of the same database, which is kept in step with
+// the items in the transaction that changes or removes them.
+type Dependent interface {
+	// UpdateProduce adds the commands bringing what is stored about an item up to date with item, which was stored, to
+	// tx. It returns an error to stop the item being changed.
+	UpdateProduce(tx *ramdb.Tx, stored, item Item) error
+	// RemoveProduce adds the commands removing what is stored about the item with produceCode to tx. It returns an
+	// error to stop the item being removed. force is true if the item is to be removed regardless.
+	RemoveProduce(tx *ramdb.Tx, produceCode string, force bool) error
+}
+
+// writer is the writes shared by the database and its transactions.
+type writer interface {
+	CompareAndSwap(r *ramdb.Record, version uint64) error
+	Delete(r *ramdb.Record) error
+}
@@ -0,0 +1,145 @@
+package produce
+
+import (
+	"strings"
+
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+)
+
+// sortColumns maps the fields List can sort by to the index on each.
+var sortColumns = map[string]string{
+	"":      KeyProduceCode,
+	"code":  KeyProduceCode,
+	"name":  KeyProduceName,
+	"price": KeyProducePrice,
+}
+
+// ListOptions selects, sorts and pages the produce items returned by List.
+type ListOptions struct {
+	// Sort is the field items are sorted by: "code", which is the default, "name" or "price". Prices sort by currency
+	// first and then by amount.
+	Sort string
+	// Descending sorts items from largest to smallest.
+	Descending bool
+	// Limit is the most items returned. It defaults to DefaultListLimit and is capped at MaxListLimit.
+	Limit int
+	// Cursor is the next cursor returned by a previous List with the same Sort, and continues from where it left off.
+	Cursor string
+
+	// Prefix only returns items with codes beginning with Prefix, ignoring case.
+	Prefix string
+	// NameContains only returns items with names containing NameContains, ignoring case.
+	NameContains string
+	// Currency only returns items priced in Currency.
+	Currency string
+	// MinPrice and MaxPrice only return items priced from MinPrice up to and including MaxPrice, in minor units of
+	// Currency. A bound of 0 is ignored, and a bound needs a Currency to compare with.
+	MinPrice int64
+	MaxPrice int64
+}
+
+// List returns a page of produce items matching opts, and the cursor of the next page or "" if it is the last page.
+// It returns ErrInvalidSort if opts.Sort is not a field items can be sorted by, and ErrCurrencyRequired if a price
+// bound is given without a currency.
+func (s *service) List(opts ListOptions) (items []Item, next string, err error) {
+	column, found := sortColumns[opts.Sort]
+	if !found {
+		return nil, "", ErrInvalidSort
+	}
+
+	if opts.Currency == "" && (opts.MinPrice != 0 || opts.MaxPrice != 0) {
+		return nil, "", ErrCurrencyRequired
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = DefaultListLimit
+	}
+	if limit > MaxListLimit {
+		limit = MaxListLimit
+	}
+
+	scan := ramdb.ScanOptions{
+		Descending: opts.Descending,
+		After:      opts.Cursor,
+		// One more item than the limit is scanned to find out if there is a next page.
+		Limit: limit + 1,
+	}
+
+	// Records are decoded once by the filter, which keeps the items it accepts in scan order.
+	var matched []Item
+	if match := opts.match(); match != nil {
+		scan.Filter = func(r *ramdb.Record) bool {
+			var item Item
+			err := r.Deserialize(&item)
+			if err != nil || !match(item) {
+				return false
+			}
+
+			matched = append(matched, item)
+			return true
+		}
+	}
+
+	switch column {
+	case KeyProduceCode:
+		scan.Prefix = strings.ToLower(opts.Prefix)
+	case KeyProducePrice:
+		if opts.Currency != "" {
+			scan.Prefix = strings.ToUpper(opts.Currency) + ":"
+		}
+	}
+
+	recs, err := s.db.Scan(column, scan)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if len(recs) > limit {
+		recs = recs[:limit]
+		next = recs[limit-1].Cursor(column, opts.Descending)
+	}
+
+	if scan.Filter != nil {
+		return matched[:len(recs)], next, nil
+	}
+
+	items, err = deserializeItems(recs)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return items, next, nil
+}
+
+// match returns a func reporting whether an item matches the options that aren't answered by the scanned index, or
+// nil if there are none.
+func (opts ListOptions) match() func(item Item) bool {
+	prefix := strings.ToLower(opts.Prefix)
+	name := strings.ToLower(opts.NameContains)
+	currency := strings.ToUpper(opts.Currency)
+	if prefix == "" && name == "" && currency == "" {
+		return nil
+	}
+
+	return func(item Item) bool {
+		if prefix != "" && !strings.HasPrefix(strings.ToLower(item.Code), prefix) {
+			return false
+		}
+
+		if name != "" && !strings.Contains(strings.ToLower(item.Name), name) {
+			return false
+		}
+
+		if currency == "" {
+			return true
+		}
+
+		if item.Price == nil || item.Price.Currency().Code != currency {
+			return false
+		}
+
+		amount := item.Price.Amount()
+		return (opts.MinPrice == 0 || amount >= opts.MinPrice) && (opts.MaxPrice == 0 || amount <= opts.MaxPrice)
+	}
+}
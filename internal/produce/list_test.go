@@ -0,0 +1,142 @@
+package produce
+
+import (
+	"testing"
+
+	"github.com/Rhymond/go-money"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_List(t *testing.T) {
+	items := []Item{
+		{Code: "A12T-4GH7-QPL9-3N4M", Name: "Lettuce", Price: money.New(346, "USD")},
+		{Code: "E5T6-9UI3-TH15-QR88", Name: "Peach", Price: money.New(299, "USD")},
+		{Code: "YRT6-72AS-K736-L4AR", Name: "Green Pepper", Price: money.New(79, "USD")},
+		{Code: "TQ4C-VV6T-75ZX-1RMR", Name: "Gala Apple", Price: money.New(359, "USD")},
+		{Code: "A12T-0000-0000-0001", Name: "Red Pepper", Price: money.New(120, "EUR")},
+	}
+
+	tests := []struct {
+		test          string
+		opts          ListOptions
+		expectedCodes []string
+		expectNext    bool
+		expectedError error
+	}{
+		{
+			test:          "it should list every item sorted by code",
+			expectedCodes: []string{"A12T-0000-0000-0001", "A12T-4GH7-QPL9-3N4M", "E5T6-9UI3-TH15-QR88", "TQ4C-VV6T-75ZX-1RMR", "YRT6-72AS-K736-L4AR"},
+		},
+		{
+			test:          "it should return a next cursor if there are more items",
+			opts:          ListOptions{Limit: 2},
+			expectedCodes: []string{"A12T-0000-0000-0001", "A12T-4GH7-QPL9-3N4M"},
+			expectNext:    true,
+		},
+		{
+			test:          "it should sort by name",
+			opts:          ListOptions{Sort: "name"},
+			expectedCodes: []string{"TQ4C-VV6T-75ZX-1RMR", "YRT6-72AS-K736-L4AR", "A12T-4GH7-QPL9-3N4M", "E5T6-9UI3-TH15-QR88", "A12T-0000-0000-0001"},
+		},
+		{
+			test:          "it should sort by price descending within a currency",
+			opts:          ListOptions{Sort: "price", Descending: true, Currency: "usd"},
+			expectedCodes: []string{"TQ4C-VV6T-75ZX-1RMR", "A12T-4GH7-QPL9-3N4M", "E5T6-9UI3-TH15-QR88", "YRT6-72AS-K736-L4AR"},
+		},
+		{
+			test:          "it should filter by code prefix",
+			opts:          ListOptions{Sort: "name", Prefix: "a12t"},
+			expectedCodes: []string{"A12T-4GH7-QPL9-3N4M", "A12T-0000-0000-0001"},
+		},
+		{
+			test:          "it should filter by names containing a string ignoring case",
+			opts:          ListOptions{NameContains: "PEPPER"},
+			expectedCodes: []string{"A12T-0000-0000-0001", "YRT6-72AS-K736-L4AR"},
+		},
+		{
+			test:          "it should filter by price range and currency",
+			opts:          ListOptions{Currency: "USD", MinPrice: 100, MaxPrice: 346},
+			expectedCodes: []string{"A12T-4GH7-QPL9-3N4M", "E5T6-9UI3-TH15-QR88"},
+		},
+		{
+			test:          "it should return ErrInvalidSort for an unknown sort field",
+			opts:          ListOptions{Sort: "weight"},
+			expectedError: ErrInvalidSort,
+		},
+		{
+			test:          "it should return ErrCurrencyRequired for a price bound without a currency",
+			opts:          ListOptions{MinPrice: 100},
+			expectedError: ErrCurrencyRequired,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			svc := NewService(newTestTable(t, items))
+
+			listed, next, err := svc.List(tc.opts)
+
+			var codes []string
+			for _, item := range listed {
+				codes = append(codes, item.Code)
+			}
+
+			assert.Equal(t, tc.expectedCodes, codes)
+			assert.Equal(t, tc.expectNext, next != "")
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestService_List_Pages(t *testing.T) {
+	t.Run("it should page through every item exactly once", func(t *testing.T) {
+		items := []Item{
+			{Code: "AAAA-0000-0000-0001", Name: "Apple", Price: money.New(100, "USD")},
+			{Code: "AAAA-0000-0000-0002", Name: "Apple", Price: money.New(100, "USD")},
+			{Code: "AAAA-0000-0000-0003", Name: "Apple", Price: money.New(100, "USD")},
+			{Code: "AAAA-0000-0000-0004", Name: "Banana", Price: money.New(50, "USD")},
+			{Code: "AAAA-0000-0000-0005", Name: "Cherry", Price: money.New(100, "USD")},
+		}
+		svc := NewService(newTestTable(t, items))
+
+		for _, sort := range []string{"code", "name", "price"} {
+			var codes []string
+			opts := ListOptions{Sort: sort, Limit: 2}
+			for {
+				page, next, err := svc.List(opts)
+				assert.Nil(t, err)
+
+				for _, item := range page {
+					codes = append(codes, item.Code)
+				}
+
+				if next == "" {
+					break
+				}
+				opts.Cursor = next
+			}
+
+			assert.ElementsMatch(t, []string{"AAAA-0000-0000-0001", "AAAA-0000-0000-0002", "AAAA-0000-0000-0003", "AAAA-0000-0000-0004", "AAAA-0000-0000-0005"}, codes, sort)
+		}
+	})
+}
+
+func TestService_List_CursorMismatch(t *testing.T) {
+	t.Run("it should reject a cursor from a list with another sort or direction", func(t *testing.T) {
+		items := []Item{
+			{Code: "AAAA-0000-0000-0001", Name: "Apple", Price: money.New(100, "USD")},
+			{Code: "AAAA-0000-0000-0002", Name: "Banana", Price: money.New(50, "USD")},
+		}
+		svc := NewService(newTestTable(t, items))
+
+		_, next, err := svc.List(ListOptions{Sort: "name", Limit: 1})
+		assert.Nil(t, err)
+
+		_, _, err = svc.List(ListOptions{Sort: "code", Limit: 1, Cursor: next})
+		assert.Equal(t, ramdb.ErrCursorMismatch, err)
+
+		_, _, err = svc.List(ListOptions{Sort: "name", Descending: true, Limit: 1, Cursor: next})
+		assert.Equal(t, ramdb.ErrCursorMismatch, err)
+	})
+}
@@ -7,4 +7,17 @@ type Item struct {
 	Code  string       `json:"code"`
 	Name  string       `json:"name"`
 	Price *money.Money `json:"price"`
+	// Unit is what Price is per. Items stored without a unit are priced per UnitEach.
+	Unit Unit `json:"unit,omitempty"`
+	// Version is incremented every time the item is changed. It is set on items read from the service, and an update
+	// with a Version only succeeds if the stored item is still at that version.
+	Version uint64 `json:"version,omitempty"`
+}
+
+// ItemPatch holds the fields to change on a produce item. Fields that are nil are left unchanged.
+type ItemPatch struct {
+	Name    *string      `json:"name"`
+	Price   *money.Money `json:"price"`
+	Unit    *Unit        `json:"unit"`
+	Version uint64       `json:"version,omitempty"`
 }
@@ -1,14 +1,17 @@
 package produce
 
 import (
+	"fmt"
 	"strings"
 
+	"github.com/Rhymond/go-money"
 	"github.com/davidlick/supermarket-api/internal/interfaces"
 	"github.com/davidlick/supermarket-api/pkg/ramdb"
 )
 
 type service struct {
-	db interfaces.RamDB
+	db         interfaces.RamDB
+	dependents []Dependent
 }
 
 // NewService creates a new produce service for storing produce items.
@@ -18,31 +21,224 @@ func NewService(db interfaces.RamDB) *service {
 	}
 }
 
-// Add adds the Items to the database.
+// AddDependent registers d so what it stores about produce items is changed and removed along with them. It must be
+// called before the service is used.
+func (s *service) AddDependent(d Dependent) {
+	s.dependents = append(s.dependents, d)
+}
+
+// Add adds the Items to the database in a single transaction. If any Item can't be added, none of them are. It returns
+// ValidationErrors if any of the Items are invalid or share a code.
 func (s *service) Add(items []Item) error {
+	err := validateItems(items)
+	if err != nil {
+		return err
+	}
+
+	tx := s.db.Begin()
 	for _, item := range items {
-		rec, err := ramdb.NewRecord(strings.ToLower(item.Code), KeyProduceCode, item)
+		rec, err := newRecord(item)
 		if err != nil {
+			tx.Rollback()
 			return err
 		}
 
-		err = s.db.Insert(rec)
+		err = tx.Insert(rec)
 		if err != nil {
+			tx.Rollback()
 			return err
 		}
 	}
 
-	return nil
+	return tx.Commit()
+}
+
+// Update replaces the stored item with the same code. If item has a Version, it returns ramdb.ErrVersionMismatch
+// unless the stored item is at that version. It returns ValidationErrors if item is invalid, and the item as stored
+// otherwise.
+func (s *service) Update(item Item) (Item, error) {
+	err := Validate(item)
+	if err != nil {
+		return Item{}, err
+	}
+
+	return s.swap(item.Code, item.Version, func(stored Item) Item {
+		return item
+	})
+}
+
+// Patch changes the fields set in patch on the item with produceCode. If patch has a Version, it returns
+// ramdb.ErrVersionMismatch unless the stored item is at that version. It returns ValidationErrors if the patched item
+// is invalid, and the item as stored otherwise.
+func (s *service) Patch(produceCode string, patch ItemPatch) (Item, error) {
+	return s.swap(produceCode, patch.Version, func(stored Item) Item {
+		if patch.Name != nil {
+			stored.Name = *patch.Name
+		}
+
+		if patch.Price != nil {
+			stored.Price = patch.Price
+		}
+
+		if patch.Unit != nil {
+			stored.Unit = *patch.Unit
+		}
+
+		return stored
+	})
+}
+
+// swap reads the item with produceCode, changes it and writes it back if it has not been changed in between. Without
+// a version, a concurrent change causes the item to be read and changed again, up to maxSwapAttempts times.
+func (s *service) swap(produceCode string, version uint64, change func(stored Item) Item) (Item, error) {
+	for attempt := 0; attempt < maxSwapAttempts; attempt++ {
+		stored, err := s.Get(produceCode)
+		if err != nil {
+			return Item{}, err
+		}
+
+		if version != 0 && stored.Version != version {
+			return Item{}, ramdb.ErrVersionMismatch
+		}
+
+		item := change(stored)
+		item.Unit = item.Unit.orDefault()
+		err = Validate(item)
+		if err != nil {
+			return Item{}, err
+		}
+
+		rec, err := newRecord(item)
+		if err != nil {
+			return Item{}, err
+		}
+
+		err = s.write(func(w writer) error {
+			return w.CompareAndSwap(rec, stored.Version)
+		}, func(d Dependent, tx *ramdb.Tx) error {
+			return d.UpdateProduce(tx, stored, item)
+		})
+		if err == ramdb.ErrVersionMismatch && version == 0 {
+			continue
+		}
+		if err != nil {
+			return Item{}, err
+		}
+
+		item.Version = stored.Version + 1
+		return item, nil
+	}
+
+	return Item{}, ramdb.ErrVersionMismatch
 }
 
-// Remove removes the item from the database.
+// Remove removes the item from the database, along with what every dependent stores about it. A dependent can refuse,
+// such as while the item is still in stock.
 func (s *service) Remove(item Item) error {
+	return s.remove(item, false)
+}
+
+// ForceRemove removes the item from the database, along with what every dependent stores about it, even if a
+// dependent would refuse Remove.
+func (s *service) ForceRemove(item Item) error {
+	return s.remove(item, true)
+}
+
+// remove deletes the item and what every dependent stores about it in one transaction. It is retried up to
+// maxSwapAttempts times if a dependent's data changes or is removed before the transaction commits, and returns
+// ramdb.ErrNoRecord once the item itself is gone.
+func (s *service) remove(item Item, force bool) (err error) {
 	rec, err := ramdb.NewRecord(strings.ToLower(item.Code), KeyProduceCode, item)
 	if err != nil {
 		return err
 	}
 
-	return s.db.Delete(rec)
+	for attempt := 0; attempt < maxSwapAttempts; attempt++ {
+		err = s.write(func(w writer) error {
+			return w.Delete(rec)
+		}, func(d Dependent, tx *ramdb.Tx) error {
+			return d.RemoveProduce(tx, item.Code, force)
+		})
+		if err == ramdb.ErrNoRecord {
+			// Either the item or a dependent's data was removed, and only the item being gone ends the removal.
+			_, err = s.Get(item.Code)
+			if err != nil {
+				return err
+			}
+
+			err = ramdb.ErrNoRecord
+			continue
+		}
+		if err != ramdb.ErrVersionMismatch {
+			return err
+		}
+	}
+
+	return err
+}
+
+// write runs write and the commands depend adds for every dependent in one transaction. Without dependents, write
+// runs straight on the database.
+func (s *service) write(write func(w writer) error, depend func(d Dependent, tx *ramdb.Tx) error) error {
+	if len(s.dependents) == 0 {
+		return write(s.db)
+	}
+
+	tx := s.db.Begin()
+	err := write(tx)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, d := range s.dependents {
+		err = depend(d, tx)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Check adds a check to tx that fails it with ramdb.ErrNoRecord if item has been removed, or ramdb.ErrVersionMismatch
+// if it has been changed, since it was read. Dependents storing data about an item for the first time check it, so
+// nothing is stored about an item that is removed or changed in the meantime.
+func (s *service) Check(tx *ramdb.Tx, item Item) error {
+	rec, err := ramdb.NewRecord(strings.ToLower(item.Code), KeyProduceCode, nil)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Join(tx).Check(rec, item.Version)
+}
+
+// Reindex rewrites every item in one transaction so its record has a key for every column produce is indexed by. Items
+// stored before an index existed have no key for it, so Reindex must be run before the index is created on a table
+// restored from them. It changes the version of every item, but not what dependents store about them.
+func (s *service) Reindex() error {
+	items, err := s.All()
+	if err != nil {
+		return err
+	}
+
+	tx := s.db.Begin()
+	for _, item := range items {
+		rec, err := newRecord(item)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		err = tx.CompareAndSwap(rec, item.Version)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
 
 // Get fetches the produceCode from the database.
@@ -53,9 +249,30 @@ func (s *service) Get(produceCode string) (item Item, err error) {
 	}
 
 	err = rec.Deserialize(&item)
+	item.Version = rec.Version()
 	return
 }
 
+// FindByName returns the produce items named name, ignoring case.
+func (s *service) FindByName(name string) (items []Item, err error) {
+	recs, err := s.db.Lookup(KeyProduceName, strings.ToLower(name))
+	if err != nil {
+		return
+	}
+
+	return deserializeItems(recs)
+}
+
+// ByCodePrefix returns the produce items with codes beginning with prefix, ignoring case, sorted by code.
+func (s *service) ByCodePrefix(prefix string) (items []Item, err error) {
+	recs, err := s.db.Prefix(KeyProduceCode, strings.ToLower(prefix))
+	if err != nil {
+		return
+	}
+
+	return deserializeItems(recs)
+}
+
 // All returns all produce items stored in the database.
 func (s *service) All() (items []Item, err error) {
 	recs, err := s.db.Select(KeyProduceCode)
@@ -63,15 +280,45 @@ func (s *service) All() (items []Item, err error) {
 		return
 	}
 
+	return deserializeItems(recs)
+}
+
+// deserializeItems deserializes each record into an Item.
+func deserializeItems(recs []*ramdb.Record) (items []Item, err error) {
 	for _, rec := range recs {
 		var item Item
 		err = rec.Deserialize(&item)
 		if err != nil {
-			return
+			return nil, err
 		}
 
+		item.Version = rec.Version()
 		items = append(items, item)
 	}
 
 	return
 }
+
+// newRecord returns the Record for storing item, keyed by its code and name ignoring case, by its price and by its name
+// for searching. The item's Version is tracked by the Record, so it is not stored with the item.
+func newRecord(item Item) (*ramdb.Record, error) {
+	item.Unit = item.Unit.orDefault()
+	item.Version = 0
+	rec, err := ramdb.NewRecord(strings.ToLower(item.Code), KeyProduceCode, item)
+	if err != nil {
+		return nil, err
+	}
+
+	rec = rec.WithKey(KeyProduceName, strings.ToLower(item.Name)).WithKey(KeyProducePrice, priceKey(item.Price))
+	return rec.WithKey(KeyProduceSearch, item.Name), nil
+}
+
+// priceKey returns the key price is indexed under. Keys sort by currency and then by amount, so prices in one currency
+// are contiguous in an ordered index. Amounts are zero padded, which relies on prices being positive.
+func priceKey(price *money.Money) string {
+	if price == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%s:%019d", price.Currency().Code, price.Amount())
+}
@@ -2,9 +2,12 @@ package produce
 
 import (
 	"errors"
+	"sort"
+	"strings"
 	"testing"
 
 	"github.com/Rhymond/go-money"
+	"github.com/davidlick/supermarket-api/internal/interfaces"
 	"github.com/davidlick/supermarket-api/internal/mocks"
 	"github.com/davidlick/supermarket-api/pkg/ramdb"
 	"github.com/golang/mock/gomock"
@@ -14,41 +17,44 @@ import (
 func TestService_Add(t *testing.T) {
 	tests := []struct {
 		test          string
-		expectFunc    func(t *testing.T, mockRamDB *mocks.MockRamDB) []Item
+		existing      []Item
+		items         []Item
+		expectedCodes []string
 		expectedError error
 	}{
 		{
 			test: "it should add all Items",
-			expectFunc: func(t *testing.T, mockRamDB *mocks.MockRamDB) []Item {
-				items := []Item{
-					{Code: "code-1", Name: "name-1", Price: money.New(101, "USD")},
-					{Code: "code-2", Name: "name-2", Price: money.New(202, "USD")},
-					{Code: "code-3", Name: "name-3", Price: money.New(303, "USD")},
-					{Code: "code-4", Name: "name-4", Price: money.New(404, "USD")},
-					{Code: "code-5", Name: "name-5", Price: money.New(505, "USD")},
-				}
-
-				for _, item := range items {
-					rec, err := ramdb.NewRecord(item.Code, KeyProduceCode, item)
-					if err != nil {
-						t.Error(err)
-					}
-
-					mockRamDB.EXPECT().Insert(rec).Return(nil)
-				}
-
-				return items
+			items: []Item{
+				{Code: "code-0000-0000-0001", Name: "name-1", Price: money.New(101, "USD")},
+				{Code: "code-0000-0000-0002", Name: "name-2", Price: money.New(202, "USD")},
+				{Code: "code-0000-0000-0003", Name: "name-3", Price: money.New(303, "USD")},
+				{Code: "code-0000-0000-0004", Name: "name-4", Price: money.New(404, "USD")},
+				{Code: "code-0000-0000-0005", Name: "name-5", Price: money.New(505, "USD")},
 			},
+			expectedCodes: []string{"code-0000-0000-0001", "code-0000-0000-0002", "code-0000-0000-0003", "code-0000-0000-0004", "code-0000-0000-0005"},
 		},
 		{
-			test: "it should return an error from ramdb",
-			expectFunc: func(t *testing.T, mockRamDB *mocks.MockRamDB) []Item {
-				mockRamDB.EXPECT().Insert(gomock.Any()).Return(errors.New("test error"))
-				return []Item{
-					{Code: "code-1", Name: "name-1", Price: money.New(101, "USD")},
-				}
+			test: "it should add no Items if any of them fail",
+			existing: []Item{
+				{Code: "code-0000-0000-0003", Name: "name-3", Price: money.New(303, "USD")},
+			},
+			items: []Item{
+				{Code: "code-0000-0000-0001", Name: "name-1", Price: money.New(101, "USD")},
+				{Code: "code-0000-0000-0002", Name: "name-2", Price: money.New(202, "USD")},
+				{Code: "code-0000-0000-0003", Name: "name-3", Price: money.New(303, "USD")},
+			},
+			expectedCodes: []string{"code-0000-0000-0003"},
+			expectedError: ramdb.ErrRecordExists,
+		},
+		{
+			test: "it should add no Items if any of them are invalid",
+			items: []Item{
+				{Code: "code-0000-0000-0001", Name: "name-1", Price: money.New(101, "USD")},
+				{Name: "name-2", Price: money.New(202, "USD")},
+			},
+			expectedError: ValidationErrors{
+				{Index: 1, Field: "code", Message: "must be four groups of four letters or digits separated by dashes"},
 			},
-			expectedError: errors.New("test error"),
 		},
 	}
 
@@ -57,41 +63,43 @@ func TestService_Add(t *testing.T) {
 			ctrl := gomock.NewController(t)
 			defer ctrl.Finish()
 
+			tbl := newTestTable(t, tc.existing)
 			mockRamDB := mocks.NewMockRamDB(ctrl)
-
-			items := tc.expectFunc(t, mockRamDB)
+			mockRamDB.EXPECT().Begin().Return(tbl.Begin()).MaxTimes(1)
 
 			svc := NewService(mockRamDB)
-			err := svc.Add(items)
+			err := svc.Add(tc.items)
 
 			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedCodes, storedCodes(t, tbl))
 		})
 	}
 }
 
 func TestService_Add_Lowercased(t *testing.T) {
-	t.Run("it should store produce codes lowercased", func(t *testing.T) {
+	t.Run("it should store produce codes and names lowercased", func(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 
 		item := Item{
-			Code:  "TEST_CODE",
+			Code:  "TEST-CODE-0000-0001",
 			Name:  "Name",
 			Price: money.New(101, "USD"),
 		}
 
+		tbl := newTestTable(t, nil)
 		mockRamDB := mocks.NewMockRamDB(ctrl)
-		rec, err := ramdb.NewRecord("test_code", KeyProduceCode, item)
-		if err != nil {
-			t.Error(err)
-		}
-
-		mockRamDB.EXPECT().Insert(rec).Return(nil)
+		mockRamDB.EXPECT().Begin().Return(tbl.Begin())
 
 		svc := NewService(mockRamDB)
-		err = svc.Add([]Item{item})
+		err := svc.Add([]Item{item})
 
 		assert.Nil(t, err)
+
+		_, err = tbl.Get(KeyProduceCode, "test-code-0000-0001")
+		assert.Nil(t, err)
+		_, err = tbl.Get(KeyProduceName, "name")
+		assert.Nil(t, err)
 	})
 }
 
@@ -104,7 +112,7 @@ func TestService_Remove(t *testing.T) {
 		{
 			test: "it should successfully remove an item",
 			expectFunc: func(t *testing.T, mockRamDB *mocks.MockRamDB) Item {
-				item := Item{Code: "code-1", Name: "name-1", Price: money.New(101, "USD")}
+				item := Item{Code: "code-0000-0000-0001", Name: "name-1", Price: money.New(101, "USD")}
 				rec, err := ramdb.NewRecord(item.Code, KeyProduceCode, item)
 				if err != nil {
 					t.Error(err)
@@ -141,6 +149,181 @@ func TestService_Remove(t *testing.T) {
 	}
 }
 
+// testDependent is a Dependent that records a copy of each item in its own table.
+type testDependent struct {
+	db  interfaces.RamDB
+	err error
+}
+
+// UpdateProduce upserts the copy of item, or returns the dependent's err.
+func (d testDependent) UpdateProduce(tx *ramdb.Tx, stored, item Item) error {
+	if d.err != nil {
+		return d.err
+	}
+
+	rec, err := newRecord(item)
+	if err != nil {
+		return err
+	}
+
+	return d.db.Join(tx).Upsert(rec)
+}
+
+// RemoveProduce deletes the copy of the item with produceCode, or returns the dependent's err unless force is true.
+func (d testDependent) RemoveProduce(tx *ramdb.Tx, produceCode string, force bool) error {
+	if d.err != nil && !force {
+		return d.err
+	}
+
+	rec, err := ramdb.NewRecord(strings.ToLower(produceCode), KeyProduceCode, nil)
+	if err != nil {
+		return err
+	}
+
+	return d.db.Join(tx).Delete(rec)
+}
+
+func TestService_Dependents(t *testing.T) {
+	lettuce := Item{Code: "A12T-4GH7-QPL9-3N4M", Name: "Lettuce", Price: money.New(346, "USD")}
+
+	tests := []struct {
+		test          string
+		dependentErr  error
+		write         func(svc *service) error
+		expectedName  string
+		expectedError error
+	}{
+		{
+			test: "it should change the dependent's data with the item",
+			write: func(svc *service) error {
+				name := "Iceberg Lettuce"
+				_, err := svc.Patch(lettuce.Code, ItemPatch{Name: &name})
+				return err
+			},
+			expectedName: "Iceberg Lettuce",
+		},
+		{
+			test:         "it should leave the item unchanged if the dependent refuses the change",
+			dependentErr: errors.New("test error"),
+			write: func(svc *service) error {
+				name := "Iceberg Lettuce"
+				_, err := svc.Patch(lettuce.Code, ItemPatch{Name: &name})
+				return err
+			},
+			expectedName:  "Lettuce",
+			expectedError: errors.New("test error"),
+		},
+		{
+			test: "it should remove the dependent's data with the item",
+			write: func(svc *service) error {
+				return svc.Remove(lettuce)
+			},
+		},
+		{
+			test:         "it should keep the item if the dependent refuses its removal",
+			dependentErr: errors.New("test error"),
+			write: func(svc *service) error {
+				return svc.Remove(lettuce)
+			},
+			expectedName:  "Lettuce",
+			expectedError: errors.New("test error"),
+		},
+		{
+			test:         "it should remove the item when forced even if the dependent would refuse",
+			dependentErr: errors.New("test error"),
+			write: func(svc *service) error {
+				return svc.ForceRemove(lettuce)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			db := ramdb.NewDatabase()
+			for _, table := range []string{"produce", "copies"} {
+				assert.Nil(t, db.CreateTable(table, KeyProduceCode))
+			}
+
+			svc := NewService(db.From("produce"))
+			assert.Nil(t, svc.Add([]Item{lettuce}))
+			rec, err := newRecord(lettuce)
+			assert.Nil(t, err)
+			assert.Nil(t, db.From("copies").Insert(rec))
+
+			svc.AddDependent(testDependent{db: db.From("copies"), err: tc.dependentErr})
+
+			err = tc.write(svc)
+			assert.Equal(t, tc.expectedError, err)
+
+			for _, table := range []string{"produce", "copies"} {
+				rec, err := db.From(table).Get(KeyProduceCode, strings.ToLower(lettuce.Code))
+				if tc.expectedName == "" {
+					assert.Equal(t, ramdb.ErrNoRecord, err, table)
+					continue
+				}
+
+				var item Item
+				assert.Nil(t, err, table)
+				assert.Nil(t, rec.Deserialize(&item))
+				assert.Equal(t, tc.expectedName, item.Name, table)
+			}
+		})
+	}
+}
+
+func TestService_Check(t *testing.T) {
+	lettuce := Item{Code: "A12T-4GH7-QPL9-3N4M", Name: "Lettuce", Price: money.New(346, "USD")}
+
+	tests := []struct {
+		test          string
+		write         func(svc *service) error
+		expectedError error
+	}{
+		{
+			test:  "it should commit if the item is unchanged",
+			write: func(svc *service) error { return nil },
+		},
+		{
+			test: "it should return ramdb.ErrVersionMismatch if the item was changed",
+			write: func(svc *service) error {
+				_, err := svc.Patch(lettuce.Code, ItemPatch{Price: money.New(299, "USD")})
+				return err
+			},
+			expectedError: ramdb.ErrVersionMismatch,
+		},
+		{
+			test: "it should return ramdb.ErrNoRecord if the item was removed",
+			write: func(svc *service) error {
+				return svc.Remove(lettuce)
+			},
+			expectedError: ramdb.ErrNoRecord,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			db := ramdb.NewDatabase()
+			for _, table := range []string{"produce", "copies"} {
+				assert.Nil(t, db.CreateTable(table, KeyProduceCode))
+			}
+
+			svc := NewService(db.From("produce"))
+			assert.Nil(t, svc.Add([]Item{lettuce}))
+			item, err := svc.Get(lettuce.Code)
+			assert.Nil(t, err)
+
+			assert.Nil(t, tc.write(svc))
+
+			rec, err := newRecord(item)
+			assert.Nil(t, err)
+			tx := db.From("copies").Begin()
+			assert.Nil(t, tx.Insert(rec))
+			assert.Nil(t, svc.Check(tx, item))
+			assert.Equal(t, tc.expectedError, tx.Commit())
+		})
+	}
+}
+
 func TestService_Get(t *testing.T) {
 	tests := []struct {
 		test          string
@@ -218,6 +401,118 @@ func TestService_Get_CaseInsensitive(t *testing.T) {
 	})
 }
 
+func TestService_FindByName(t *testing.T) {
+	tests := []struct {
+		test          string
+		expectFunc    func(t *testing.T, mockRamDB *mocks.MockRamDB) []Item
+		expectedError error
+	}{
+		{
+			test: "it should return every item with the name",
+			expectFunc: func(t *testing.T, mockRamDB *mocks.MockRamDB) []Item {
+				items := []Item{
+					{Code: "code-0000-0000-0001", Name: "Gala Apple", Price: money.New(101, "USD")},
+					{Code: "code-0000-0000-0002", Name: "Gala Apple", Price: money.New(202, "USD")},
+				}
+
+				var recs []*ramdb.Record
+				for _, item := range items {
+					rec, err := ramdb.NewRecord(item.Code, KeyProduceCode, item)
+					if err != nil {
+						t.Error(err)
+					}
+
+					recs = append(recs, rec)
+				}
+
+				mockRamDB.EXPECT().Lookup(KeyProduceName, "gala apple").Return(recs, nil)
+				return items
+			},
+		},
+		{
+			test: "it should return an error from ramdb",
+			expectFunc: func(t *testing.T, mockRamDB *mocks.MockRamDB) []Item {
+				mockRamDB.EXPECT().Lookup(KeyProduceName, "gala apple").Return(nil, ramdb.ErrNoRecord)
+				return nil
+			},
+			expectedError: ramdb.ErrNoRecord,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRamDB := mocks.NewMockRamDB(ctrl)
+
+			expectedItems := tc.expectFunc(t, mockRamDB)
+
+			svc := NewService(mockRamDB)
+			items, err := svc.FindByName("Gala Apple")
+
+			assert.Equal(t, expectedItems, items)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestService_ByCodePrefix(t *testing.T) {
+	tests := []struct {
+		test          string
+		expectFunc    func(t *testing.T, mockRamDB *mocks.MockRamDB) []Item
+		expectedError error
+	}{
+		{
+			test: "it should return items with codes beginning with the lowercased prefix",
+			expectFunc: func(t *testing.T, mockRamDB *mocks.MockRamDB) []Item {
+				items := []Item{
+					{Code: "A12T-0001", Name: "name-1", Price: money.New(101, "USD")},
+					{Code: "A12T-0002", Name: "name-2", Price: money.New(202, "USD")},
+				}
+
+				var recs []*ramdb.Record
+				for _, item := range items {
+					rec, err := ramdb.NewRecord(item.Code, KeyProduceCode, item)
+					if err != nil {
+						t.Error(err)
+					}
+
+					recs = append(recs, rec)
+				}
+
+				mockRamDB.EXPECT().Prefix(KeyProduceCode, "a12t-").Return(recs, nil)
+				return items
+			},
+		},
+		{
+			test: "it should return an error from ramdb",
+			expectFunc: func(t *testing.T, mockRamDB *mocks.MockRamDB) []Item {
+				mockRamDB.EXPECT().Prefix(KeyProduceCode, "a12t-").Return(nil, errors.New("test error"))
+				return nil
+			},
+			expectedError: errors.New("test error"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRamDB := mocks.NewMockRamDB(ctrl)
+
+			expectedItems := tc.expectFunc(t, mockRamDB)
+
+			svc := NewService(mockRamDB)
+			items, err := svc.ByCodePrefix("A12T-")
+
+			assert.Equal(t, expectedItems, items)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
 func TestService_All(t *testing.T) {
 	tests := []struct {
 		test          string
@@ -228,11 +523,11 @@ func TestService_All(t *testing.T) {
 			test: "it should return all items",
 			expectFunc: func(t *testing.T, mockRamDB *mocks.MockRamDB) []Item {
 				items := []Item{
-					{Code: "code-1", Name: "name-1", Price: money.New(101, "USD")},
-					{Code: "code-2", Name: "name-2", Price: money.New(202, "USD")},
-					{Code: "code-3", Name: "name-3", Price: money.New(303, "USD")},
-					{Code: "code-4", Name: "name-4", Price: money.New(404, "USD")},
-					{Code: "code-5", Name: "name-5", Price: money.New(505, "USD")},
+					{Code: "code-0000-0000-0001", Name: "name-1", Price: money.New(101, "USD")},
+					{Code: "code-0000-0000-0002", Name: "name-2", Price: money.New(202, "USD")},
+					{Code: "code-0000-0000-0003", Name: "name-3", Price: money.New(303, "USD")},
+					{Code: "code-0000-0000-0004", Name: "name-4", Price: money.New(404, "USD")},
+					{Code: "code-0000-0000-0005", Name: "name-5", Price: money.New(505, "USD")},
 				}
 
 				var recs []*ramdb.Record
@@ -277,3 +572,238 @@ func TestService_All(t *testing.T) {
 		})
 	}
 }
+
+// newTestTable returns a produce table indexed the same way as in cmd/api holding items.
+func newTestTable(t *testing.T, items []Item) interfaces.RamDB {
+	db := ramdb.NewDatabase()
+	err := db.CreateTable("produce")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tbl := db.From("produce")
+	for _, column := range []string{KeyProduceCode, KeyProduceName, KeyProducePrice} {
+		err = tbl.CreateOrderedIndex(column)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	err = tbl.CreateTextIndex(KeyProduceSearch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, item := range items {
+		rec, err := newRecord(item)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = tbl.Insert(rec)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return tbl
+}
+
+// storedCodes returns the sorted codes of every item in tbl.
+func storedCodes(t *testing.T, tbl interfaces.RamDB) (codes []string) {
+	recs, err := tbl.Select(KeyProduceCode)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, rec := range recs {
+		var item Item
+		err = rec.Deserialize(&item)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		codes = append(codes, item.Code)
+	}
+
+	sort.Strings(codes)
+	return
+}
+
+func TestService_Update(t *testing.T) {
+	tests := []struct {
+		test          string
+		item          Item
+		expectedItem  Item
+		expectedError error
+	}{
+		{
+			test:         "it should replace the item and return it with its new version",
+			item:         Item{Code: "CODE-0000-0000-0001", Name: "Red Apple", Price: money.New(150, "USD")},
+			expectedItem: Item{Code: "CODE-0000-0000-0001", Name: "Red Apple", Price: money.New(150, "USD"), Unit: UnitEach, Version: 2},
+		},
+		{
+			test:         "it should replace the item if the version matches",
+			item:         Item{Code: "code-0000-0000-0001", Name: "Red Apple", Price: money.New(150, "USD"), Version: 1},
+			expectedItem: Item{Code: "code-0000-0000-0001", Name: "Red Apple", Price: money.New(150, "USD"), Unit: UnitEach, Version: 2},
+		},
+		{
+			test:          "it should return ErrVersionMismatch if the version does not match",
+			item:          Item{Code: "code-0000-0000-0001", Name: "Red Apple", Price: money.New(150, "USD"), Version: 3},
+			expectedError: ramdb.ErrVersionMismatch,
+		},
+		{
+			test:          "it should return ErrNoRecord if the item does not exist",
+			item:          Item{Code: "code-0000-0000-0002", Name: "Red Apple", Price: money.New(150, "USD")},
+			expectedError: ramdb.ErrNoRecord,
+		},
+		{
+			test: "it should return ValidationErrors if the item is invalid",
+			item: Item{Code: "code-0000-0000-0001", Name: "Red Apple"},
+			expectedError: ValidationErrors{
+				{Code: "code-0000-0000-0001", Field: "price", Message: "is required"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			tbl := newTestTable(t, []Item{{Code: "code-0000-0000-0001", Name: "apple", Price: money.New(101, "USD")}})
+
+			svc := NewService(tbl)
+			item, err := svc.Update(tc.item)
+
+			assert.Equal(t, tc.expectedItem, item)
+			assert.Equal(t, tc.expectedError, err)
+
+			if tc.expectedError == nil {
+				stored, err := svc.Get(tc.item.Code)
+				assert.Nil(t, err)
+				assert.Equal(t, tc.expectedItem, stored)
+
+				found, err := svc.FindByName("red apple")
+				assert.Nil(t, err)
+				assert.Len(t, found, 1)
+			}
+		})
+	}
+}
+
+func TestService_Patch(t *testing.T) {
+	name := "Green Apple"
+	unit := UnitKilogram
+
+	tests := []struct {
+		test          string
+		patch         ItemPatch
+		expectedItem  Item
+		expectedError error
+	}{
+		{
+			test:         "it should change only the name",
+			patch:        ItemPatch{Name: &name},
+			expectedItem: Item{Code: "code-0000-0000-0001", Name: "Green Apple", Price: money.New(101, "USD"), Unit: UnitEach, Version: 2},
+		},
+		{
+			test:         "it should change only the price",
+			patch:        ItemPatch{Price: money.New(99, "USD"), Version: 1},
+			expectedItem: Item{Code: "code-0000-0000-0001", Name: "apple", Price: money.New(99, "USD"), Unit: UnitEach, Version: 2},
+		},
+		{
+			test:         "it should change only the unit",
+			patch:        ItemPatch{Unit: &unit},
+			expectedItem: Item{Code: "code-0000-0000-0001", Name: "apple", Price: money.New(101, "USD"), Unit: UnitKilogram, Version: 2},
+		},
+		{
+			test:          "it should return ErrVersionMismatch if the version does not match",
+			patch:         ItemPatch{Name: &name, Version: 2},
+			expectedError: ramdb.ErrVersionMismatch,
+		},
+		{
+			test:  "it should return ValidationErrors if the patched item is invalid",
+			patch: ItemPatch{Price: money.New(-5, "USD")},
+			expectedError: ValidationErrors{
+				{Code: "code-0000-0000-0001", Field: "price.amount", Message: "must be positive"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			tbl := newTestTable(t, []Item{{Code: "code-0000-0000-0001", Name: "apple", Price: money.New(101, "USD")}})
+
+			svc := NewService(tbl)
+			item, err := svc.Patch("CODE-0000-0000-0001", tc.patch)
+
+			assert.Equal(t, tc.expectedItem, item)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestService_Patch_Retry(t *testing.T) {
+	tests := []struct {
+		test          string
+		version       uint64
+		expectFunc    func(t *testing.T, mockRamDB *mocks.MockRamDB)
+		expectedError error
+	}{
+		{
+			test: "it should read the item again if it changed before it was written",
+			expectFunc: func(t *testing.T, mockRamDB *mocks.MockRamDB) {
+				rec, err := ramdb.NewRecord("code-0000-0000-0001", KeyProduceCode, Item{Code: "code-0000-0000-0001", Name: "apple", Price: money.New(101, "USD")})
+				if err != nil {
+					t.Error(err)
+				}
+
+				mockRamDB.EXPECT().Get(KeyProduceCode, "code-0000-0000-0001").Return(rec, nil).Times(2)
+				gomock.InOrder(
+					mockRamDB.EXPECT().CompareAndSwap(gomock.Any(), uint64(0)).Return(ramdb.ErrVersionMismatch),
+					mockRamDB.EXPECT().CompareAndSwap(gomock.Any(), uint64(0)).Return(nil),
+				)
+			},
+		},
+		{
+			test:    "it should not retry if a version was given",
+			version: 1,
+			expectFunc: func(t *testing.T, mockRamDB *mocks.MockRamDB) {
+				rec, err := ramdb.NewRecord("code-0000-0000-0001", KeyProduceCode, Item{Code: "code-0000-0000-0001", Name: "apple", Price: money.New(101, "USD")})
+				if err != nil {
+					t.Error(err)
+				}
+
+				mockRamDB.EXPECT().Get(KeyProduceCode, "code-0000-0000-0001").Return(rec, nil)
+			},
+			expectedError: ramdb.ErrVersionMismatch,
+		},
+		{
+			test: "it should give up after maxSwapAttempts",
+			expectFunc: func(t *testing.T, mockRamDB *mocks.MockRamDB) {
+				rec, err := ramdb.NewRecord("code-0000-0000-0001", KeyProduceCode, Item{Code: "code-0000-0000-0001", Name: "apple", Price: money.New(101, "USD")})
+				if err != nil {
+					t.Error(err)
+				}
+
+				mockRamDB.EXPECT().Get(KeyProduceCode, "code-0000-0000-0001").Return(rec, nil).Times(maxSwapAttempts)
+				mockRamDB.EXPECT().CompareAndSwap(gomock.Any(), uint64(0)).Return(ramdb.ErrVersionMismatch).Times(maxSwapAttempts)
+			},
+			expectedError: ramdb.ErrVersionMismatch,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRamDB := mocks.NewMockRamDB(ctrl)
+			tc.expectFunc(t, mockRamDB)
+
+			name := "green apple"
+			svc := NewService(mockRamDB)
+			_, err := svc.Patch("code-0000-0000-0001", ItemPatch{Name: &name, Version: tc.version})
+
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
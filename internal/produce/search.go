@@ -0,0 +1,33 @@
+package produce
+
+import "github.com/davidlick/supermarket-api/pkg/ramdb"
+
+// Search returns up to limit produce items with names similar to query, most relevant first. Case is ignored, items
+// with words beginning with each word of query rank highest, and misspelt names still match. A limit of 0 returns
+// DefaultSearchLimit items, and it is capped at MaxListLimit.
+func (s *service) Search(query string, limit int) (items []Item, err error) {
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	if limit > MaxListLimit {
+		limit = MaxListLimit
+	}
+
+	results, err := s.db.Search(KeyProduceSearch, query, ramdb.SearchOptions{Limit: limit})
+	if err != nil {
+		return
+	}
+
+	for _, result := range results {
+		var item Item
+		err = result.Record.Deserialize(&item)
+		if err != nil {
+			return nil, err
+		}
+
+		item.Version = result.Record.Version()
+		items = append(items, item)
+	}
+
+	return
+}
@@ -0,0 +1,118 @@
+package produce
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/Rhymond/go-money"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestService_Search(t *testing.T) {
+	items := []Item{
+		{Code: "A12T-4GH7-QPL9-3N4M", Name: "Lettuce", Price: money.New(346, "USD")},
+		{Code: "E5T6-9UI3-TH15-QR88", Name: "Peach", Price: money.New(299, "USD")},
+		{Code: "YRT6-72AS-K736-L4AR", Name: "Green Pepper", Price: money.New(79, "USD")},
+		{Code: "TQ4C-VV6T-75ZX-1RMR", Name: "Gala Apple", Price: money.New(359, "USD")},
+		{Code: "A12T-0000-0000-0001", Name: "Red Pepper", Price: money.New(120, "EUR")},
+	}
+
+	tests := []struct {
+		test          string
+		query         string
+		limit         int
+		expectedCodes []string
+	}{
+		{
+			test:          "it should find items by the beginning of a word ignoring case",
+			query:         "PEPP",
+			expectedCodes: []string{"A12T-0000-0000-0001", "YRT6-72AS-K736-L4AR"},
+		},
+		{
+			test:          "it should rank the closest name first",
+			query:         "red pepper",
+			expectedCodes: []string{"A12T-0000-0000-0001", "YRT6-72AS-K736-L4AR"},
+		},
+		{
+			test:          "it should tolerate typos",
+			query:         "letuce",
+			expectedCodes: []string{"A12T-4GH7-QPL9-3N4M"},
+		},
+		{
+			test:          "it should return at most limit items",
+			query:         "pepper",
+			limit:         1,
+			expectedCodes: []string{"A12T-0000-0000-0001"},
+		},
+		{
+			test:          "it should return nothing if no name is similar",
+			query:         "zucchini",
+			expectedCodes: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			svc := NewService(newTestTable(t, items))
+
+			found, err := svc.Search(tc.query, tc.limit)
+			assert.Nil(t, err)
+
+			var codes []string
+			for _, item := range found {
+				codes = append(codes, item.Code)
+				assert.Equal(t, uint64(1), item.Version)
+			}
+
+			assert.Equal(t, tc.expectedCodes, codes)
+		})
+	}
+}
+
+func TestService_Reindex(t *testing.T) {
+	t.Run("it should make produce restored from a snapshot made before search existed searchable", func(t *testing.T) {
+		db := ramdb.NewDatabase()
+		assert.Nil(t, db.CreateTable("produce"))
+		for _, column := range []string{KeyProduceCode, KeyProduceName, KeyProducePrice} {
+			assert.Nil(t, db.From("produce").CreateOrderedIndex(column))
+		}
+
+		// Items were stored without a key for the search index before it existed.
+		for _, item := range []Item{
+			{Code: "A12T-4GH7-QPL9-3N4M", Name: "Lettuce", Price: money.New(346, "USD"), Unit: UnitEach},
+			{Code: "YRT6-72AS-K736-L4AR", Name: "Green Pepper", Price: money.New(79, "USD"), Unit: UnitEach},
+		} {
+			rec, err := ramdb.NewRecord(strings.ToLower(item.Code), KeyProduceCode, item)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			rec = rec.WithKey(KeyProduceName, strings.ToLower(item.Name)).WithKey(KeyProducePrice, priceKey(item.Price))
+			assert.Nil(t, db.From("produce").Insert(rec))
+		}
+
+		var buf bytes.Buffer
+		assert.Nil(t, db.Snapshot(&buf))
+		restored, err := ramdb.LoadSnapshot(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		svc := NewService(restored.From("produce"))
+		assert.Nil(t, svc.Reindex())
+		assert.Nil(t, restored.From("produce").CreateTextIndex(KeyProduceSearch))
+
+		found, err := svc.Search("letuce", 0)
+		assert.Nil(t, err)
+		assert.Len(t, found, 1)
+		assert.Equal(t, "A12T-4GH7-QPL9-3N4M", found[0].Code)
+		assert.Equal(t, uint64(2), found[0].Version)
+
+		found, err = svc.Search("pepper", 0)
+		assert.Nil(t, err)
+		assert.Len(t, found, 1)
+		assert.Equal(t, "YRT6-72AS-K736-L4AR", found[0].Code)
+	})
+}
@@ -0,0 +1,140 @@
+package produce
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/Rhymond/go-money"
+)
+
+// Unit is what the price of a produce item is per.
+type Unit string
+
+const (
+	UnitEach     Unit = "each"
+	UnitBunch    Unit = "bunch"
+	UnitPound    Unit = "lb"
+	UnitKilogram Unit = "kg"
+)
+
+// SupportedUnits are the units produce can be priced per. Items without a unit are priced per UnitEach.
+var SupportedUnits = map[Unit]bool{
+	UnitEach:     true,
+	UnitBunch:    true,
+	UnitPound:    true,
+	UnitKilogram: true,
+}
+
+// kilogramsPerPound is the exact number of kilograms in an international pound.
+var kilogramsPerPound = big.NewRat(45359237, 100000000)
+
+// Weighed returns true if produce priced per the unit is sold by weight, in any fraction of the unit.
+func (u Unit) Weighed() bool {
+	return u == UnitPound || u == UnitKilogram
+}
+
+// QuantityScale is the number of Quantity units in one whole unit, so quantities are exact to three decimal places.
+const QuantityScale = 1000
+
+// Quantity is an amount of a produce item in thousandths of its Unit. It is written in JSON as a decimal number, such
+// as 1.25, and must have at most three decimal places.
+type Quantity int64
+
+// NewQuantity returns the Quantity of n whole units.
+func NewQuantity(n int64) Quantity {
+	return Quantity(n * QuantityScale)
+}
+
+// IsWhole returns true if q is a whole number of units.
+func (q Quantity) IsWhole() bool {
+	return q%QuantityScale == 0
+}
+
+// Whole returns the number of whole units in q, rounded down.
+func (q Quantity) Whole() int64 {
+	return int64(q) / QuantityScale
+}
+
+// String returns q as a decimal without trailing zeros.
+func (q Quantity) String() string {
+	s := new(big.Rat).SetFrac64(int64(q), QuantityScale).FloatString(3)
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
+
+// MarshalJSON writes q as a decimal number.
+func (q Quantity) MarshalJSON() ([]byte, error) {
+	return []byte(q.String()), nil
+}
+
+// UnmarshalJSON reads q from a decimal number, which is parsed exactly rather than as a float.
+func (q *Quantity) UnmarshalJSON(b []byte) error {
+	r, ok := new(big.Rat).SetString(string(b))
+	if !ok {
+		return fmt.Errorf("quantity %s is not a number", b)
+	}
+
+	r.Mul(r, big.NewRat(QuantityScale, 1))
+	if !r.IsInt() || !r.Num().IsInt64() {
+		return fmt.Errorf("quantity %s must have at most three decimal places", b)
+	}
+
+	*q = Quantity(r.Num().Int64())
+	return nil
+}
+
+// ConvertQuantity converts q from one unit to another, rounding half up to the nearest thousandth. Only pounds and
+// kilograms can be converted between, and converting a unit to itself returns q unchanged. It returns
+// ErrIncompatibleUnits for any other conversion.
+func ConvertQuantity(q Quantity, from, to Unit) (Quantity, error) {
+	from, to = from.orDefault(), to.orDefault()
+	if from == to {
+		return q, nil
+	}
+
+	var factor *big.Rat
+	switch {
+	case from == UnitPound && to == UnitKilogram:
+		factor = kilogramsPerPound
+	case from == UnitKilogram && to == UnitPound:
+		factor = new(big.Rat).Inv(kilogramsPerPound)
+	default:
+		return 0, fmt.Errorf("%w: %s to %s", ErrIncompatibleUnits, from, to)
+	}
+
+	r := new(big.Rat).Mul(new(big.Rat).SetInt64(int64(q)), factor)
+	return Quantity(RoundHalfUp(r)), nil
+}
+
+// LineTotal returns the price of q units priced at price per unit, rounded half up to the currency's minor unit.
+func LineTotal(price *money.Money, q Quantity) *money.Money {
+	r := new(big.Rat).SetFrac64(price.Amount(), QuantityScale)
+	r.Mul(r, new(big.Rat).SetInt64(int64(q)))
+	return money.New(RoundHalfUp(r), price.Currency().Code)
+}
+
+// orDefault returns u, or UnitEach if u is empty.
+func (u Unit) orDefault() Unit {
+	if u == "" {
+		return UnitEach
+	}
+
+	return u
+}
+
+// RoundHalfUp returns r rounded to the nearest integer, with halves rounded away from zero. Amounts of money are
+// rounded to whole minor units with it.
+func RoundHalfUp(r *big.Rat) int64 {
+	quo, rem := new(big.Int).QuoRem(r.Num(), r.Denom(), new(big.Int))
+
+	rem.Abs(rem).Lsh(rem, 1)
+	if rem.Cmp(r.Denom()) >= 0 {
+		if r.Sign() < 0 {
+			quo.Sub(quo, big.NewInt(1))
+		} else {
+			quo.Add(quo, big.NewInt(1))
+		}
+	}
+
+	return quo.Int64()
+}
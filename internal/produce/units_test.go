@@ -0,0 +1,151 @@
+package produce
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Rhymond/go-money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuantity_UnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		test             string
+		json             string
+		expectedQuantity Quantity
+		expectError      bool
+	}{
+		{
+			test:             "it should read whole numbers",
+			json:             `2`,
+			expectedQuantity: 2000,
+		},
+		{
+			test:             "it should read decimals exactly",
+			json:             `1.255`,
+			expectedQuantity: 1255,
+		},
+		{
+			test:        "it should reject more than three decimal places",
+			json:        `1.2555`,
+			expectError: true,
+		},
+		{
+			test:        "it should reject values that aren't numbers",
+			json:        `"two"`,
+			expectError: true,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			var q Quantity
+			err := json.Unmarshal([]byte(tc.json), &q)
+
+			assert.Equal(t, tc.expectError, err != nil)
+			assert.Equal(t, tc.expectedQuantity, q)
+		})
+	}
+}
+
+func TestQuantity_MarshalJSON(t *testing.T) {
+	t.Run("it should write quantities as decimals without trailing zeros", func(t *testing.T) {
+		b, err := json.Marshal([]Quantity{2000, 1250, 5})
+
+		assert.Nil(t, err)
+		assert.Equal(t, `[2,1.25,0.005]`, string(b))
+	})
+}
+
+func TestConvertQuantity(t *testing.T) {
+	tests := []struct {
+		test             string
+		quantity         Quantity
+		from             Unit
+		to               Unit
+		expectedQuantity Quantity
+		expectedError    error
+	}{
+		{
+			test:             "it should convert pounds to kilograms",
+			quantity:         NewQuantity(1),
+			from:             UnitPound,
+			to:               UnitKilogram,
+			expectedQuantity: 454,
+		},
+		{
+			test:             "it should convert kilograms to pounds",
+			quantity:         NewQuantity(1),
+			from:             UnitKilogram,
+			to:               UnitPound,
+			expectedQuantity: 2205,
+		},
+		{
+			test:             "it should leave quantities in the same unit unchanged",
+			quantity:         1234,
+			from:             UnitKilogram,
+			to:               UnitKilogram,
+			expectedQuantity: 1234,
+		},
+		{
+			test:             "it should treat a missing unit as each",
+			quantity:         NewQuantity(3),
+			from:             "",
+			to:               UnitEach,
+			expectedQuantity: NewQuantity(3),
+		},
+		{
+			test:          "it should return ErrIncompatibleUnits for units that aren't weights",
+			quantity:      NewQuantity(1),
+			from:          UnitEach,
+			to:            UnitKilogram,
+			expectedError: ErrIncompatibleUnits,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			q, err := ConvertQuantity(tc.quantity, tc.from, tc.to)
+
+			assert.True(t, errors.Is(err, tc.expectedError), "expected %v, got %v", tc.expectedError, err)
+			assert.Equal(t, tc.expectedQuantity, q)
+		})
+	}
+}
+
+func TestLineTotal(t *testing.T) {
+	tests := []struct {
+		test          string
+		price         *money.Money
+		quantity      Quantity
+		expectedTotal *money.Money
+	}{
+		{
+			test:          "it should multiply the price by whole quantities",
+			price:         money.New(299, "USD"),
+			quantity:      NewQuantity(3),
+			expectedTotal: money.New(897, "USD"),
+		},
+		{
+			test:     "it should round fractional totals half up",
+			price:    money.New(299, "USD"),
+			quantity: 1250,
+			// 299 * 1.25 = 373.75.
+			expectedTotal: money.New(374, "USD"),
+		},
+		{
+			test:     "it should round fractional totals below a half down",
+			price:    money.New(199, "EUR"),
+			quantity: 333,
+			// 199 * 0.333 = 66.267.
+			expectedTotal: money.New(66, "EUR"),
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			assert.Equal(t, tc.expectedTotal, LineTotal(tc.price, tc.quantity))
+		})
+	}
+}
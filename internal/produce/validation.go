@@ -0,0 +1,232 @@
+package produce
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/Rhymond/go-money"
+)
+
+// codePattern matches produce codes: four groups of four letters or digits separated by dashes.
+var codePattern = regexp.MustCompile(`^[A-Za-z0-9]{4}-[A-Za-z0-9]{4}-[A-Za-z0-9]{4}-[A-Za-z0-9]{4}$`)
+
+// FieldError describes why one field of a produce item is invalid.
+type FieldError struct {
+	// Index is the position of the item in the request, or 0 for a single item.
+	Index   int    `json:"index"`
+	Code    string `json:"code,omitempty"`
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationErrors holds every FieldError found validating one or more produce items. It matches ErrInvalidItem with
+// errors.Is.
+type ValidationErrors []FieldError
+
+// Error returns every FieldError joined into one message.
+func (ve ValidationErrors) Error() string {
+	msgs := make([]string, 0, len(ve))
+	for _, fe := range ve {
+		msgs = append(msgs, fmt.Sprintf("item %d: %s %s", fe.Index, fe.Field, fe.Message))
+	}
+
+	return fmt.Sprintf("%v: %s", ErrInvalidItem, strings.Join(msgs, "; "))
+}
+
+// Is returns true if target is ErrInvalidItem.
+func (ve ValidationErrors) Is(target error) bool {
+	return target == ErrInvalidItem
+}
+
+// Validate returns the ValidationErrors for item, or nil if it is valid.
+func Validate(item Item) error {
+	ve := validateItem(0, item)
+	if len(ve) > 0 {
+		return ve
+	}
+
+	return nil
+}
+
+// validateItems returns the ValidationErrors for every item, including items sharing a code, or nil if they are all
+// valid.
+func validateItems(items []Item) error {
+	var ve ValidationErrors
+	seen := make(map[string]int, len(items))
+	for i, item := range items {
+		ve = append(ve, validateItem(i, item)...)
+
+		code := strings.ToLower(item.Code)
+		if first, found := seen[code]; found && code != "" {
+			ve = append(ve, FieldError{Index: i, Code: item.Code, Field: "code", Message: fmt.Sprintf("duplicates item %d", first)})
+			continue
+		}
+
+		seen[code] = i
+	}
+
+	if len(ve) > 0 {
+		return ve
+	}
+
+	return nil
+}
+
+// validateItem returns a FieldError for each invalid field of the item at index.
+func validateItem(index int, item Item) (ve ValidationErrors) {
+	if !codePattern.MatchString(item.Code) {
+		ve = append(ve, FieldError{Index: index, Code: item.Code, Field: "code", Message: "must be four groups of four letters or digits separated by dashes"})
+	}
+
+	if strings.TrimSpace(item.Name) == "" {
+		ve = append(ve, FieldError{Index: index, Code: item.Code, Field: "name", Message: "is required"})
+	}
+
+	switch {
+	case item.Price == nil:
+		ve = append(ve, FieldError{Index: index, Code: item.Code, Field: "price", Message: "is required"})
+	case !SupportedCurrencies[item.Price.Currency().Code]:
+		ve = append(ve, FieldError{Index: index, Code: item.Code, Field: "price.currency", Message: fmt.Sprintf("%q is not supported", item.Price.Currency().Code)})
+	case !item.Price.IsPositive():
+		ve = append(ve, FieldError{Index: index, Code: item.Code, Field: "price.amount", Message: "must be positive"})
+	}
+
+	if item.Unit != "" && !SupportedUnits[item.Unit] {
+		ve = append(ve, FieldError{Index: index, Code: item.Code, Field: "unit", Message: fmt.Sprintf("%q is not supported", item.Unit)})
+	}
+
+	return
+}
+
+// price is the JSON form of a price. It is decoded before building a money.Money so that missing or invalid fields are
+// reported as ValidationErrors instead of reaching money.Money's own decoding.
+type price struct {
+	Amount   *json.Number `json:"amount"`
+	Currency *string      `json:"currency"`
+}
+
+// DecodePrice returns the money.Money described by data, or nil if data is empty or null, and a FieldError for each
+// missing or invalid field. Prices are decoded with it instead of money.Money's own decoding, which panics on missing
+// fields.
+func DecodePrice(data json.RawMessage) (*money.Money, ValidationErrors) {
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+
+	var p price
+	err := json.Unmarshal(data, &p)
+	if err != nil {
+		return nil, ValidationErrors{{Field: "price", Message: "must be an object with an amount and a currency"}}
+	}
+
+	var ve ValidationErrors
+	var amount int64
+	switch {
+	case p.Amount == nil:
+		ve = append(ve, FieldError{Field: "price.amount", Message: "is required"})
+	default:
+		amount, err = p.Amount.Int64()
+		if err != nil {
+			ve = append(ve, FieldError{Field: "price.amount", Message: "must be a whole number of minor units"})
+		}
+	}
+
+	if p.Currency == nil || *p.Currency == "" {
+		ve = append(ve, FieldError{Field: "price.currency", Message: "is required"})
+	}
+
+	if len(ve) > 0 {
+		return nil, ve
+	}
+
+	return money.New(amount, *p.Currency), nil
+}
+
+// UnmarshalJSON decodes an item, returning ValidationErrors if its price is missing fields or has invalid ones.
+func (i *Item) UnmarshalJSON(data []byte) error {
+	type item Item
+	var raw struct {
+		item
+		Price json.RawMessage `json:"price"`
+	}
+
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+
+	p, ve := DecodePrice(raw.Price)
+	*i = Item(raw.item)
+	i.Price = p
+
+	if len(ve) > 0 {
+		for j := range ve {
+			ve[j].Code = i.Code
+		}
+
+		return ve
+	}
+
+	return nil
+}
+
+// UnmarshalJSON decodes an item patch, returning ValidationErrors if its price is missing fields or has invalid ones.
+func (ip *ItemPatch) UnmarshalJSON(data []byte) error {
+	type itemPatch ItemPatch
+	var raw struct {
+		itemPatch
+		Price json.RawMessage `json:"price"`
+	}
+
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+
+	p, ve := DecodePrice(raw.Price)
+	*ip = ItemPatch(raw.itemPatch)
+	ip.Price = p
+
+	if len(ve) > 0 {
+		return ve
+	}
+
+	return nil
+}
+
+// DecodeItems decodes a JSON array of items. It returns the ValidationErrors for the price of every item that could
+// not be decoded, indexed by their position in the array.
+func DecodeItems(data []byte) ([]Item, error) {
+	var raw []json.RawMessage
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var ve ValidationErrors
+	items := make([]Item, len(raw))
+	for i, r := range raw {
+		err = json.Unmarshal(r, &items[i])
+
+		var itemErrors ValidationErrors
+		switch {
+		case errors.As(err, &itemErrors):
+			for _, fe := range itemErrors {
+				fe.Index = i
+				ve = append(ve, fe)
+			}
+		case err != nil:
+			return nil, err
+		}
+	}
+
+	if len(ve) > 0 {
+		return nil, ve
+	}
+
+	return items, nil
+}
@@ -0,0 +1,172 @@
+package produce
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/Rhymond/go-money"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		test          string
+		item          Item
+		expectedError error
+	}{
+		{
+			test: "it should accept a valid item",
+			item: Item{Code: "A12T-4GH7-QPL9-3N4M", Name: "Lettuce", Price: money.New(346, "USD")},
+		},
+		{
+			test: "it should accept lowercase codes",
+			item: Item{Code: "a12t-4gh7-qpl9-3n4m", Name: "Lettuce", Price: money.New(346, "EUR")},
+		},
+		{
+			test: "it should reject codes in the wrong format",
+			item: Item{Code: "A12T-4GH7-QPL9", Name: "Lettuce", Price: money.New(346, "USD")},
+			expectedError: ValidationErrors{
+				{Code: "A12T-4GH7-QPL9", Field: "code", Message: "must be four groups of four letters or digits separated by dashes"},
+			},
+		},
+		{
+			test: "it should reject codes with symbols",
+			item: Item{Code: "A12T-4GH7-QPL9-3N4_", Name: "Lettuce", Price: money.New(346, "USD")},
+			expectedError: ValidationErrors{
+				{Code: "A12T-4GH7-QPL9-3N4_", Field: "code", Message: "must be four groups of four letters or digits separated by dashes"},
+			},
+		},
+		{
+			test: "it should reject blank names",
+			item: Item{Code: "A12T-4GH7-QPL9-3N4M", Name: "  ", Price: money.New(346, "USD")},
+			expectedError: ValidationErrors{
+				{Code: "A12T-4GH7-QPL9-3N4M", Field: "name", Message: "is required"},
+			},
+		},
+		{
+			test: "it should reject a missing price",
+			item: Item{Code: "A12T-4GH7-QPL9-3N4M", Name: "Lettuce"},
+			expectedError: ValidationErrors{
+				{Code: "A12T-4GH7-QPL9-3N4M", Field: "price", Message: "is required"},
+			},
+		},
+		{
+			test: "it should accept a supported unit",
+			item: Item{Code: "A12T-4GH7-QPL9-3N4M", Name: "Peppers", Price: money.New(299, "USD"), Unit: UnitPound},
+		},
+		{
+			test: "it should reject units that are not supported",
+			item: Item{Code: "A12T-4GH7-QPL9-3N4M", Name: "Peppers", Price: money.New(299, "USD"), Unit: "stone"},
+			expectedError: ValidationErrors{
+				{Code: "A12T-4GH7-QPL9-3N4M", Field: "unit", Message: "\"stone\" is not supported"},
+			},
+		},
+		{
+			test: "it should reject prices that are not positive",
+			item: Item{Code: "A12T-4GH7-QPL9-3N4M", Name: "Lettuce", Price: money.New(0, "USD")},
+			expectedError: ValidationErrors{
+				{Code: "A12T-4GH7-QPL9-3N4M", Field: "price.amount", Message: "must be positive"},
+			},
+		},
+		{
+			test: "it should reject unsupported currencies",
+			item: Item{Code: "A12T-4GH7-QPL9-3N4M", Name: "Lettuce", Price: money.New(346, "XYZ")},
+			expectedError: ValidationErrors{
+				{Code: "A12T-4GH7-QPL9-3N4M", Field: "price.currency", Message: `"XYZ" is not supported`},
+			},
+		},
+		{
+			test: "it should return every invalid field",
+			item: Item{Code: "bad", Price: money.New(-1, "USD")},
+			expectedError: ValidationErrors{
+				{Code: "bad", Field: "code", Message: "must be four groups of four letters or digits separated by dashes"},
+				{Code: "bad", Field: "name", Message: "is required"},
+				{Code: "bad", Field: "price.amount", Message: "must be positive"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			err := Validate(tc.item)
+
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestValidateItems(t *testing.T) {
+	t.Run("it should reject codes repeated in a batch ignoring case", func(t *testing.T) {
+		err := validateItems([]Item{
+			{Code: "A12T-4GH7-QPL9-3N4M", Name: "Lettuce", Price: money.New(346, "USD")},
+			{Code: "E5T6-9UI3-TH15-QR88", Name: "Peach", Price: money.New(299, "USD")},
+			{Code: "a12t-4gh7-qpl9-3n4m", Name: "Lettuce", Price: money.New(346, "USD")},
+		})
+
+		assert.Equal(t, ValidationErrors{
+			{Index: 2, Code: "a12t-4gh7-qpl9-3n4m", Field: "code", Message: "duplicates item 0"},
+		}, err)
+		assert.True(t, errors.Is(err, ErrInvalidItem))
+		assert.Equal(t, "invalid produce item: item 2: code duplicates item 0", err.Error())
+	})
+}
+
+func TestDecodeItems(t *testing.T) {
+	tests := []struct {
+		test          string
+		body          string
+		expectedItems []Item
+		expectedError error
+	}{
+		{
+			test:          "it should decode items with valid prices",
+			body:          `[{"code":"A12T-4GH7-QPL9-3N4M","name":"Lettuce","price":{"amount":346,"currency":"USD"}}]`,
+			expectedItems: []Item{{Code: "A12T-4GH7-QPL9-3N4M", Name: "Lettuce", Price: money.New(346, "USD")}},
+		},
+		{
+			test:          "it should leave a missing price for validation",
+			body:          `[{"code":"A12T-4GH7-QPL9-3N4M","name":"Lettuce"}]`,
+			expectedItems: []Item{{Code: "A12T-4GH7-QPL9-3N4M", Name: "Lettuce"}},
+		},
+		{
+			test: "it should reject an empty price",
+			body: `[{"code":"A12T-4GH7-QPL9-3N4M","name":"Lettuce","price":{}}]`,
+			expectedError: ValidationErrors{
+				{Code: "A12T-4GH7-QPL9-3N4M", Field: "price.amount", Message: "is required"},
+				{Code: "A12T-4GH7-QPL9-3N4M", Field: "price.currency", Message: "is required"},
+			},
+		},
+		{
+			test: "it should reject invalid prices with the index of their item",
+			body: `[{"code":"A12T-4GH7-QPL9-3N4M","name":"Lettuce","price":{"amount":346,"currency":"USD"}},` +
+				`{"code":"E5T6-9UI3-TH15-QR88","name":"Peach","price":{"amount":2.5,"currency":"USD"}},` +
+				`{"code":"YRT6-72AS-K736-L4AR","name":"Pepper","price":"cheap"}]`,
+			expectedError: ValidationErrors{
+				{Index: 1, Code: "E5T6-9UI3-TH15-QR88", Field: "price.amount", Message: "must be a whole number of minor units"},
+				{Index: 2, Code: "YRT6-72AS-K736-L4AR", Field: "price", Message: "must be an object with an amount and a currency"},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			items, err := DecodeItems([]byte(tc.body))
+
+			assert.Equal(t, tc.expectedItems, items)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestItemPatch_UnmarshalJSON(t *testing.T) {
+	t.Run("it should reject an empty price", func(t *testing.T) {
+		var patch ItemPatch
+		err := json.Unmarshal([]byte(`{"price":{}}`), &patch)
+
+		assert.Equal(t, ValidationErrors{
+			{Field: "price.amount", Message: "is required"},
+			{Field: "price.currency", Message: "is required"},
+		}, err)
+	})
+}
@@ -0,0 +1,18 @@
+package promotions
+
+const (
+	KeyPromotionID   = "promotion_id"
+	KeyPromotionCode = "produce_code"
+)
+
+// RuleType is the kind of discount a Rule gives.
+type RuleType string
+
+const (
+	// PercentOff takes Percent off the line total.
+	PercentOff RuleType = "percent_off"
+	// BuyGet makes Get items free for every Buy items bought, such as buy 2 get 1 free.
+	BuyGet RuleType = "buy_get"
+	// MultiBuy sells every Quantity items for Price, such as 3 for $5.
+	MultiBuy RuleType = "multi_buy"
+)
@@ -0,0 +1,104 @@
+package promotions
+
+import (
+	"sort"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/davidlick/supermarket-api/internal/produce"
+)
+
+// Discounts returns the discounts the rules for the produce item with produceCode give on a line of quantity of the
+// item priced at unitPrice, with a line total of total, at the time at. See Apply for how rules are chosen and combined.
+func (s *service) Discounts(produceCode string, quantity produce.Quantity, unitPrice, total *money.Money, at time.Time) ([]Discount, error) {
+	rules, err := s.ForCode(produceCode)
+	if err != nil {
+		return nil, err
+	}
+
+	return Apply(rules, quantity, unitPrice, total, at), nil
+}
+
+// Apply returns the discounts rules give on a line of quantity of an item priced at unitPrice, with a line total of
+// total, at the time at. BuyGet and MultiBuy rules count whole units, so a line of 2.5 lb counts as 2. Rules that are
+// active at the time are tried in order of Priority, highest first, and then ID. The first rule that gives a discount
+// is applied, and later rules are only applied if they and every rule applied before them are Stackable. Each
+// discount is taken from what is left of the line total after the discounts before it, and a line never goes below
+// zero.
+func Apply(rules []Rule, quantity produce.Quantity, unitPrice, total *money.Money, at time.Time) []Discount {
+	active := make([]Rule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.activeAt(at) {
+			active = append(active, rule)
+		}
+	}
+
+	sort.Slice(active, func(i, j int) bool {
+		if active[i].Priority != active[j].Priority {
+			return active[i].Priority > active[j].Priority
+		}
+
+		return active[i].ID < active[j].ID
+	})
+
+	var discounts []Discount
+	remaining := total.Amount()
+	stackable := true
+	for _, rule := range active {
+		if len(discounts) > 0 && !(stackable && rule.Stackable) {
+			continue
+		}
+
+		amount := rule.discount(quantity.Whole(), unitPrice, remaining)
+		if amount > remaining {
+			amount = remaining
+		}
+
+		if amount <= 0 {
+			continue
+		}
+
+		discounts = append(discounts, Discount{
+			RuleID: rule.ID,
+			Name:   rule.Name,
+			Amount: money.New(amount, unitPrice.Currency().Code),
+		})
+		remaining -= amount
+		stackable = stackable && rule.Stackable
+	}
+
+	return discounts
+}
+
+// activeAt returns true if the rule applies at the time at.
+func (r Rule) activeAt(at time.Time) bool {
+	if r.StartsAt != nil && at.Before(*r.StartsAt) {
+		return false
+	}
+
+	if r.EndsAt != nil && !at.Before(*r.EndsAt) {
+		return false
+	}
+
+	return true
+}
+
+// discount returns the discount in minor units the rule gives on units whole items priced at unitPrice, where remaining
+// is what is left of the line total after earlier discounts.
+func (r Rule) discount(units int64, unitPrice *money.Money, remaining int64) int64 {
+	switch r.Type {
+	case PercentOff:
+		// Rounded half up to a whole minor unit.
+		return (remaining*r.Percent + 50) / 100
+	case BuyGet:
+		return units / (r.Buy + r.Get) * r.Get * unitPrice.Amount()
+	case MultiBuy:
+		if r.Price == nil || !r.Price.SameCurrency(unitPrice) {
+			return 0
+		}
+
+		return units / r.Quantity * (r.Quantity*unitPrice.Amount() - r.Price.Amount())
+	}
+
+	return 0
+}
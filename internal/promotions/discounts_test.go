@@ -0,0 +1,147 @@
+package promotions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApply(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	yesterday := now.Add(-24 * time.Hour)
+	tomorrow := now.Add(24 * time.Hour)
+
+	tests := []struct {
+		test              string
+		rules             []Rule
+		quantity          int64
+		expectedDiscounts []Discount
+	}{
+		{
+			test:     "it should take a percentage off the line total, rounded half up",
+			rules:    []Rule{{ID: "a", Name: "10% off", Type: PercentOff, Percent: 10}},
+			quantity: 3,
+			// 10% of 897 is 89.7.
+			expectedDiscounts: []Discount{{RuleID: "a", Name: "10% off", Amount: money.New(90, "USD")}},
+		},
+		{
+			test:              "it should make items free for every group bought",
+			rules:             []Rule{{ID: "a", Name: "buy 2 get 1 free", Type: BuyGet, Buy: 2, Get: 1}},
+			quantity:          7,
+			expectedDiscounts: []Discount{{RuleID: "a", Name: "buy 2 get 1 free", Amount: money.New(598, "USD")}},
+		},
+		{
+			test:              "it should price every group at the multi-buy price",
+			rules:             []Rule{{ID: "a", Name: "3 for $5", Type: MultiBuy, Quantity: 3, Price: money.New(500, "USD")}},
+			quantity:          7,
+			expectedDiscounts: []Discount{{RuleID: "a", Name: "3 for $5", Amount: money.New(794, "USD")}},
+		},
+		{
+			test:     "it should skip a multi-buy that costs more than the items",
+			rules:    []Rule{{ID: "a", Name: "3 for $10", Type: MultiBuy, Quantity: 3, Price: money.New(1000, "USD")}},
+			quantity: 3,
+		},
+		{
+			test:     "it should skip a multi-buy priced in another currency",
+			rules:    []Rule{{ID: "a", Name: "3 for €5", Type: MultiBuy, Quantity: 3, Price: money.New(500, "EUR")}},
+			quantity: 3,
+		},
+		{
+			test:     "it should skip rules that haven't started or have ended",
+			quantity: 1,
+			rules: []Rule{
+				{ID: "a", Name: "tomorrow", Type: PercentOff, Percent: 10, StartsAt: &tomorrow},
+				{ID: "b", Name: "yesterday", Type: PercentOff, Percent: 10, EndsAt: &now},
+			},
+		},
+		{
+			test:     "it should apply rules that have started and not ended",
+			quantity: 1,
+			rules: []Rule{
+				{ID: "a", Name: "sale", Type: PercentOff, Percent: 50, StartsAt: &yesterday, EndsAt: &tomorrow},
+			},
+			expectedDiscounts: []Discount{{RuleID: "a", Name: "sale", Amount: money.New(150, "USD")}},
+		},
+		{
+			test:     "it should only apply the highest priority rule if it isn't stackable",
+			quantity: 3,
+			rules: []Rule{
+				{ID: "a", Name: "10% off", Type: PercentOff, Percent: 10, Stackable: true},
+				{ID: "b", Name: "buy 2 get 1 free", Type: BuyGet, Buy: 2, Get: 1, Priority: 1},
+			},
+			expectedDiscounts: []Discount{{RuleID: "b", Name: "buy 2 get 1 free", Amount: money.New(299, "USD")}},
+		},
+		{
+			test:     "it should stack stackable rules in priority order on the remaining total",
+			quantity: 3,
+			rules: []Rule{
+				{ID: "a", Name: "10% off", Type: PercentOff, Percent: 10, Stackable: true},
+				{ID: "b", Name: "buy 2 get 1 free", Type: BuyGet, Buy: 2, Get: 1, Priority: 1, Stackable: true},
+			},
+			expectedDiscounts: []Discount{
+				{RuleID: "b", Name: "buy 2 get 1 free", Amount: money.New(299, "USD")},
+				// 10% of the remaining 598.
+				{RuleID: "a", Name: "10% off", Amount: money.New(60, "USD")},
+			},
+		},
+		{
+			test:     "it should not stack a rule that isn't stackable after a stackable one",
+			quantity: 3,
+			rules: []Rule{
+				{ID: "a", Name: "10% off", Type: PercentOff, Percent: 10, Priority: 1, Stackable: true},
+				{ID: "b", Name: "20% off", Type: PercentOff, Percent: 20},
+			},
+			expectedDiscounts: []Discount{{RuleID: "a", Name: "10% off", Amount: money.New(90, "USD")}},
+		},
+		{
+			test:     "it should order rules with the same priority by ID",
+			quantity: 1,
+			rules: []Rule{
+				{ID: "b", Name: "20% off", Type: PercentOff, Percent: 20},
+				{ID: "a", Name: "10% off", Type: PercentOff, Percent: 10},
+			},
+			expectedDiscounts: []Discount{{RuleID: "a", Name: "10% off", Amount: money.New(30, "USD")}},
+		},
+		{
+			test:     "it should not discount a line below zero",
+			quantity: 2,
+			rules: []Rule{
+				{ID: "a", Name: "buy 1 get 1 free", Type: BuyGet, Buy: 1, Get: 1, Priority: 1, Stackable: true},
+				{ID: "b", Name: "100% off", Type: PercentOff, Percent: 100, Stackable: true},
+				{ID: "c", Name: "3 for $1", Type: MultiBuy, Quantity: 2, Price: money.New(1, "USD"), Stackable: true},
+			},
+			expectedDiscounts: []Discount{
+				{RuleID: "a", Name: "buy 1 get 1 free", Amount: money.New(299, "USD")},
+				{RuleID: "b", Name: "100% off", Amount: money.New(299, "USD")},
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			discounts := Apply(tc.rules, produce.NewQuantity(tc.quantity), money.New(299, "USD"), money.New(299*tc.quantity, "USD"), now)
+
+			assert.Equal(t, tc.expectedDiscounts, discounts)
+		})
+	}
+}
+
+func TestApply_Weighed(t *testing.T) {
+	t.Run("it should count whole units of weighed lines and discount their total", func(t *testing.T) {
+		rules := []Rule{
+			{ID: "a", Name: "buy 1 get 1 free", Type: BuyGet, Buy: 1, Get: 1, Stackable: true, Priority: 1},
+			{ID: "b", Name: "10% off", Type: PercentOff, Percent: 10, Stackable: true},
+		}
+
+		// 2.5 lb at $2.99/lb is $7.48, and one whole pound is free.
+		discounts := Apply(rules, 2500, money.New(299, "USD"), money.New(748, "USD"), time.Now())
+
+		assert.Equal(t, []Discount{
+			{RuleID: "a", Name: "buy 1 get 1 free", Amount: money.New(299, "USD")},
+			{RuleID: "b", Name: "10% off", Amount: money.New(45, "USD")},
+		}, discounts)
+	})
+}
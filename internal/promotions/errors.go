@@ -0,0 +1,7 @@
+package promotions
+
+import "errors"
+
+var (
+	ErrInvalidRule = errors.New("invalid promotion rule")
+)
@@ -0,0 +1,38 @@
+package promotions
+
+import (
+	"time"
+
+	"github.com/Rhymond/go-money"
+)
+
+// Rule is a promotion discounting one produce item. Which fields are used depends on Type.
+type Rule struct {
+	ID   string   `json:"id"`
+	Name string   `json:"name"`
+	Code string   `json:"code"`
+	Type RuleType `json:"type"`
+
+	Percent  int64        `json:"percent,omitempty"`
+	Buy      int64        `json:"buy,omitempty"`
+	Get      int64        `json:"get,omitempty"`
+	Quantity int64        `json:"quantity,omitempty"`
+	Price    *money.Money `json:"price,omitempty"`
+
+	// Priority orders the rules applying to a line, highest first. Rules with the same priority are ordered by ID.
+	Priority int `json:"priority"`
+	// Stackable rules can be applied together. A rule that isn't stackable is only applied on its own.
+	Stackable bool `json:"stackable"`
+	// StartsAt and EndsAt bound when the rule applies. Either can be nil for a rule without that bound.
+	StartsAt *time.Time `json:"starts_at,omitempty"`
+	EndsAt   *time.Time `json:"ends_at,omitempty"`
+
+	Version uint64 `json:"version,omitempty"`
+}
+
+// Discount is the amount a Rule took off a line.
+type Discount struct {
+	RuleID string       `json:"rule_id"`
+	Name   string       `json:"name"`
+	Amount *money.Money `json:"amount"`
+}
@@ -0,0 +1,162 @@
+package promotions
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+
+	"github.com/davidlick/supermarket-api/internal/interfaces"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+)
+
+type service struct {
+	db interfaces.RamDB
+}
+
+// NewService creates a new promotion service storing promotion rules in db.
+func NewService(db interfaces.RamDB) *service {
+	return &service{
+		db: db,
+	}
+}
+
+// Create stores a new rule under a generated ID and returns it as stored. It returns an error matching ErrInvalidRule
+// if the rule is invalid.
+func (s *service) Create(rule Rule) (Rule, error) {
+	err := Validate(rule)
+	if err != nil {
+		return Rule{}, err
+	}
+
+	rule.ID, err = newID()
+	if err != nil {
+		return Rule{}, err
+	}
+
+	rec, err := newRecord(rule)
+	if err != nil {
+		return Rule{}, err
+	}
+
+	err = s.db.Insert(rec)
+	if err != nil {
+		return Rule{}, err
+	}
+
+	rule.Version = 1
+	return rule, nil
+}
+
+// Update replaces the stored rule with the same ID. If rule has a Version, it returns ramdb.ErrVersionMismatch unless
+// the stored rule is at that version. It returns an error matching ErrInvalidRule if the rule is invalid.
+func (s *service) Update(rule Rule) (Rule, error) {
+	err := Validate(rule)
+	if err != nil {
+		return Rule{}, err
+	}
+
+	stored, err := s.Get(rule.ID)
+	if err != nil {
+		return Rule{}, err
+	}
+
+	if rule.Version == 0 {
+		rule.Version = stored.Version
+	}
+
+	rec, err := newRecord(rule)
+	if err != nil {
+		return Rule{}, err
+	}
+
+	err = s.db.CompareAndSwap(rec, rule.Version)
+	if err != nil {
+		return Rule{}, err
+	}
+
+	rule.Version++
+	return rule, nil
+}
+
+// Remove removes the rule with id.
+func (s *service) Remove(id string) error {
+	rec, err := ramdb.NewRecord(id, KeyPromotionID, nil)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Delete(rec)
+}
+
+// Get returns the rule with id.
+func (s *service) Get(id string) (rule Rule, err error) {
+	rec, err := s.db.Get(KeyPromotionID, id)
+	if err != nil {
+		return
+	}
+
+	err = rec.Deserialize(&rule)
+	rule.Version = rec.Version()
+	return
+}
+
+// All returns every rule sorted by ID.
+func (s *service) All() (rules []Rule, err error) {
+	recs, err := s.db.Scan(KeyPromotionID, ramdb.ScanOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return deserializeRules(recs)
+}
+
+// ForCode returns every rule discounting the produce item with produceCode, or none if it has no rules.
+func (s *service) ForCode(produceCode string) (rules []Rule, err error) {
+	recs, err := s.db.Lookup(KeyPromotionCode, strings.ToLower(produceCode))
+	if err == ramdb.ErrNoRecord {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return deserializeRules(recs)
+}
+
+// deserializeRules deserializes each record into a Rule.
+func deserializeRules(recs []*ramdb.Record) (rules []Rule, err error) {
+	for _, rec := range recs {
+		var rule Rule
+		err = rec.Deserialize(&rule)
+		if err != nil {
+			return nil, err
+		}
+
+		rule.Version = rec.Version()
+		rules = append(rules, rule)
+	}
+
+	return
+}
+
+// newRecord returns the record storing rule, keyed by ID and by lowercased produce code.
+func newRecord(rule Rule) (*ramdb.Record, error) {
+	rule.Version = 0
+	rec, err := ramdb.NewRecord(rule.ID, KeyPromotionID, rule)
+	if err != nil {
+		return nil, err
+	}
+
+	return rec.WithKey(KeyPromotionCode, strings.ToLower(rule.Code)), nil
+}
+
+// newID returns a random rule ID.
+func newID() (string, error) {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,169 @@
+package promotions
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+	"github.com/stretchr/testify/assert"
+)
+
+// newTestService returns a promotion service on a new table.
+func newTestService(t *testing.T) *service {
+	db := ramdb.NewDatabase()
+	assert.Nil(t, db.CreateTable("promotions"))
+	assert.Nil(t, db.From("promotions").CreateOrderedIndex(KeyPromotionID))
+	assert.Nil(t, db.From("promotions").CreateIndex(KeyPromotionCode))
+
+	return NewService(db.From("promotions"))
+}
+
+func TestService_Create(t *testing.T) {
+	tests := []struct {
+		test          string
+		rule          Rule
+		expectedError error
+	}{
+		{
+			test: "it should store a valid rule",
+			rule: Rule{Name: "10% off", Code: "A12T-4GH7-QPL9-3N4M", Type: PercentOff, Percent: 10},
+		},
+		{
+			test:          "it should return ErrInvalidRule for a rule without a code",
+			rule:          Rule{Name: "10% off", Type: PercentOff, Percent: 10},
+			expectedError: ErrInvalidRule,
+		},
+		{
+			test:          "it should return ErrInvalidRule for an unknown type",
+			rule:          Rule{Name: "free", Code: "A12T-4GH7-QPL9-3N4M", Type: "free"},
+			expectedError: ErrInvalidRule,
+		},
+		{
+			test:          "it should return ErrInvalidRule for a percentage over 100",
+			rule:          Rule{Name: "110% off", Code: "A12T-4GH7-QPL9-3N4M", Type: PercentOff, Percent: 110},
+			expectedError: ErrInvalidRule,
+		},
+		{
+			test:          "it should return ErrInvalidRule for a buy-get without a free quantity",
+			rule:          Rule{Name: "buy 2", Code: "A12T-4GH7-QPL9-3N4M", Type: BuyGet, Buy: 2},
+			expectedError: ErrInvalidRule,
+		},
+		{
+			test:          "it should return ErrInvalidRule for a multi-buy without a price",
+			rule:          Rule{Name: "3 for", Code: "A12T-4GH7-QPL9-3N4M", Type: MultiBuy, Quantity: 3},
+			expectedError: ErrInvalidRule,
+		},
+		{
+			test: "it should return ErrInvalidRule for a rule ending before it starts",
+			rule: Rule{
+				Name:     "sale",
+				Code:     "A12T-4GH7-QPL9-3N4M",
+				Type:     MultiBuy,
+				Quantity: 3,
+				Price:    money.New(500, "USD"),
+				StartsAt: &time.Time{},
+				EndsAt:   &time.Time{},
+			},
+			expectedError: ErrInvalidRule,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			svc := newTestService(t)
+
+			rule, err := svc.Create(tc.rule)
+
+			assert.True(t, errors.Is(err, tc.expectedError), "expected %v, got %v", tc.expectedError, err)
+			if tc.expectedError != nil {
+				return
+			}
+
+			assert.NotEmpty(t, rule.ID)
+			assert.Equal(t, uint64(1), rule.Version)
+
+			stored, err := svc.Get(rule.ID)
+			assert.Nil(t, err)
+			assert.Equal(t, rule, stored)
+		})
+	}
+}
+
+func TestService_Update(t *testing.T) {
+	t.Run("it should replace the rule and reindex it by its new code", func(t *testing.T) {
+		svc := newTestService(t)
+
+		rule, err := svc.Create(Rule{Name: "10% off", Code: "A12T-4GH7-QPL9-3N4M", Type: PercentOff, Percent: 10})
+		assert.Nil(t, err)
+
+		rule.Code = "E5T6-9UI3-TH15-QR88"
+		rule.Percent = 20
+		rule, err = svc.Update(rule)
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(2), rule.Version)
+
+		rules, err := svc.ForCode("A12T-4GH7-QPL9-3N4M")
+		assert.Nil(t, err)
+		assert.Empty(t, rules)
+
+		rules, err = svc.ForCode("e5t6-9ui3-th15-qr88")
+		assert.Nil(t, err)
+		assert.Equal(t, []Rule{rule}, rules)
+	})
+
+	t.Run("it should return ErrVersionMismatch if the rule has changed", func(t *testing.T) {
+		svc := newTestService(t)
+
+		rule, err := svc.Create(Rule{Name: "10% off", Code: "A12T-4GH7-QPL9-3N4M", Type: PercentOff, Percent: 10})
+		assert.Nil(t, err)
+
+		_, err = svc.Update(rule)
+		assert.Nil(t, err)
+
+		_, err = svc.Update(rule)
+		assert.Equal(t, ramdb.ErrVersionMismatch, err)
+	})
+
+	t.Run("it should return ErrNoRecord for an unknown rule", func(t *testing.T) {
+		svc := newTestService(t)
+
+		_, err := svc.Update(Rule{ID: "unknown", Name: "10% off", Code: "A12T-4GH7-QPL9-3N4M", Type: PercentOff, Percent: 10})
+		assert.Equal(t, ramdb.ErrNoRecord, err)
+	})
+}
+
+func TestService_Remove(t *testing.T) {
+	t.Run("it should remove the rule", func(t *testing.T) {
+		svc := newTestService(t)
+
+		rule, err := svc.Create(Rule{Name: "10% off", Code: "A12T-4GH7-QPL9-3N4M", Type: PercentOff, Percent: 10})
+		assert.Nil(t, err)
+
+		assert.Nil(t, svc.Remove(rule.ID))
+
+		_, err = svc.Get(rule.ID)
+		assert.Equal(t, ramdb.ErrNoRecord, err)
+
+		rules, err := svc.All()
+		assert.Nil(t, err)
+		assert.Empty(t, rules)
+	})
+}
+
+func TestService_Discounts(t *testing.T) {
+	t.Run("it should apply the rules for the code", func(t *testing.T) {
+		svc := newTestService(t)
+
+		rule, err := svc.Create(Rule{Name: "10% off", Code: "A12T-4GH7-QPL9-3N4M", Type: PercentOff, Percent: 10})
+		assert.Nil(t, err)
+		_, err = svc.Create(Rule{Name: "20% off", Code: "E5T6-9UI3-TH15-QR88", Type: PercentOff, Percent: 20})
+		assert.Nil(t, err)
+
+		discounts, err := svc.Discounts("a12t-4gh7-qpl9-3n4m", produce.NewQuantity(1), money.New(300, "USD"), money.New(300, "USD"), time.Now())
+		assert.Nil(t, err)
+		assert.Equal(t, []Discount{{RuleID: rule.ID, Name: "10% off", Amount: money.New(30, "USD")}}, discounts)
+	})
+}
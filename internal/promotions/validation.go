@@ -0,0 +1,71 @@
+package promotions
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/davidlick/supermarket-api/internal/produce"
+)
+
+// Validate returns an error matching ErrInvalidRule if rule is invalid, or nil if it is valid.
+func Validate(rule Rule) error {
+	if strings.TrimSpace(rule.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidRule)
+	}
+
+	if strings.TrimSpace(rule.Code) == "" {
+		return fmt.Errorf("%w: code is required", ErrInvalidRule)
+	}
+
+	switch rule.Type {
+	case PercentOff:
+		if rule.Percent < 1 || rule.Percent > 100 {
+			return fmt.Errorf("%w: percent must be from 1 to 100", ErrInvalidRule)
+		}
+	case BuyGet:
+		if rule.Buy < 1 || rule.Get < 1 {
+			return fmt.Errorf("%w: buy and get must be positive", ErrInvalidRule)
+		}
+	case MultiBuy:
+		if rule.Quantity < 2 {
+			return fmt.Errorf("%w: quantity must be at least 2", ErrInvalidRule)
+		}
+
+		if rule.Price == nil || !rule.Price.IsPositive() {
+			return fmt.Errorf("%w: price must be positive", ErrInvalidRule)
+		}
+	default:
+		return fmt.Errorf("%w: type must be %s, %s or %s", ErrInvalidRule, PercentOff, BuyGet, MultiBuy)
+	}
+
+	if rule.StartsAt != nil && rule.EndsAt != nil && !rule.EndsAt.After(*rule.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidRule)
+	}
+
+	return nil
+}
+
+// UnmarshalJSON decodes a rule, returning produce.ValidationErrors if its price is missing fields or has invalid ones.
+func (r *Rule) UnmarshalJSON(data []byte) error {
+	type rule Rule
+	var raw struct {
+		rule
+		Price json.RawMessage `json:"price"`
+	}
+
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+
+	p, ve := produce.DecodePrice(raw.Price)
+	*r = Rule(raw.rule)
+	r.Price = p
+
+	if len(ve) > 0 {
+		return ve
+	}
+
+	return nil
+}
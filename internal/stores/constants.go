@@ -0,0 +1,12 @@
+package stores
+
+const (
+	KeyStoreID = "store_id"
+	// KeyOverrideID is the column overrides are keyed by, the store ID and lowercased produce code joined by a colon,
+	// so the overrides of a store can be scanned by prefix.
+	KeyOverrideID = "store_produce"
+)
+
+// maxSwapAttempts is the number of times a change to a store's overrides is retried when the store or item is changed
+// by another caller between being read and written.
+const maxSwapAttempts = 5
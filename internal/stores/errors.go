@@ -0,0 +1,7 @@
+package stores
+
+import "errors"
+
+var (
+	ErrInvalidStore = errors.New("invalid store")
+)
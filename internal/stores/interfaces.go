@@ -0,0 +1,12 @@
+package stores
+
+import (
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+)
+
+type ProduceService interface {
+	Get(produceCode string) (item produce.Item, err error)
+	List(opts produce.ListOptions) (items []produce.Item, next string, err error)
+	Check(tx *ramdb.Tx, item produce.Item) error
+}
@@ -0,0 +1,57 @@
+package stores
+
+import (
+	"encoding/json"
+
+	"github.com/Rhymond/go-money"
+	"github.com/davidlick/supermarket-api/internal/produce"
+)
+
+// Store is a store selling the base produce catalogue, with its own prices and availability for some items.
+type Store struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Version uint64 `json:"version,omitempty"`
+}
+
+// Override changes a produce item at one store. Fields that are nil are inherited from the base catalogue.
+type Override struct {
+	StoreID   string       `json:"store_id"`
+	Code      string       `json:"code"`
+	Price     *money.Money `json:"price,omitempty"`
+	Available *bool        `json:"available,omitempty"`
+}
+
+// UnmarshalJSON decodes an override, returning produce.ValidationErrors if its price is missing fields or has invalid
+// ones.
+func (o *Override) UnmarshalJSON(data []byte) error {
+	type override Override
+	var raw struct {
+		override
+		Price json.RawMessage `json:"price"`
+	}
+
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return err
+	}
+
+	p, ve := produce.DecodePrice(raw.Price)
+	*o = Override(raw.override)
+	o.Price = p
+
+	if len(ve) > 0 {
+		return ve
+	}
+
+	return nil
+}
+
+// Item is a produce item as sold at a store: the catalogue item with the store's override applied.
+type Item struct {
+	produce.Item
+	StoreID   string `json:"store_id"`
+	Available bool   `json:"available"`
+	// BasePrice is the catalogue price of an item whose price the store overrides.
+	BasePrice *money.Money `json:"base_price,omitempty"`
+}
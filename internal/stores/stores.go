@@ -0,0 +1,481 @@
+package stores
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/davidlick/supermarket-api/internal/interfaces"
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+)
+
+type service struct {
+	db         interfaces.RamDB
+	overrides  interfaces.RamDB
+	produceSvc ProduceService
+}
+
+// NewService creates a new store service storing stores in db and their overrides of the produce in produceSvc in
+// overrides.
+func NewService(db, overrides interfaces.RamDB, produceSvc ProduceService) *service {
+	return &service{
+		db:         db,
+		overrides:  overrides,
+		produceSvc: produceSvc,
+	}
+}
+
+// Create stores a new store under a generated ID and returns it as stored. It returns an error matching
+// ErrInvalidStore if the store has no name.
+func (s *service) Create(store Store) (Store, error) {
+	err := validate(store)
+	if err != nil {
+		return Store{}, err
+	}
+
+	store.ID, err = newID()
+	if err != nil {
+		return Store{}, err
+	}
+
+	rec, err := newRecord(store)
+	if err != nil {
+		return Store{}, err
+	}
+
+	err = s.db.Insert(rec)
+	if err != nil {
+		return Store{}, err
+	}
+
+	store.Version = 1
+	return store, nil
+}
+
+// Update replaces the stored store with the same ID. If store has a Version, it returns ramdb.ErrVersionMismatch unless
+// the stored store is at that version.
+func (s *service) Update(store Store) (Store, error) {
+	err := validate(store)
+	if err != nil {
+		return Store{}, err
+	}
+
+	stored, err := s.Get(store.ID)
+	if err != nil {
+		return Store{}, err
+	}
+
+	if store.Version == 0 {
+		store.Version = stored.Version
+	}
+
+	rec, err := newRecord(store)
+	if err != nil {
+		return Store{}, err
+	}
+
+	err = s.db.CompareAndSwap(rec, store.Version)
+	if err != nil {
+		return Store{}, err
+	}
+
+	store.Version++
+	return store, nil
+}
+
+// Remove removes the store with id and all of its overrides. It is retried up to maxSwapAttempts times if the store's
+// overrides change before it is removed.
+func (s *service) Remove(id string) (err error) {
+	for attempt := 0; attempt < maxSwapAttempts; attempt++ {
+		err = s.remove(id)
+		if err != ramdb.ErrVersionMismatch {
+			return err
+		}
+	}
+
+	return err
+}
+
+// remove deletes the store with id and the overrides it has when it is read in one transaction, which fails with
+// ramdb.ErrVersionMismatch if the store has changed since.
+func (s *service) remove(id string) error {
+	store, err := s.Get(id)
+	if err != nil {
+		return err
+	}
+
+	overrides, err := s.Overrides(id)
+	if err != nil {
+		return err
+	}
+
+	rec, err := ramdb.NewRecord(id, KeyStoreID, nil)
+	if err != nil {
+		return err
+	}
+
+	tx := s.overrides.Begin()
+	err = s.db.Join(tx).Check(rec, store.Version)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	for _, o := range overrides {
+		orec, err := ramdb.NewRecord(overrideID(id, o.Code), KeyOverrideID, nil)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		err = tx.Delete(orec)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	err = s.db.Join(tx).Delete(rec)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Get returns the store with id.
+func (s *service) Get(id string) (store Store, err error) {
+	rec, err := s.db.Get(KeyStoreID, id)
+	if err != nil {
+		return
+	}
+
+	err = rec.Deserialize(&store)
+	store.Version = rec.Version()
+	return
+}
+
+// All returns every store sorted by ID.
+func (s *service) All() (stores []Store, err error) {
+	recs, err := s.db.Scan(KeyStoreID, ramdb.ScanOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rec := range recs {
+		var store Store
+		err = rec.Deserialize(&store)
+		if err != nil {
+			return nil, err
+		}
+
+		store.Version = rec.Version()
+		stores = append(stores, store)
+	}
+
+	return stores, nil
+}
+
+// SetOverride replaces the override of a produce item at a store and returns the item as now sold there. The override
+// price must be valid for the item, and the item must be catalogued. It is retried up to maxSwapAttempts times if the
+// store or item changes before the override is written.
+func (s *service) SetOverride(override Override) (item Item, err error) {
+	for attempt := 0; attempt < maxSwapAttempts; attempt++ {
+		item, err = s.setOverride(override)
+		if err != ramdb.ErrVersionMismatch {
+			return item, err
+		}
+	}
+
+	return Item{}, err
+}
+
+// setOverride writes override in a transaction that changes the version of its store, and fails with
+// ramdb.ErrNoRecord or ramdb.ErrVersionMismatch if the store or item has been removed or changed since it was read.
+func (s *service) setOverride(override Override) (Item, error) {
+	store, err := s.Get(override.StoreID)
+	if err != nil {
+		return Item{}, err
+	}
+
+	item, err := s.produceSvc.Get(override.Code)
+	if err != nil {
+		return Item{}, err
+	}
+
+	override.Code = item.Code
+	effective := apply(override.StoreID, item, &override)
+	err = produce.Validate(effective.Item)
+	if err != nil {
+		return Item{}, err
+	}
+
+	rec, err := ramdb.NewRecord(overrideID(override.StoreID, override.Code), KeyOverrideID, override)
+	if err != nil {
+		return Item{}, err
+	}
+
+	tx := s.overrides.Begin()
+	err = tx.Upsert(rec)
+	if err != nil {
+		tx.Rollback()
+		return Item{}, err
+	}
+
+	err = s.touch(tx, store)
+	if err != nil {
+		tx.Rollback()
+		return Item{}, err
+	}
+
+	err = s.produceSvc.Check(tx, item)
+	if err != nil {
+		tx.Rollback()
+		return Item{}, err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return Item{}, err
+	}
+
+	return effective, nil
+}
+
+// RemoveOverride removes the override of a produce item at a store, so the store sells it as catalogued. It is
+// retried up to maxSwapAttempts times if the store changes before the override is removed.
+func (s *service) RemoveOverride(storeID, produceCode string) (err error) {
+	rec, err := ramdb.NewRecord(overrideID(storeID, produceCode), KeyOverrideID, nil)
+	if err != nil {
+		return err
+	}
+
+	for attempt := 0; attempt < maxSwapAttempts; attempt++ {
+		var store Store
+		store, err = s.Get(storeID)
+		if err != nil {
+			return err
+		}
+
+		tx := s.overrides.Begin()
+		err = tx.Delete(rec)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		err = s.touch(tx, store)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		err = tx.Commit()
+		if err != ramdb.ErrVersionMismatch {
+			return err
+		}
+	}
+
+	return err
+}
+
+// touch adds a compare and swap writing store back unchanged to tx, which changes its version. Every change to a
+// store's overrides touches the store, so its version changes whenever its overrides do, and a transaction checking
+// the version fails if they have changed since it was read.
+func (s *service) touch(tx *ramdb.Tx, store Store) error {
+	rec, err := newRecord(store)
+	if err != nil {
+		return err
+	}
+
+	return s.db.Join(tx).CompareAndSwap(rec, store.Version)
+}
+
+// UpdateProduce does nothing, as overrides are applied to the item as it is when it is read.
+func (s *service) UpdateProduce(tx *ramdb.Tx, stored, item produce.Item) error {
+	return nil
+}
+
+// RemoveProduce removes the overrides of the produce item with produceCode at every store as part of tx, which
+// removes the item. tx fails with ramdb.ErrVersionMismatch or ramdb.ErrNoRecord if any store's overrides change, or
+// the store is removed, before it commits.
+func (s *service) RemoveProduce(tx *ramdb.Tx, produceCode string, force bool) error {
+	stores, err := s.All()
+	if err != nil {
+		return err
+	}
+
+	otx := s.overrides.Join(tx)
+	stx := s.db.Join(tx)
+	for _, store := range stores {
+		srec, err := ramdb.NewRecord(store.ID, KeyStoreID, nil)
+		if err != nil {
+			return err
+		}
+
+		err = stx.Check(srec, store.Version)
+		if err != nil {
+			return err
+		}
+
+		override, err := s.override(store.ID, produceCode)
+		if err != nil {
+			return err
+		}
+
+		if override == nil {
+			continue
+		}
+
+		rec, err := ramdb.NewRecord(overrideID(store.ID, produceCode), KeyOverrideID, nil)
+		if err != nil {
+			return err
+		}
+
+		err = otx.Delete(rec)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Overrides returns every override at the store with storeID, sorted by produce code.
+func (s *service) Overrides(storeID string) (overrides []Override, err error) {
+	recs, err := s.overrides.Scan(KeyOverrideID, ramdb.ScanOptions{Prefix: storeID + ":"})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rec := range recs {
+		var o Override
+		err = rec.Deserialize(&o)
+		if err != nil {
+			return nil, err
+		}
+
+		overrides = append(overrides, o)
+	}
+
+	return overrides, nil
+}
+
+// Item returns the produce item with produceCode as sold at the store with storeID.
+func (s *service) Item(storeID, produceCode string) (Item, error) {
+	_, err := s.Get(storeID)
+	if err != nil {
+		return Item{}, err
+	}
+
+	item, err := s.produceSvc.Get(produceCode)
+	if err != nil {
+		return Item{}, err
+	}
+
+	override, err := s.override(storeID, item.Code)
+	if err != nil {
+		return Item{}, err
+	}
+
+	return apply(storeID, item, override), nil
+}
+
+// Items returns a page of the catalogue matching opts as sold at the store with storeID, and the cursor of the next
+// page or "" if it is the last page. Items are selected and sorted by their catalogue price, not the store's.
+func (s *service) Items(storeID string, opts produce.ListOptions) (items []Item, next string, err error) {
+	_, err = s.Get(storeID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	catalogue, next, err := s.produceSvc.List(opts)
+	if err != nil {
+		return nil, "", err
+	}
+
+	items = make([]Item, 0, len(catalogue))
+	for _, item := range catalogue {
+		override, err := s.override(storeID, item.Code)
+		if err != nil {
+			return nil, "", err
+		}
+
+		items = append(items, apply(storeID, item, override))
+	}
+
+	return items, next, nil
+}
+
+// override returns the override of the produce item with produceCode at the store with storeID, or nil if there is
+// none.
+func (s *service) override(storeID, produceCode string) (*Override, error) {
+	rec, err := s.overrides.Get(KeyOverrideID, overrideID(storeID, produceCode))
+	if err == ramdb.ErrNoRecord {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var o Override
+	err = rec.Deserialize(&o)
+	if err != nil {
+		return nil, err
+	}
+
+	return &o, nil
+}
+
+// apply returns item as sold at the store with storeID with override, which may be nil, applied.
+func apply(storeID string, item produce.Item, override *Override) Item {
+	effective := Item{Item: item, StoreID: storeID, Available: true}
+	if override == nil {
+		return effective
+	}
+
+	if override.Price != nil {
+		effective.BasePrice = item.Price
+		effective.Price = override.Price
+	}
+
+	if override.Available != nil {
+		effective.Available = *override.Available
+	}
+
+	return effective
+}
+
+// validate returns an error matching ErrInvalidStore if store is invalid, or nil if it is valid.
+func validate(store Store) error {
+	if strings.TrimSpace(store.Name) == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidStore)
+	}
+
+	return nil
+}
+
+// newRecord returns the record storing store, keyed by ID.
+func newRecord(store Store) (*ramdb.Record, error) {
+	store.Version = 0
+	return ramdb.NewRecord(store.ID, KeyStoreID, store)
+}
+
+// overrideID returns the key of the override of the produce item with produceCode at the store with storeID.
+func overrideID(storeID, produceCode string) string {
+	return storeID + ":" + strings.ToLower(produceCode)
+}
+
+// newID returns a random store ID.
+func newID() (string, error) {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
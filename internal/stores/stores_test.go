@@ -0,0 +1,337 @@
+package stores
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/Rhymond/go-money"
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+	"github.com/stretchr/testify/assert"
+)
+
+var testItems = []produce.Item{
+	{Code: "A12T-4GH7-QPL9-3N4M", Name: "Lettuce", Price: money.New(346, "USD")},
+	{Code: "E5T6-9UI3-TH15-QR88", Name: "Peach", Price: money.New(299, "USD")},
+}
+
+// newTestService returns a store service on new tables, with a catalogue of testItems and one store.
+func newTestService(t *testing.T) (*service, Store, testProduceService) {
+	db := ramdb.NewDatabase()
+	assert.Nil(t, db.CreateTable("produce"))
+	assert.Nil(t, db.From("produce").CreateOrderedIndex(produce.KeyProduceCode))
+	assert.Nil(t, db.From("produce").CreateOrderedIndex(produce.KeyProduceName))
+	assert.Nil(t, db.From("produce").CreateOrderedIndex(produce.KeyProducePrice))
+	assert.Nil(t, db.CreateTable("stores"))
+	assert.Nil(t, db.From("stores").CreateOrderedIndex(KeyStoreID))
+	assert.Nil(t, db.CreateTable("store_produce"))
+	assert.Nil(t, db.From("store_produce").CreateOrderedIndex(KeyOverrideID))
+
+	produceSvc := produce.NewService(db.From("produce"))
+	assert.Nil(t, produceSvc.Add(testItems))
+
+	s := NewService(db.From("stores"), db.From("store_produce"), produceSvc)
+	produceSvc.AddDependent(s)
+	store, err := s.Create(Store{Name: "Dublin"})
+	assert.Nil(t, err)
+
+	return s, store, produceSvc
+}
+
+// testProduceService is the produce service the store service is tested with.
+type testProduceService interface {
+	ProduceService
+	Remove(item produce.Item) error
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+func TestService_Create(t *testing.T) {
+	s, store, _ := newTestService(t)
+
+	assert.NotEmpty(t, store.ID)
+	assert.Equal(t, uint64(1), store.Version)
+
+	_, err := s.Create(Store{Name: " "})
+	assert.True(t, errors.Is(err, ErrInvalidStore))
+
+	stores, err := s.All()
+	assert.Nil(t, err)
+	assert.Equal(t, []Store{store}, stores)
+}
+
+func TestService_Update(t *testing.T) {
+	s, store, _ := newTestService(t)
+
+	store.Name = "Dublin City"
+	updated, err := s.Update(store)
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(2), updated.Version)
+
+	_, err = s.Update(store)
+	assert.Equal(t, ramdb.ErrVersionMismatch, err)
+}
+
+func TestService_Item(t *testing.T) {
+	tests := []struct {
+		test          string
+		override      *Override
+		code          string
+		expectedItem  func(storeID string) Item
+		expectedError error
+	}{
+		{
+			test: "it should inherit the catalogue item without an override",
+			code: "A12T-4GH7-QPL9-3N4M",
+			expectedItem: func(storeID string) Item {
+				return Item{Item: produce.Item{Code: "A12T-4GH7-QPL9-3N4M", Name: "Lettuce", Price: money.New(346, "USD"), Unit: produce.UnitEach, Version: 1}, StoreID: storeID, Available: true}
+			},
+		},
+		{
+			test:     "it should apply the store's price",
+			override: &Override{Code: "a12t-4gh7-qpl9-3n4m", Price: money.New(320, "EUR")},
+			code:     "A12T-4GH7-QPL9-3N4M",
+			expectedItem: func(storeID string) Item {
+				return Item{
+					Item:      produce.Item{Code: "A12T-4GH7-QPL9-3N4M", Name: "Lettuce", Price: money.New(320, "EUR"), Unit: produce.UnitEach, Version: 1},
+					StoreID:   storeID,
+					Available: true,
+					BasePrice: money.New(346, "USD"),
+				}
+			},
+		},
+		{
+			test:     "it should apply the store's availability and keep the catalogue price",
+			override: &Override{Code: "A12T-4GH7-QPL9-3N4M", Available: boolPtr(false)},
+			code:     "A12T-4GH7-QPL9-3N4M",
+			expectedItem: func(storeID string) Item {
+				return Item{Item: produce.Item{Code: "A12T-4GH7-QPL9-3N4M", Name: "Lettuce", Price: money.New(346, "USD"), Unit: produce.UnitEach, Version: 1}, StoreID: storeID}
+			},
+		},
+		{
+			test:          "it should return ErrNoRecord for an item that isn't catalogued",
+			code:          "NONE-NONE-NONE-NONE",
+			expectedError: ramdb.ErrNoRecord,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			s, store, _ := newTestService(t)
+
+			if tc.override != nil {
+				tc.override.StoreID = store.ID
+				item, err := s.SetOverride(*tc.override)
+				assert.Nil(t, err)
+				assert.Equal(t, tc.expectedItem(store.ID), item)
+			}
+
+			item, err := s.Item(store.ID, tc.code)
+			assert.True(t, errors.Is(err, tc.expectedError), "expected %v, got %v", tc.expectedError, err)
+			if tc.expectedError != nil {
+				return
+			}
+
+			assert.Equal(t, tc.expectedItem(store.ID), item)
+		})
+	}
+}
+
+func TestService_SetOverride(t *testing.T) {
+	s, store, _ := newTestService(t)
+
+	_, err := s.SetOverride(Override{StoreID: store.ID, Code: "A12T-4GH7-QPL9-3N4M", Price: money.New(-1, "USD")})
+	assert.True(t, errors.Is(err, produce.ErrInvalidItem))
+
+	_, err = s.SetOverride(Override{StoreID: "none", Code: "A12T-4GH7-QPL9-3N4M", Price: money.New(320, "USD")})
+	assert.Equal(t, ramdb.ErrNoRecord, err)
+
+	_, err = s.SetOverride(Override{StoreID: store.ID, Code: "A12T-4GH7-QPL9-3N4M", Price: money.New(320, "USD")})
+	assert.Nil(t, err)
+
+	assert.Nil(t, s.RemoveOverride(store.ID, "A12T-4GH7-QPL9-3N4M"))
+	item, err := s.Item(store.ID, "A12T-4GH7-QPL9-3N4M")
+	assert.Nil(t, err)
+	assert.Equal(t, money.New(346, "USD"), item.Price)
+}
+
+func TestService_SetOverride_concurrent(t *testing.T) {
+	tests := []struct {
+		test          string
+		between       func(t *testing.T, s *service, store Store, produceSvc testProduceService)
+		expectedError error
+		expectedCodes []string
+	}{
+		{
+			test: "it should write the override if another override is set in between",
+			between: func(t *testing.T, s *service, store Store, produceSvc testProduceService) {
+				_, err := s.SetOverride(Override{StoreID: store.ID, Code: "E5T6-9UI3-TH15-QR88", Available: boolPtr(false)})
+				assert.Nil(t, err)
+			},
+			expectedCodes: []string{"A12T-4GH7-QPL9-3N4M", "E5T6-9UI3-TH15-QR88"},
+		},
+		{
+			test: "it should return ErrNoRecord if the store is removed in between",
+			between: func(t *testing.T, s *service, store Store, produceSvc testProduceService) {
+				assert.Nil(t, s.Remove(store.ID))
+			},
+			expectedError: ramdb.ErrNoRecord,
+		},
+		{
+			test: "it should return ErrNoRecord if the item is removed in between",
+			between: func(t *testing.T, s *service, store Store, produceSvc testProduceService) {
+				assert.Nil(t, produceSvc.Remove(produce.Item{Code: "A12T-4GH7-QPL9-3N4M"}))
+			},
+			expectedError: ramdb.ErrNoRecord,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			s, store, produceSvc := newTestService(t)
+			s.produceSvc = &interleavedProduceService{testProduceService: produceSvc, between: func() {
+				tc.between(t, s, store, produceSvc)
+			}}
+
+			_, err := s.SetOverride(Override{StoreID: store.ID, Code: "A12T-4GH7-QPL9-3N4M", Price: money.New(320, "USD")})
+			assert.Equal(t, tc.expectedError, err)
+
+			overrides, err := s.Overrides(store.ID)
+			assert.Nil(t, err)
+
+			var codes []string
+			for _, o := range overrides {
+				codes = append(codes, o.Code)
+			}
+
+			assert.Equal(t, tc.expectedCodes, codes)
+		})
+	}
+}
+
+// interleavedProduceService runs between once, after the first item it gets is read, so a change can be made between
+// the item being read and the override of it being written.
+type interleavedProduceService struct {
+	testProduceService
+	between func()
+}
+
+func (p *interleavedProduceService) Get(produceCode string) (produce.Item, error) {
+	item, err := p.testProduceService.Get(produceCode)
+	if p.between != nil {
+		between := p.between
+		p.between = nil
+		between()
+	}
+
+	return item, err
+}
+
+func TestService_SetOverride_version(t *testing.T) {
+	s, store, _ := newTestService(t)
+
+	_, err := s.SetOverride(Override{StoreID: store.ID, Code: "A12T-4GH7-QPL9-3N4M", Available: boolPtr(false)})
+	assert.Nil(t, err)
+
+	stored, err := s.Get(store.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, store.Version+1, stored.Version)
+	assert.Equal(t, store.Name, stored.Name)
+
+	assert.Nil(t, s.RemoveOverride(store.ID, "A12T-4GH7-QPL9-3N4M"))
+	stored, err = s.Get(store.ID)
+	assert.Nil(t, err)
+	assert.Equal(t, store.Version+2, stored.Version)
+
+	assert.Equal(t, ramdb.ErrNoRecord, s.RemoveOverride(store.ID, "A12T-4GH7-QPL9-3N4M"))
+	assert.Equal(t, ramdb.ErrNoRecord, s.RemoveOverride("none", "A12T-4GH7-QPL9-3N4M"))
+}
+
+func TestService_Items(t *testing.T) {
+	s, store, _ := newTestService(t)
+	other, err := s.Create(Store{Name: "Paris"})
+	assert.Nil(t, err)
+
+	_, err = s.SetOverride(Override{StoreID: store.ID, Code: "E5T6-9UI3-TH15-QR88", Price: money.New(250, "USD")})
+	assert.Nil(t, err)
+	_, err = s.SetOverride(Override{StoreID: other.ID, Code: "A12T-4GH7-QPL9-3N4M", Available: boolPtr(false)})
+	assert.Nil(t, err)
+
+	items, next, err := s.Items(store.ID, produce.ListOptions{})
+	assert.Nil(t, err)
+	assert.Empty(t, next)
+	assert.Len(t, items, 2)
+	assert.Equal(t, money.New(346, "USD"), items[0].Price)
+	assert.True(t, items[0].Available)
+	assert.Equal(t, money.New(250, "USD"), items[1].Price)
+
+	items, _, err = s.Items(other.ID, produce.ListOptions{})
+	assert.Nil(t, err)
+	assert.False(t, items[0].Available)
+	assert.Equal(t, money.New(299, "USD"), items[1].Price)
+
+	_, _, err = s.Items("none", produce.ListOptions{})
+	assert.Equal(t, ramdb.ErrNoRecord, err)
+}
+
+func TestService_Remove(t *testing.T) {
+	s, store, _ := newTestService(t)
+
+	_, err := s.SetOverride(Override{StoreID: store.ID, Code: "A12T-4GH7-QPL9-3N4M", Available: boolPtr(false)})
+	assert.Nil(t, err)
+
+	assert.Nil(t, s.Remove(store.ID))
+
+	_, err = s.Get(store.ID)
+	assert.Equal(t, ramdb.ErrNoRecord, err)
+
+	overrides, err := s.Overrides(store.ID)
+	assert.Nil(t, err)
+	assert.Empty(t, overrides)
+
+	other, err := s.Create(Store{Name: "Paris"})
+	assert.Nil(t, err)
+	assert.Nil(t, s.Remove(other.ID))
+}
+
+func TestService_RemoveProduce(t *testing.T) {
+	s, store, produceSvc := newTestService(t)
+	other, err := s.Create(Store{Name: "Paris"})
+	assert.Nil(t, err)
+
+	for _, id := range []string{store.ID, other.ID} {
+		_, err = s.SetOverride(Override{StoreID: id, Code: "A12T-4GH7-QPL9-3N4M", Available: boolPtr(false)})
+		assert.Nil(t, err)
+	}
+	_, err = s.SetOverride(Override{StoreID: store.ID, Code: "E5T6-9UI3-TH15-QR88", Available: boolPtr(false)})
+	assert.Nil(t, err)
+
+	assert.Nil(t, produceSvc.Remove(produce.Item{Code: "a12t-4gh7-qpl9-3n4m"}))
+
+	overrides, err := s.Overrides(store.ID)
+	assert.Nil(t, err)
+	assert.Len(t, overrides, 1)
+	assert.Equal(t, "E5T6-9UI3-TH15-QR88", overrides[0].Code)
+
+	overrides, err = s.Overrides(other.ID)
+	assert.Nil(t, err)
+	assert.Empty(t, overrides)
+}
+
+func TestService_RemoveProduce_concurrent(t *testing.T) {
+	s, store, _ := newTestService(t)
+
+	// The stores are read without the override, which is set before the transaction commits.
+	tx := s.db.Begin()
+	assert.Nil(t, s.RemoveProduce(tx, "A12T-4GH7-QPL9-3N4M", false))
+
+	_, err := s.SetOverride(Override{StoreID: store.ID, Code: "A12T-4GH7-QPL9-3N4M", Available: boolPtr(false)})
+	assert.Nil(t, err)
+	assert.Equal(t, ramdb.ErrVersionMismatch, tx.Commit())
+
+	overrides, err := s.Overrides(store.ID)
+	assert.Nil(t, err)
+	assert.Len(t, overrides, 1)
+}
@@ -0,0 +1,66 @@
+package webhooks
+
+import "time"
+
+const (
+	KeySubscriptionID = "subscription_id"
+	// KeyDeliveryID is the column deliveries are keyed by, the subscription ID, creation time and event ID joined by
+	// "@", so the deliveries of a subscription can be scanned by prefix in the order they were made.
+	KeyDeliveryID = "delivery_id"
+	// KeyDeliveryDue is the column deliveries are indexed by their status, with pending deliveries ordered by when
+	// they are next attempted and dead letters by when they were given up on.
+	KeyDeliveryDue = "delivery_due"
+)
+
+const (
+	EventProduceAdded    = "produce.added"
+	EventProduceRemoved  = "produce.removed"
+	EventProduceRepriced = "produce.repriced"
+)
+
+// SupportedEvents are the event types a subscription can receive.
+var SupportedEvents = map[string]bool{
+	EventProduceAdded:    true,
+	EventProduceRemoved:  true,
+	EventProduceRepriced: true,
+}
+
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusDead      = "dead"
+)
+
+const (
+	// DefaultMaxAttempts is the number of times a delivery is attempted before it is dead-lettered.
+	DefaultMaxAttempts = 8
+	// DefaultBackoff is how long after the first failed attempt a delivery is retried. The wait doubles after each
+	// failed attempt, up to DefaultMaxBackoff.
+	DefaultBackoff    = 10 * time.Second
+	DefaultMaxBackoff = time.Hour
+	// DefaultWorkers is how many subscriptions are posted to at once.
+	DefaultWorkers = 8
+	// DefaultRetention is how long delivered deliveries are kept before they are pruned.
+	DefaultRetention = 7 * 24 * time.Hour
+
+	// minSecretLength is the fewest characters a subscription's secret can have.
+	minSecretLength = 16
+	// maxSwapAttempts is the number of times deliveries are queued again when a subscription is changed or removed
+	// between being read and its delivery being written.
+	maxSwapAttempts = 5
+)
+
+const (
+	// timeLayout formats times in keys. It is fixed width in UTC, so keys sort in time order.
+	timeLayout = "20060102T150405.000000000Z"
+
+	duePending   = "pending@"
+	dueDelivered = "delivered@"
+	dueDead      = "dead@"
+)
+
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderSignature = "X-Webhook-Signature"
+)
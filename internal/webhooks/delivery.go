@@ -0,0 +1,481 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+	"github.com/sirupsen/logrus"
+)
+
+// Notify queues a delivery of the webhook event for a change to the produce catalogue to every subscription to its
+// type. Adds and deletes are sent as produce.added and produce.removed, and updates that change the price of an item
+// as produce.repriced. Other changes are ignored. It is retried up to maxSwapAttempts times if a subscription is
+// changed or removed before the deliveries are queued.
+func (s *service) Notify(change produce.Event) (err error) {
+	event, ok := newEvent(change)
+	if !ok {
+		return nil
+	}
+
+	event.ID, err = newID()
+	if err != nil {
+		return err
+	}
+
+	now := s.now().UTC()
+	event.OccurredAt = now
+
+	for attempt := 0; attempt < maxSwapAttempts; attempt++ {
+		var subs []Subscription
+		subs, err = s.subscriptions()
+		if err != nil {
+			return err
+		}
+
+		err = s.notify(event, now, subs)
+		if !errors.Is(err, ramdb.ErrVersionMismatch) && !errors.Is(err, ramdb.ErrNoRecord) {
+			return err
+		}
+	}
+
+	return err
+}
+
+// notify queues a delivery of event to each of subs to its type in one transaction, which fails with
+// ramdb.ErrNoRecord or ramdb.ErrVersionMismatch if any of them has been removed or changed since it was read.
+func (s *service) notify(event Event, now time.Time, subs []Subscription) error {
+	tx := s.deliveries.Begin()
+	for _, sub := range subs {
+		if !sub.subscribes(event.Type) {
+			continue
+		}
+
+		subRec, err := ramdb.NewRecord(sub.ID, KeySubscriptionID, nil)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		err = s.db.Join(tx).Check(subRec, sub.Version)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		rec, err := newDeliveryRecord(Delivery{
+			ID:             sub.ID + "@" + now.Format(timeLayout) + "@" + event.ID,
+			SubscriptionID: sub.ID,
+			Event:          event,
+			Status:         StatusPending,
+			Attempts:       []Attempt{},
+			NextAttemptAt:  &now,
+			CreatedAt:      now,
+		})
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		err = tx.Insert(rec)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// DeliverDue attempts every pending delivery due at or before now. Deliveries to different subscriptions are posted
+// in parallel by up to DefaultWorkers workers, so a slow subscriber only holds up its own deliveries, and the
+// deliveries to each subscription are posted one at a time in the order they are due. A delivery that fails is retried
+// after a backoff that doubles with every attempt, and is dead-lettered once it has been attempted maxAttempts times.
+func (s *service) DeliverDue(now time.Time) error {
+	recs, err := s.deliveries.Scan(KeyDeliveryDue, ramdb.ScanOptions{
+		Prefix: duePending,
+		To:     duePending + now.UTC().Format(timeLayout) + "\x00",
+	})
+	if err != nil {
+		return err
+	}
+
+	type pending struct {
+		delivery Delivery
+		version  uint64
+	}
+
+	var order []string
+	due := make(map[string][]pending)
+	for _, rec := range recs {
+		var d Delivery
+		err = rec.Deserialize(&d)
+		if err != nil {
+			return err
+		}
+
+		if _, found := due[d.SubscriptionID]; !found {
+			order = append(order, d.SubscriptionID)
+		}
+		due[d.SubscriptionID] = append(due[d.SubscriptionID], pending{d, rec.Version()})
+	}
+
+	var wg sync.WaitGroup
+	var once sync.Once
+	var failed error
+	workers := make(chan struct{}, s.workers)
+	for _, id := range order {
+		wg.Add(1)
+		workers <- struct{}{}
+		go func(deliveries []pending) {
+			defer wg.Done()
+			defer func() { <-workers }()
+
+			for _, p := range deliveries {
+				err := s.attempt(p.delivery, p.version, now)
+				if err != nil {
+					once.Do(func() { failed = fmt.Errorf("could not attempt delivery %s: %w", p.delivery.ID, err) })
+					return
+				}
+			}
+		}(due[id])
+	}
+
+	wg.Wait()
+	return failed
+}
+
+// Prune removes every delivered delivery last attempted before before, and returns how many were removed. Pending and
+// dead-lettered deliveries are kept.
+func (s *service) Prune(before time.Time) (int, error) {
+	recs, err := s.deliveries.Scan(KeyDeliveryDue, ramdb.ScanOptions{
+		Prefix: dueDelivered,
+		To:     dueDelivered + before.UTC().Format(timeLayout),
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	tx := s.deliveries.Begin()
+	for _, rec := range recs {
+		err = tx.Delete(rec)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return 0, err
+	}
+
+	return len(recs), nil
+}
+
+// Deliveries returns the deliveries to the subscription with id in the order they were made.
+func (s *service) Deliveries(id string) ([]Delivery, error) {
+	_, err := s.subscription(id)
+	if err != nil {
+		return nil, err
+	}
+
+	recs, err := s.deliveries.Scan(KeyDeliveryID, ramdb.ScanOptions{Prefix: id + "@"})
+	if err != nil {
+		return nil, err
+	}
+
+	return deserializeDeliveries(recs)
+}
+
+// DeadLetters returns every delivery that was given up on, in the order they were given up on.
+func (s *service) DeadLetters() ([]Delivery, error) {
+	recs, err := s.deliveries.Scan(KeyDeliveryDue, ramdb.ScanOptions{Prefix: dueDead})
+	if err != nil {
+		return nil, err
+	}
+
+	return deserializeDeliveries(recs)
+}
+
+// Run queues deliveries for the changes to the produce catalogue as they are made, and attempts the deliveries that
+// are due as they are queued and every interval, until ctx is done. Changes are read by their own goroutine, so a slow
+// subscriber never holds up the watch. Delivered deliveries are removed once they are older than retention. Errors are
+// logged and retried on the next tick.
+func (s *service) Run(ctx context.Context, interval, retention time.Duration, logger *logrus.Logger) {
+	queued := make(chan struct{}, 1)
+	go s.queue(ctx, interval, queued, logger)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-queued:
+		case <-ticker.C:
+			_, err := s.Prune(s.now().Add(-retention))
+			if err != nil {
+				logger.Errorf("could not prune webhook deliveries: %v", err)
+			}
+		}
+
+		err := s.DeliverDue(s.now())
+		if err != nil {
+			logger.Errorf("could not deliver webhooks: %v", err)
+		}
+	}
+}
+
+// queue queues deliveries for the changes to the produce catalogue until ctx is done, and signals queued after each
+// change without waiting for it to be received. The watch is retried every interval if it fails.
+func (s *service) queue(ctx context.Context, interval time.Duration, queued chan<- struct{}, logger *logrus.Logger) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	var next uint64
+	changes := s.watch(ctx, next, logger)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case change, ok := <-changes:
+			if !ok {
+				if ctx.Err() != nil {
+					return
+				}
+
+				// The watch fell behind, so it is resumed from the change after the last one received.
+				changes = s.watch(ctx, next, logger)
+				continue
+			}
+
+			next = change.ID + 1
+			err := s.Notify(change)
+			if err != nil {
+				logger.Errorf("could not queue webhooks for produce change %d: %v", change.ID, err)
+				continue
+			}
+
+			select {
+			case queued <- struct{}{}:
+			default:
+			}
+		case <-ticker.C:
+			if changes == nil {
+				changes = s.watch(ctx, next, logger)
+			}
+		}
+	}
+}
+
+// watch returns the changes to the produce catalogue from the change with ID from, or from now if they are no longer
+// kept. It returns nil if the catalogue can't be watched.
+func (s *service) watch(ctx context.Context, from uint64, logger *logrus.Logger) <-chan produce.Event {
+	changes, err := s.produceSvc.Watch(ctx, from)
+	if errors.Is(err, ramdb.ErrSequenceUnavailable) {
+		logger.Warnf("produce changes from %d are no longer kept, webhooks will not be sent for them", from)
+		changes, err = s.produceSvc.Watch(ctx, 0)
+	}
+
+	if err != nil {
+		logger.Errorf("could not watch produce changes: %v", err)
+		return nil
+	}
+
+	return changes
+}
+
+// attempt posts d to its subscription and stores the outcome, unless the delivery has changed since version. A
+// delivery whose subscription has been removed is deleted instead, as it was queued while the subscription and its
+// deliveries were being removed.
+func (s *service) attempt(d Delivery, version uint64, now time.Time) error {
+	sub, err := s.subscription(d.SubscriptionID)
+	if errors.Is(err, ramdb.ErrNoRecord) {
+		rec, err := ramdb.NewRecord(d.ID, KeyDeliveryID, nil)
+		if err != nil {
+			return err
+		}
+
+		err = s.deliveries.Delete(rec)
+		if errors.Is(err, ramdb.ErrNoRecord) {
+			return nil
+		}
+
+		return err
+	}
+	if err != nil {
+		return err
+	}
+
+	attempt := s.post(sub, d, now.UTC())
+	d.Attempts = append(d.Attempts, attempt)
+	d.NextAttemptAt = nil
+
+	switch {
+	case attempt.Error == "" && attempt.StatusCode >= 200 && attempt.StatusCode < 300:
+		d.Status = StatusDelivered
+	case len(d.Attempts) >= s.maxAttempts:
+		d.Status = StatusDead
+	default:
+		next := attempt.At.Add(s.backoffAfter(len(d.Attempts)))
+		d.NextAttemptAt = &next
+	}
+
+	rec, err := newDeliveryRecord(d)
+	if err != nil {
+		return err
+	}
+
+	// The delivery is only missing or changed if its subscription was removed while it was being posted.
+	err = s.deliveries.CompareAndSwap(rec, version)
+	if errors.Is(err, ramdb.ErrNoRecord) || errors.Is(err, ramdb.ErrVersionMismatch) {
+		return nil
+	}
+
+	return err
+}
+
+// post sends the event of d to the subscription and returns the attempt.
+func (s *service) post(sub Subscription, d Delivery, now time.Time) Attempt {
+	attempt := Attempt{At: now}
+
+	body, err := json.Marshal(d.Event)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	req, err := http.NewRequest(http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, d.Event.Type)
+	req.Header.Set(HeaderDelivery, d.ID)
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, now, body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		attempt.Error = err.Error()
+		return attempt
+	}
+	defer resp.Body.Close()
+
+	// The body is read so the connection can be reused, but only up to a limit.
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	attempt.StatusCode = resp.StatusCode
+	return attempt
+}
+
+// backoffAfter returns how long to wait before retrying a delivery that has failed attempts times.
+func (s *service) backoffAfter(attempts int) time.Duration {
+	wait := s.backoff
+	for i := 1; i < attempts && wait < s.maxBackoff; i++ {
+		wait *= 2
+	}
+
+	if wait > s.maxBackoff {
+		wait = s.maxBackoff
+	}
+
+	return wait
+}
+
+// Sign returns the signature header of a payload posted at t with secret, in the form "t=<unix time>,v1=<signature>".
+// The signature is the hex encoded HMAC-SHA256 of the unix time and the body joined by a ".", so receivers can check
+// both where the payload came from and that it is recent.
+func Sign(secret string, t time.Time, body []byte) string {
+	timestamp := strconv.FormatInt(t.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+
+	return fmt.Sprintf("t=%s,v1=%s", timestamp, hex.EncodeToString(mac.Sum(nil)))
+}
+
+// newEvent returns the webhook event for a change to the produce catalogue, and false if the change isn't sent.
+func newEvent(change produce.Event) (Event, bool) {
+	event := Event{Item: change.Item}
+
+	switch change.Type {
+	case produce.EventAdd:
+		event.Type = EventProduceAdded
+	case produce.EventDelete:
+		event.Type = EventProduceRemoved
+	case produce.EventUpdate:
+		if change.Previous == nil || samePrice(change.Previous.Price, change.Item.Price) {
+			return Event{}, false
+		}
+
+		event.Type = EventProduceRepriced
+		event.PreviousPrice = change.Previous.Price
+	default:
+		return Event{}, false
+	}
+
+	return event, true
+}
+
+// samePrice returns true if a and b are both nil or are the same amount of the same currency.
+func samePrice(a, b *money.Money) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return a.Currency().Code == b.Currency().Code && a.Amount() == b.Amount()
+}
+
+// newDeliveryRecord returns the record storing d, keyed by ID and by when it is due.
+func newDeliveryRecord(d Delivery) (*ramdb.Record, error) {
+	rec, err := ramdb.NewRecord(d.ID, KeyDeliveryID, d)
+	if err != nil {
+		return nil, err
+	}
+
+	var due string
+	switch d.Status {
+	case StatusDelivered:
+		due = dueDelivered + d.Attempts[len(d.Attempts)-1].At.UTC().Format(timeLayout)
+	case StatusPending:
+		due = duePending + d.NextAttemptAt.UTC().Format(timeLayout)
+	case StatusDead:
+		due = dueDead + d.Attempts[len(d.Attempts)-1].At.UTC().Format(timeLayout)
+	}
+
+	return rec.WithKey(KeyDeliveryDue, due), nil
+}
+
+// deserializeDeliveries deserializes each record into a Delivery.
+func deserializeDeliveries(recs []*ramdb.Record) (deliveries []Delivery, err error) {
+	for _, rec := range recs {
+		var d Delivery
+		err = rec.Deserialize(&d)
+		if err != nil {
+			return nil, err
+		}
+
+		deliveries = append(deliveries, d)
+	}
+
+	return
+}
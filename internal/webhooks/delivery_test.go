@@ -0,0 +1,445 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+)
+
+var testItem = produce.Item{Code: "A12T-4GH7-QPL9-3N4M", Name: "Lettuce", Price: money.New(346, "USD"), Unit: produce.UnitEach}
+
+// receiver is a webhook endpoint responding with status and recording the requests it was sent.
+type receiver struct {
+	*httptest.Server
+
+	mutex    sync.Mutex
+	status   int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+// newReceiver starts a receiver responding with status.
+func newReceiver(t *testing.T, status int) *receiver {
+	rcv := &receiver{status: status}
+	rcv.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+
+		rcv.mutex.Lock()
+		defer rcv.mutex.Unlock()
+
+		rcv.requests = append(rcv.requests, r)
+		rcv.bodies = append(rcv.bodies, body)
+		w.WriteHeader(rcv.status)
+	}))
+	t.Cleanup(rcv.Close)
+
+	return rcv
+}
+
+// newSlowReceiver starts a webhook endpoint that doesn't respond until the test ends.
+func newSlowReceiver(t *testing.T) *httptest.Server {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(release) })
+
+	return srv
+}
+
+// received returns the number of requests the receiver was sent.
+func (rcv *receiver) received() int {
+	rcv.mutex.Lock()
+	defer rcv.mutex.Unlock()
+
+	return len(rcv.requests)
+}
+
+func TestService_Notify(t *testing.T) {
+	tests := []struct {
+		test          string
+		change        produce.Event
+		expectedEvent *Event
+	}{
+		{
+			test:          "it should send adds as produce.added",
+			change:        produce.Event{ID: 1, Type: produce.EventAdd, Item: testItem},
+			expectedEvent: &Event{Type: EventProduceAdded, Item: testItem},
+		},
+		{
+			test:          "it should send deletes as produce.removed",
+			change:        produce.Event{ID: 1, Type: produce.EventDelete, Item: testItem},
+			expectedEvent: &Event{Type: EventProduceRemoved, Item: testItem},
+		},
+		{
+			test: "it should send price changes as produce.repriced",
+			change: produce.Event{ID: 1, Type: produce.EventUpdate, Item: testItem, Previous: &produce.Item{
+				Code: testItem.Code, Name: testItem.Name, Price: money.New(299, "USD"),
+			}},
+			expectedEvent: &Event{Type: EventProduceRepriced, Item: testItem, PreviousPrice: money.New(299, "USD")},
+		},
+		{
+			test: "it should ignore updates that don't change the price",
+			change: produce.Event{ID: 1, Type: produce.EventUpdate, Item: testItem, Previous: &produce.Item{
+				Code: testItem.Code, Name: "Iceberg Lettuce", Price: money.New(346, "USD"),
+			}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			svc, _ := newTestService(t)
+
+			all, err := svc.Create(Subscription{URL: "https://erp.example.com/all", Events: []string{EventProduceAdded, EventProduceRemoved, EventProduceRepriced}, Secret: testSecret})
+			assert.Nil(t, err)
+			none, err := svc.Create(Subscription{URL: "https://erp.example.com/none", Events: []string{"produce.added"}, Secret: testSecret})
+			assert.Nil(t, err)
+			none.Events = []string{EventProduceRemoved}
+			if tc.change.Type == produce.EventDelete {
+				none.Events = []string{EventProduceAdded}
+			}
+			_, err = svc.Update(none)
+			assert.Nil(t, err)
+
+			assert.Nil(t, svc.Notify(tc.change))
+
+			deliveries, err := svc.Deliveries(none.ID)
+			assert.Nil(t, err)
+			if tc.change.Type != produce.EventUpdate {
+				assert.Empty(t, deliveries)
+			}
+
+			deliveries, err = svc.Deliveries(all.ID)
+			assert.Nil(t, err)
+			if tc.expectedEvent == nil {
+				assert.Empty(t, deliveries)
+				return
+			}
+
+			assert.Len(t, deliveries, 1)
+			d := deliveries[0]
+			assert.Equal(t, StatusPending, d.Status)
+			assert.Equal(t, all.ID, d.SubscriptionID)
+			assert.Equal(t, testNow, *d.NextAttemptAt)
+			assert.Empty(t, d.Attempts)
+			assert.NotEmpty(t, d.Event.ID)
+
+			d.Event.ID = ""
+			tc.expectedEvent.OccurredAt = testNow
+			assert.Equal(t, *tc.expectedEvent, d.Event)
+		})
+	}
+}
+
+func TestService_notify(t *testing.T) {
+	t.Run("it should not queue deliveries for a subscription removed since it was read", func(t *testing.T) {
+		svc, _ := newTestService(t)
+
+		sub, err := svc.Create(Subscription{URL: "https://erp.example.com/hooks", Events: []string{EventProduceAdded}, Secret: testSecret})
+		assert.Nil(t, err)
+
+		subs, err := svc.subscriptions()
+		assert.Nil(t, err)
+		assert.Nil(t, svc.Remove(sub.ID))
+
+		event, _ := newEvent(produce.Event{ID: 1, Type: produce.EventAdd, Item: testItem})
+		assert.Equal(t, ramdb.ErrNoRecord, svc.notify(event, testNow, subs))
+
+		recs, err := svc.deliveries.Scan(KeyDeliveryID, ramdb.ScanOptions{})
+		assert.Nil(t, err)
+		assert.Empty(t, recs)
+	})
+}
+
+func TestService_DeliverDue(t *testing.T) {
+	t.Run("it should post signed events", func(t *testing.T) {
+		svc, _ := newTestService(t)
+		rcv := newReceiver(t, http.StatusNoContent)
+
+		sub, err := svc.Create(Subscription{URL: rcv.URL, Events: []string{EventProduceAdded}, Secret: testSecret})
+		assert.Nil(t, err)
+
+		assert.Nil(t, svc.Notify(produce.Event{ID: 1, Type: produce.EventAdd, Item: testItem}))
+		assert.Nil(t, svc.DeliverDue(testNow))
+		assert.Equal(t, 1, rcv.received())
+
+		deliveries, err := svc.Deliveries(sub.ID)
+		assert.Nil(t, err)
+		assert.Len(t, deliveries, 1)
+		d := deliveries[0]
+		assert.Equal(t, StatusDelivered, d.Status)
+		assert.Nil(t, d.NextAttemptAt)
+		assert.Equal(t, []Attempt{{At: testNow, StatusCode: http.StatusNoContent}}, d.Attempts)
+
+		r, body := rcv.requests[0], rcv.bodies[0]
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, EventProduceAdded, r.Header.Get(HeaderEvent))
+		assert.Equal(t, d.ID, r.Header.Get(HeaderDelivery))
+		assert.Equal(t, Sign(testSecret, testNow, body), r.Header.Get(HeaderSignature))
+		assert.NotEqual(t, Sign("another secret!!", testNow, body), r.Header.Get(HeaderSignature))
+
+		var event Event
+		assert.Nil(t, json.Unmarshal(body, &event))
+		assert.Equal(t, d.Event, event)
+
+		// Delivered events are not sent again.
+		assert.Nil(t, svc.DeliverDue(testNow.Add(time.Hour)))
+		assert.Equal(t, 1, rcv.received())
+	})
+
+	t.Run("it should retry with exponential backoff and then dead-letter the delivery", func(t *testing.T) {
+		svc, _ := newTestService(t)
+		svc.maxAttempts = 3
+		rcv := newReceiver(t, http.StatusInternalServerError)
+
+		sub, err := svc.Create(Subscription{URL: rcv.URL, Events: []string{EventProduceAdded}, Secret: testSecret})
+		assert.Nil(t, err)
+		assert.Nil(t, svc.Notify(produce.Event{ID: 1, Type: produce.EventAdd, Item: testItem}))
+
+		assert.Nil(t, svc.DeliverDue(testNow))
+		deliveries, err := svc.Deliveries(sub.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, StatusPending, deliveries[0].Status)
+		assert.Equal(t, testNow.Add(DefaultBackoff), *deliveries[0].NextAttemptAt)
+
+		// It is not retried before it is due.
+		assert.Nil(t, svc.DeliverDue(testNow.Add(DefaultBackoff-time.Second)))
+		assert.Equal(t, 1, rcv.received())
+
+		assert.Nil(t, svc.DeliverDue(testNow.Add(DefaultBackoff)))
+		assert.Equal(t, 2, rcv.received())
+		deliveries, err = svc.Deliveries(sub.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, testNow.Add(3*DefaultBackoff), *deliveries[0].NextAttemptAt)
+
+		dead, err := svc.DeadLetters()
+		assert.Nil(t, err)
+		assert.Empty(t, dead)
+
+		assert.Nil(t, svc.DeliverDue(testNow.Add(3*DefaultBackoff)))
+		assert.Equal(t, 3, rcv.received())
+
+		dead, err = svc.DeadLetters()
+		assert.Nil(t, err)
+		assert.Len(t, dead, 1)
+		assert.Equal(t, StatusDead, dead[0].Status)
+		assert.Len(t, dead[0].Attempts, 3)
+		assert.Equal(t, http.StatusInternalServerError, dead[0].Attempts[2].StatusCode)
+		assert.Nil(t, dead[0].NextAttemptAt)
+
+		assert.Nil(t, svc.DeliverDue(testNow.Add(24*time.Hour)))
+		assert.Equal(t, 3, rcv.received())
+	})
+
+	t.Run("it should record attempts that get no response", func(t *testing.T) {
+		svc, _ := newTestService(t)
+		rcv := newReceiver(t, http.StatusOK)
+		rcv.Close()
+
+		sub, err := svc.Create(Subscription{URL: rcv.URL, Events: []string{EventProduceAdded}, Secret: testSecret})
+		assert.Nil(t, err)
+		assert.Nil(t, svc.Notify(produce.Event{ID: 1, Type: produce.EventAdd, Item: testItem}))
+		assert.Nil(t, svc.DeliverDue(testNow))
+
+		deliveries, err := svc.Deliveries(sub.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, StatusPending, deliveries[0].Status)
+		assert.NotEmpty(t, deliveries[0].Attempts[0].Error)
+	})
+
+	t.Run("it should delete deliveries whose subscription has been removed", func(t *testing.T) {
+		svc, _ := newTestService(t)
+		rcv := newReceiver(t, http.StatusOK)
+
+		sub, err := svc.Create(Subscription{URL: rcv.URL, Events: []string{EventProduceAdded}, Secret: testSecret})
+		assert.Nil(t, err)
+
+		// The delivery was queued after the subscription's deliveries were read to be removed with it.
+		rec, err := newDeliveryRecord(Delivery{
+			ID:             "removed@" + testNow.Format(timeLayout) + "@event",
+			SubscriptionID: "removed",
+			Event:          Event{ID: "event", Type: EventProduceAdded, Item: testItem, OccurredAt: testNow},
+			Status:         StatusPending,
+			Attempts:       []Attempt{},
+			NextAttemptAt:  &testNow,
+			CreatedAt:      testNow,
+		})
+		assert.Nil(t, err)
+		assert.Nil(t, svc.deliveries.Insert(rec))
+		assert.Nil(t, svc.Notify(produce.Event{ID: 1, Type: produce.EventAdd, Item: testItem}))
+
+		assert.Nil(t, svc.DeliverDue(testNow))
+		assert.Equal(t, 1, rcv.received())
+
+		recs, err := svc.deliveries.Scan(KeyDeliveryID, ramdb.ScanOptions{})
+		assert.Nil(t, err)
+		assert.Len(t, recs, 1)
+
+		deliveries, err := svc.Deliveries(sub.ID)
+		assert.Nil(t, err)
+		assert.Len(t, deliveries, 1)
+	})
+
+	t.Run("it should not hold up other subscriptions behind a slow one", func(t *testing.T) {
+		svc, _ := newTestService(t)
+		slow := newSlowReceiver(t)
+		rcv := newReceiver(t, http.StatusOK)
+
+		_, err := svc.Create(Subscription{URL: slow.URL, Events: []string{EventProduceAdded}, Secret: testSecret})
+		assert.Nil(t, err)
+		_, err = svc.Create(Subscription{URL: rcv.URL, Events: []string{EventProduceAdded}, Secret: testSecret})
+		assert.Nil(t, err)
+		assert.Nil(t, svc.Notify(produce.Event{ID: 1, Type: produce.EventAdd, Item: testItem}))
+
+		go func() { _ = svc.DeliverDue(testNow) }()
+
+		assert.Eventually(t, func() bool { return rcv.received() == 1 }, 500*time.Millisecond, 5*time.Millisecond)
+	})
+}
+
+func TestService_Prune(t *testing.T) {
+	t.Run("it should only remove deliveries delivered before the cutoff", func(t *testing.T) {
+		svc, _ := newTestService(t)
+		svc.maxAttempts = 1
+		rcv := newReceiver(t, http.StatusOK)
+		failing := newReceiver(t, http.StatusInternalServerError)
+
+		sub, err := svc.Create(Subscription{URL: rcv.URL, Events: []string{EventProduceAdded}, Secret: testSecret})
+		assert.Nil(t, err)
+		dead, err := svc.Create(Subscription{URL: failing.URL, Events: []string{EventProduceAdded}, Secret: testSecret})
+		assert.Nil(t, err)
+		assert.Nil(t, svc.Notify(produce.Event{ID: 1, Type: produce.EventAdd, Item: testItem}))
+		assert.Nil(t, svc.DeliverDue(testNow))
+
+		later := testNow.Add(time.Hour)
+		svc.now = func() time.Time { return later }
+		assert.Nil(t, svc.Notify(produce.Event{ID: 2, Type: produce.EventDelete, Item: testItem}))
+		assert.Nil(t, svc.Notify(produce.Event{ID: 3, Type: produce.EventAdd, Item: testItem}))
+		assert.Nil(t, svc.DeliverDue(later))
+
+		pruned, err := svc.Prune(later)
+		assert.Nil(t, err)
+		assert.Equal(t, 1, pruned)
+
+		deliveries, err := svc.Deliveries(sub.ID)
+		assert.Nil(t, err)
+		assert.Len(t, deliveries, 1)
+		assert.Equal(t, later, deliveries[0].CreatedAt)
+
+		deadLetters, err := svc.DeadLetters()
+		assert.Nil(t, err)
+		assert.Len(t, deadLetters, 2)
+		assert.Equal(t, dead.ID, deadLetters[0].SubscriptionID)
+	})
+}
+
+func TestService_backoffAfter(t *testing.T) {
+	svc, _ := newTestService(t)
+
+	assert.Equal(t, DefaultBackoff, svc.backoffAfter(1))
+	assert.Equal(t, 2*DefaultBackoff, svc.backoffAfter(2))
+	assert.Equal(t, 4*DefaultBackoff, svc.backoffAfter(3))
+	assert.Equal(t, DefaultMaxBackoff, svc.backoffAfter(100))
+}
+
+func TestService_Remove(t *testing.T) {
+	t.Run("it should remove the subscription and its deliveries", func(t *testing.T) {
+		svc, _ := newTestService(t)
+
+		sub, err := svc.Create(Subscription{URL: "https://erp.example.com/hooks", Events: []string{EventProduceAdded}, Secret: testSecret})
+		assert.Nil(t, err)
+		assert.Nil(t, svc.Notify(produce.Event{ID: 1, Type: produce.EventAdd, Item: testItem}))
+
+		assert.Nil(t, svc.Remove(sub.ID))
+
+		_, err = svc.Get(sub.ID)
+		assert.Equal(t, ramdb.ErrNoRecord, err)
+
+		recs, err := svc.deliveries.Scan(KeyDeliveryID, ramdb.ScanOptions{})
+		assert.Nil(t, err)
+		assert.Empty(t, recs)
+	})
+}
+
+func TestService_Run(t *testing.T) {
+	t.Run("it should post changes to the catalogue as they are made", func(t *testing.T) {
+		svc, produceSvc := newTestService(t)
+		svc.now = time.Now
+		rcv := newReceiver(t, http.StatusOK)
+
+		_, err := svc.Create(Subscription{URL: rcv.URL, Events: []string{EventProduceAdded, EventProduceRepriced}, Secret: testSecret})
+		assert.Nil(t, err)
+
+		logger := logrus.New()
+		logger.SetOutput(ioutil.Discard)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			svc.Run(ctx, time.Hour, DefaultRetention, logger)
+		}()
+
+		// Run only sends changes made after it starts watching.
+		time.Sleep(20 * time.Millisecond)
+
+		assert.Nil(t, produceSvc.Add([]produce.Item{testItem}))
+		_, err = produceSvc.Patch(testItem.Code, produce.ItemPatch{Price: money.New(299, "USD")})
+		assert.Nil(t, err)
+
+		assert.Eventually(t, func() bool { return rcv.received() == 2 }, time.Second, 5*time.Millisecond)
+
+		cancel()
+		<-done
+
+		rcv.mutex.Lock()
+		defer rcv.mutex.Unlock()
+		assert.Equal(t, EventProduceAdded, rcv.requests[0].Header.Get(HeaderEvent))
+		assert.Equal(t, EventProduceRepriced, rcv.requests[1].Header.Get(HeaderEvent))
+	})
+
+	t.Run("it should keep queueing changes while a subscriber is slow", func(t *testing.T) {
+		svc, produceSvc := newTestService(t)
+		svc.now = time.Now
+		slow := newSlowReceiver(t)
+
+		sub, err := svc.Create(Subscription{URL: slow.URL, Events: []string{EventProduceRepriced}, Secret: testSecret})
+		assert.Nil(t, err)
+		assert.Nil(t, produceSvc.Add([]produce.Item{testItem}))
+
+		logger := logrus.New()
+		logger.SetOutput(ioutil.Discard)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go svc.Run(ctx, time.Hour, DefaultRetention, logger)
+
+		time.Sleep(20 * time.Millisecond)
+
+		for _, price := range []int64{299, 249, 199} {
+			_, err = produceSvc.Patch(testItem.Code, produce.ItemPatch{Price: money.New(price, "USD")})
+			assert.Nil(t, err)
+		}
+
+		// The first delivery is still being posted while the later changes are queued.
+		assert.Eventually(t, func() bool {
+			deliveries, err := svc.Deliveries(sub.ID)
+			return err == nil && len(deliveries) == 3
+		}, time.Second, 5*time.Millisecond)
+	})
+}
@@ -0,0 +1,7 @@
+package webhooks
+
+import "errors"
+
+var (
+	ErrInvalidSubscription = errors.New("invalid webhook subscription")
+)
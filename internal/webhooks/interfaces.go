@@ -0,0 +1,11 @@
+package webhooks
+
+import (
+	"context"
+
+	"github.com/davidlick/supermarket-api/internal/produce"
+)
+
+type ProduceService interface {
+	Watch(ctx context.Context, from uint64) (<-chan produce.Event, error)
+}
@@ -0,0 +1,49 @@
+package webhooks
+
+import (
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/davidlick/supermarket-api/internal/produce"
+)
+
+// Subscription is an endpoint that events of the types in Events are posted to.
+type Subscription struct {
+	ID     string   `json:"id"`
+	URL    string   `json:"url"`
+	Events []string `json:"events"`
+	// Secret signs every payload posted to URL. It is never read back from the service.
+	Secret    string    `json:"secret,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+	Version   uint64    `json:"version,omitempty"`
+}
+
+// Event is the payload posted to subscriptions.
+type Event struct {
+	ID         string       `json:"id"`
+	Type       string       `json:"type"`
+	OccurredAt time.Time    `json:"occurred_at"`
+	Item       produce.Item `json:"item"`
+	// PreviousPrice is the price of the item before a produce.repriced event.
+	PreviousPrice *money.Money `json:"previous_price,omitempty"`
+}
+
+// Delivery is an Event being or having been posted to a subscription, and the log of every attempt to post it.
+type Delivery struct {
+	ID             string    `json:"id"`
+	SubscriptionID string    `json:"subscription_id"`
+	Event          Event     `json:"event"`
+	Status         string    `json:"status"`
+	Attempts       []Attempt `json:"attempts"`
+	// NextAttemptAt is when a pending delivery is next attempted.
+	NextAttemptAt *time.Time `json:"next_attempt_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+}
+
+// Attempt is one try at posting a Delivery. A failed attempt has the status code responded with, or the error if
+// there was no response.
+type Attempt struct {
+	At         time.Time `json:"at"`
+	StatusCode int       `json:"status_code,omitempty"`
+	Error      string    `json:"error,omitempty"`
+}
@@ -0,0 +1,254 @@
+package webhooks
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/davidlick/supermarket-api/internal/interfaces"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+)
+
+type service struct {
+	db         interfaces.RamDB
+	deliveries interfaces.RamDB
+	produceSvc ProduceService
+	client     *http.Client
+	now        func() time.Time
+
+	maxAttempts int
+	backoff     time.Duration
+	maxBackoff  time.Duration
+	workers     int
+}
+
+// NewService creates a new webhook service storing subscriptions in db and their deliveries in deliveries, which posts
+// the changes watched from produceSvc with client.
+func NewService(db, deliveries interfaces.RamDB, produceSvc ProduceService, client *http.Client) *service {
+	return &service{
+		db:          db,
+		deliveries:  deliveries,
+		produceSvc:  produceSvc,
+		client:      client,
+		now:         time.Now,
+		maxAttempts: DefaultMaxAttempts,
+		backoff:     DefaultBackoff,
+		maxBackoff:  DefaultMaxBackoff,
+		workers:     DefaultWorkers,
+	}
+}
+
+// Create stores a new subscription under a generated ID and returns it as stored, without its secret. It returns an
+// error matching ErrInvalidSubscription if the subscription is invalid.
+func (s *service) Create(sub Subscription) (Subscription, error) {
+	err := validate(sub)
+	if err != nil {
+		return Subscription{}, err
+	}
+
+	sub.ID, err = newID()
+	if err != nil {
+		return Subscription{}, err
+	}
+	sub.CreatedAt = s.now().UTC()
+
+	rec, err := newRecord(sub)
+	if err != nil {
+		return Subscription{}, err
+	}
+
+	err = s.db.Insert(rec)
+	if err != nil {
+		return Subscription{}, err
+	}
+
+	sub.Version = 1
+	return redact(sub), nil
+}
+
+// Update replaces the URL, events and, if it is set, the secret of the subscription with the same ID. If sub has a
+// Version, it returns ramdb.ErrVersionMismatch unless the stored subscription is at that version.
+func (s *service) Update(sub Subscription) (Subscription, error) {
+	stored, err := s.subscription(sub.ID)
+	if err != nil {
+		return Subscription{}, err
+	}
+
+	if sub.Secret == "" {
+		sub.Secret = stored.Secret
+	}
+	if sub.Version == 0 {
+		sub.Version = stored.Version
+	}
+	sub.CreatedAt = stored.CreatedAt
+
+	err = validate(sub)
+	if err != nil {
+		return Subscription{}, err
+	}
+
+	rec, err := newRecord(sub)
+	if err != nil {
+		return Subscription{}, err
+	}
+
+	err = s.db.CompareAndSwap(rec, sub.Version)
+	if err != nil {
+		return Subscription{}, err
+	}
+
+	sub.Version++
+	return redact(sub), nil
+}
+
+// Remove removes the subscription with id and its deliveries.
+func (s *service) Remove(id string) error {
+	deliveries, err := s.Deliveries(id)
+	if err != nil {
+		return err
+	}
+
+	tx := s.deliveries.Begin()
+	for _, d := range deliveries {
+		rec, err := ramdb.NewRecord(d.ID, KeyDeliveryID, nil)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+
+		err = tx.Delete(rec)
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	rec, err := ramdb.NewRecord(id, KeySubscriptionID, nil)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	err = s.db.Join(tx).Delete(rec)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Get returns the subscription with id, without its secret.
+func (s *service) Get(id string) (Subscription, error) {
+	sub, err := s.subscription(id)
+	return redact(sub), err
+}
+
+// All returns every subscription sorted by ID, without their secrets.
+func (s *service) All() (subs []Subscription, err error) {
+	all, err := s.subscriptions()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, sub := range all {
+		subs = append(subs, redact(sub))
+	}
+
+	return
+}
+
+// subscription returns the subscription with id, including its secret.
+func (s *service) subscription(id string) (sub Subscription, err error) {
+	rec, err := s.db.Get(KeySubscriptionID, id)
+	if err != nil {
+		return
+	}
+
+	err = rec.Deserialize(&sub)
+	sub.Version = rec.Version()
+	return
+}
+
+// subscriptions returns every subscription sorted by ID, including their secrets.
+func (s *service) subscriptions() (subs []Subscription, err error) {
+	recs, err := s.db.Scan(KeySubscriptionID, ramdb.ScanOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, rec := range recs {
+		var sub Subscription
+		err = rec.Deserialize(&sub)
+		if err != nil {
+			return nil, err
+		}
+
+		sub.Version = rec.Version()
+		subs = append(subs, sub)
+	}
+
+	return
+}
+
+// subscribes returns true if sub receives events of eventType.
+func (sub Subscription) subscribes(eventType string) bool {
+	for _, e := range sub.Events {
+		if e == eventType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validate returns an error matching ErrInvalidSubscription if sub is invalid, or nil if it is valid.
+func validate(sub Subscription) error {
+	u, err := url.Parse(sub.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https url", ErrInvalidSubscription)
+	}
+
+	if len(sub.Events) == 0 {
+		return fmt.Errorf("%w: at least one event is required", ErrInvalidSubscription)
+	}
+
+	for _, e := range sub.Events {
+		if !SupportedEvents[e] {
+			return fmt.Errorf("%w: unsupported event %q", ErrInvalidSubscription, e)
+		}
+	}
+
+	if len(strings.TrimSpace(sub.Secret)) < minSecretLength {
+		return fmt.Errorf("%w: secret must be at least %d characters", ErrInvalidSubscription, minSecretLength)
+	}
+
+	return nil
+}
+
+// redact returns sub without its secret.
+func redact(sub Subscription) Subscription {
+	sub.Secret = ""
+	return sub
+}
+
+// newRecord returns the record storing sub, keyed by ID.
+func newRecord(sub Subscription) (*ramdb.Record, error) {
+	sub.Version = 0
+	return ramdb.NewRecord(sub.ID, KeySubscriptionID, sub)
+}
+
+// newID returns a random ID.
+func newID() (string, error) {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,159 @@
+package webhooks
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/davidlick/supermarket-api/internal/produce"
+	"github.com/davidlick/supermarket-api/pkg/ramdb"
+	"github.com/stretchr/testify/assert"
+)
+
+const testSecret = "0123456789abcdef"
+
+var testNow = time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+// newTestService returns a webhook service on new produce, webhooks and webhook_deliveries tables, with testNow as the
+// current time. It also returns a produce service on the produce table.
+func newTestService(t *testing.T) (*service, testProduceService) {
+	db := ramdb.NewDatabase()
+	assert.Nil(t, db.CreateTable("produce"))
+	for _, column := range []string{produce.KeyProduceCode, produce.KeyProduceName, produce.KeyProducePrice} {
+		assert.Nil(t, db.From("produce").CreateOrderedIndex(column))
+	}
+	assert.Nil(t, db.CreateTable("webhooks"))
+	assert.Nil(t, db.From("webhooks").CreateOrderedIndex(KeySubscriptionID))
+	assert.Nil(t, db.CreateTable("webhook_deliveries"))
+	assert.Nil(t, db.From("webhook_deliveries").CreateOrderedIndex(KeyDeliveryID))
+	assert.Nil(t, db.From("webhook_deliveries").CreateOrderedIndex(KeyDeliveryDue))
+
+	produceSvc := produce.NewService(db.From("produce"))
+	svc := NewService(db.From("webhooks"), db.From("webhook_deliveries"), produceSvc, &http.Client{Timeout: time.Second})
+	svc.now = func() time.Time { return testNow }
+	return svc, produceSvc
+}
+
+// testProduceService is the produce service tests change the catalogue with.
+type testProduceService interface {
+	ProduceService
+	Add(items []produce.Item) error
+	Patch(produceCode string, patch produce.ItemPatch) (produce.Item, error)
+	Remove(item produce.Item) error
+}
+
+func TestService_Create(t *testing.T) {
+	valid := Subscription{URL: "https://erp.example.com/hooks", Events: []string{EventProduceAdded}, Secret: testSecret}
+
+	tests := []struct {
+		test          string
+		change        func(sub Subscription) Subscription
+		expectedError error
+	}{
+		{
+			test:   "it should create a valid subscription",
+			change: func(sub Subscription) Subscription { return sub },
+		},
+		{
+			test:          "it should reject a relative url",
+			change:        func(sub Subscription) Subscription { sub.URL = "/hooks"; return sub },
+			expectedError: ErrInvalidSubscription,
+		},
+		{
+			test:          "it should reject a url that is not http or https",
+			change:        func(sub Subscription) Subscription { sub.URL = "ftp://erp.example.com"; return sub },
+			expectedError: ErrInvalidSubscription,
+		},
+		{
+			test:          "it should reject a subscription without events",
+			change:        func(sub Subscription) Subscription { sub.Events = nil; return sub },
+			expectedError: ErrInvalidSubscription,
+		},
+		{
+			test:          "it should reject unsupported events",
+			change:        func(sub Subscription) Subscription { sub.Events = []string{"produce.eaten"}; return sub },
+			expectedError: ErrInvalidSubscription,
+		},
+		{
+			test:          "it should reject a short secret",
+			change:        func(sub Subscription) Subscription { sub.Secret = "secret"; return sub },
+			expectedError: ErrInvalidSubscription,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			svc, _ := newTestService(t)
+
+			created, err := svc.Create(tc.change(valid))
+			assert.ErrorIs(t, err, tc.expectedError)
+			if tc.expectedError != nil {
+				return
+			}
+
+			assert.NotEmpty(t, created.ID)
+			assert.Empty(t, created.Secret)
+			assert.Equal(t, testNow, created.CreatedAt)
+			assert.Equal(t, uint64(1), created.Version)
+
+			stored, err := svc.subscription(created.ID)
+			assert.Nil(t, err)
+			assert.Equal(t, testSecret, stored.Secret)
+		})
+	}
+}
+
+func TestService_Update(t *testing.T) {
+	t.Run("it should keep the secret if none is given", func(t *testing.T) {
+		svc, _ := newTestService(t)
+		created, err := svc.Create(Subscription{URL: "https://erp.example.com/hooks", Events: []string{EventProduceAdded}, Secret: testSecret})
+		assert.Nil(t, err)
+
+		created.Events = []string{EventProduceRemoved}
+		updated, err := svc.Update(created)
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(2), updated.Version)
+		assert.Empty(t, updated.Secret)
+
+		stored, err := svc.subscription(created.ID)
+		assert.Nil(t, err)
+		assert.Equal(t, testSecret, stored.Secret)
+		assert.Equal(t, []string{EventProduceRemoved}, stored.Events)
+	})
+
+	t.Run("it should return ramdb.ErrVersionMismatch for a stale version", func(t *testing.T) {
+		svc, _ := newTestService(t)
+		created, err := svc.Create(Subscription{URL: "https://erp.example.com/hooks", Events: []string{EventProduceAdded}, Secret: testSecret})
+		assert.Nil(t, err)
+
+		_, err = svc.Update(created)
+		assert.Nil(t, err)
+
+		_, err = svc.Update(created)
+		assert.Equal(t, ramdb.ErrVersionMismatch, err)
+	})
+
+	t.Run("it should return ramdb.ErrNoRecord for an unknown subscription", func(t *testing.T) {
+		svc, _ := newTestService(t)
+
+		_, err := svc.Update(Subscription{ID: "missing"})
+		assert.Equal(t, ramdb.ErrNoRecord, err)
+	})
+}
+
+func TestService_All(t *testing.T) {
+	t.Run("it should return every subscription without secrets", func(t *testing.T) {
+		svc, _ := newTestService(t)
+		for i := 0; i < 2; i++ {
+			_, err := svc.Create(Subscription{URL: "https://erp.example.com/hooks", Events: []string{EventProduceAdded}, Secret: testSecret})
+			assert.Nil(t, err)
+		}
+
+		subs, err := svc.All()
+		assert.Nil(t, err)
+		assert.Len(t, subs, 2)
+		for _, sub := range subs {
+			assert.Empty(t, sub.Secret)
+		}
+	})
+}
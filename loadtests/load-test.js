@@ -38,19 +38,31 @@ export const options = {
 	},
 }
 
+// Requests are made with the API key in the API_KEY environment variable, which must have the pricing-editor or admin
+// role, such as with `k6 run -e API_KEY=sk_... loadtests/load-test.js`.
+const params = {
+	headers: {
+		'Authorization': `Bearer ${__ENV.API_KEY}`,
+	},
+}
+
 export default function() {
 	const id = `${__ITER}-${__VU}`
 
+	// Produce codes must be four groups of four letters or digits, so the VU and iteration are zero padded into them.
+	const iter = String(__ITER).padStart(8, '0')
+	const code = `LOAD-${String(__VU).padStart(4, '0')}-${iter.slice(0, 4)}-${iter.slice(4)}`
+
 	const addRes = http.post('http://localhost:3000/v1/produce', JSON.stringify([{
-		code: `code-${id}`,
+		code: code,
 		name: `name-${id}`,
 		price: {
 			amount: 100,
 			currency: "USD"
 		}
-	}]))
+	}]), params)
 
-	const getRes = http.get('http://localhost:3000/v1/produce')
+	const getRes = http.get('http://localhost:3000/v1/produce', params)
 
 	addProduceSuccessRate.add(addRes.status < 300)
 	addProduceTimingTrend.add(addRes.timings.duration)
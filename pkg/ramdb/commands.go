@@ -2,28 +2,61 @@ package ramdb
 
 import "github.com/google/btree"
 
-// Get validates that the table and index for the given column exists and searches for the given key in the tree.
+// Get validates that the table and index for the given column exists and searches for the given key in the tree. For
+// a secondary index with several Records under key, the first Record in id order is returned.
 func (t *table) Get(column, key string) (r *Record, err error) {
 	if !t.exists {
 		return nil, ErrNoTable
 	}
 
-	if !t.HasIndex(column) {
+	index, found := t.indexes()[column]
+	if !found {
 		return nil, ErrNoIndex
 	}
 
-	return t.keyLookup(key, t.indexes[column])
+	if index.text {
+		return nil, ErrTextIndex
+	}
+
+	return t.keyLookup(key, index)
 }
 
 // keyLookup tries to find the given key in the tree. It returns ErrNoRecord if not found.
 func (t *table) keyLookup(key string, index *index) (r *Record, err error) {
-	item := &Record{id: keyHash(key)}
-	result := index.tree.Get(item)
-	if result == nil {
+	r = index.first(key)
+	if r == nil {
+		return nil, ErrNoRecord
+	}
+
+	return r, nil
+}
+
+// Lookup returns every Record indexed under key for the column, sorted in ascending order by id. It returns
+// ErrNoRecord if there are none.
+func (t *table) Lookup(column, key string) (rr []*Record, err error) {
+	if !t.exists {
+		return nil, ErrNoTable
+	}
+
+	index, found := t.indexes()[column]
+	if !found {
+		return nil, ErrNoIndex
+	}
+
+	if index.text {
+		return nil, ErrTextIndex
+	}
+
+	index.ascendKey(key, func(e *entry) bool {
+		rr = append(rr, e.record)
+		return true
+	})
+
+	if len(rr) == 0 {
 		return nil, ErrNoRecord
 	}
 
-	return result.(*Record), nil
+	return
 }
 
 // Select returns all of the Records in the database sorted in ascending order by id.
@@ -32,62 +65,191 @@ func (t *table) Select(column string) (rr []*Record, err error) {
 		return nil, ErrNoTable
 	}
 
-	if !t.HasIndex(column) {
+	index, found := t.indexes()[column]
+	if !found {
 		return nil, ErrNoIndex
 	}
 
-	t.indexes[column].tree.Ascend(func(item btree.Item) bool {
-		r := item.(*Record)
-		rr = append(rr, r)
+	if index.text {
+		return nil, ErrTextIndex
+	}
+
+	index.tree.Ascend(func(item btree.Item) bool {
+		rr = append(rr, item.(*entry).record)
 		return true
 	})
 
 	return
 }
 
-// Insert adds the Record to the database. It returns ErrRecordExists if the Record already exists. Insert is thread safe.
+// Insert adds the Record to the database and to every index on the table at version 1. It returns ErrRecordExists if
+// a Record with the same key exists and ErrMissingIndexKey if the Record has no key for one of the table's indexes.
+// Insert is thread safe.
 func (t *table) Insert(r *Record) error {
+	return t.write(command{op: opInsert, record: r})
+}
+
+// Update replaces the Record with the same key in the database and in every index on the table, and increments its
+// version. It returns ErrNoRecord if the Record does not exist and ErrMissingIndexKey if r has no key for one of the
+// table's indexes. Update is thread safe.
+func (t *table) Update(r *Record) error {
+	return t.write(command{op: opUpdate, record: r})
+}
+
+// Upsert updates the Record with the same key if it exists and inserts it if it does not. Upsert is thread safe.
+func (t *table) Upsert(r *Record) error {
+	return t.write(command{op: opUpsert, record: r})
+}
+
+// CompareAndSwap updates the Record with the same key only if its version is version. It returns ErrNoRecord if the
+// Record does not exist and ErrVersionMismatch if it has been changed since version. CompareAndSwap is thread safe.
+func (t *table) CompareAndSwap(r *Record, version uint64) error {
+	return t.write(command{op: opCompareAndSwap, record: r, version: version})
+}
+
+// Delete removes the Record with the same key from the database and from every index on the table. Only the key of r
+// is used, so r does not need the keys of the table's other indexes. It returns ErrNoRecord if the Record does not
+// exist. Delete is thread safe.
+func (t *table) Delete(r *Record) error {
+	return t.write(command{op: opDelete, record: r})
+}
+
+// command is a buffered write to a table's Records.
+type command struct {
+	op     string
+	record *Record
+	// version is the expected version of the stored Record for opCompareAndSwap, and the version to insert the
+	// Record at for opInsert. Inserts default to version 1.
+	version uint64
+}
+
+// write runs the command on a clone of the table's indexes, logs it and publishes the clone.
+func (t *table) write(cmd command) error {
 	if !t.exists {
 		return ErrNoTable
 	}
 
-	if !t.HasIndex(r.keyColumn) {
-		return ErrNoIndex
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	indexes := t.cloneIndexes()
+	entry, event, err := execute(indexes, cmd)
+	if err != nil {
+		return err
 	}
 
-	index := t.indexes[r.keyColumn]
+	err = t.writeLog(entry)
+	if err != nil {
+		return err
+	}
 
-	if has := index.tree.Has(r); has {
-		return ErrRecordExists
+	t.state.Store(indexes)
+	t.feed.publish(event)
+	return nil
+}
+
+// execute applies the command to indexes and returns the write-ahead log entry that replays it and the Event
+// describing it, without a sequence number. indexes are left unchanged if it returns an error.
+func execute(indexes map[string]*index, cmd command) (logEntry, Event, error) {
+	r := cmd.record
+	primary, found := indexes[r.keyColumn]
+	if !found {
+		return logEntry{}, Event{}, ErrNoIndex
 	}
 
-	t.mutex.Lock()
-	defer t.mutex.Unlock()
+	if primary.text {
+		return logEntry{}, Event{}, ErrTextIndex
+	}
 
-	index.tree.ReplaceOrInsert(r)
-	return nil
+	stored := primary.first(r.key)
+	if cmd.op == opDelete {
+		if stored == nil {
+			return logEntry{}, Event{}, ErrNoRecord
+		}
 
-}
+		applyDelete(indexes, stored)
+		return deleteEntry(r), Event{Op: EventDelete, Key: r.key, Before: stored}, nil
+	}
 
-// Delete removes the item from the database. It returns ErrNoRecord if the Record does not exist and ErrNotDeleted if the removal fails. Delete is thread safe.
-func (t *table) Delete(r *Record) error {
-	if !t.exists {
-		return ErrNoTable
+	for column := range indexes {
+		if _, ok := r.indexKey(column); !ok {
+			return logEntry{}, Event{}, ErrMissingIndexKey
+		}
+	}
+
+	switch {
+	case cmd.op == opInsert && stored != nil:
+		return logEntry{}, Event{}, ErrRecordExists
+	case cmd.op == opInsert, cmd.op == opUpsert && stored == nil:
+		version := cmd.version
+		if version == 0 {
+			version = 1
+		}
+
+		r = r.withVersion(version)
+		applyInsert(indexes, r)
+		return insertEntry(r), Event{Op: EventInsert, Key: r.key, After: r}, nil
+	case stored == nil:
+		return logEntry{}, Event{}, ErrNoRecord
+	case cmd.op == opCompareAndSwap && stored.version != cmd.version:
+		return logEntry{}, Event{}, ErrVersionMismatch
 	}
 
-	if !t.HasIndex(r.keyColumn) {
+	r = r.withVersion(stored.version + 1)
+	applyDelete(indexes, stored)
+	applyInsert(indexes, r)
+	return updateEntry(r), Event{Op: EventUpdate, Key: r.key, Before: stored, After: r}, nil
+}
+
+// check returns ErrNoRecord if indexes hold no Record with the key of the command's Record, or ErrVersionMismatch if
+// the stored Record isn't at the command's version.
+func check(indexes map[string]*index, cmd command) error {
+	r := cmd.record
+	primary, found := indexes[r.keyColumn]
+	if !found {
 		return ErrNoIndex
 	}
 
-	index := t.indexes[r.keyColumn]
+	if primary.text {
+		return ErrTextIndex
+	}
 
-	if has := index.tree.Has(r); !has {
+	stored := primary.first(r.key)
+	switch {
+	case stored == nil:
 		return ErrNoRecord
+	case stored.version != cmd.version:
+		return ErrVersionMismatch
 	}
 
-	t.mutex.Lock()
-	defer t.mutex.Unlock()
-
-	index.tree.Delete(r)
 	return nil
 }
+
+// applyInsert adds r to every index.
+func applyInsert(indexes map[string]*index, r *Record) {
+	for _, idx := range indexes {
+		idx.insert(r)
+	}
+}
+
+// applyDelete removes the stored Record from every index.
+func applyDelete(indexes map[string]*index, stored *Record) {
+	for _, idx := range indexes {
+		idx.remove(stored)
+	}
+}
+
+// insertEntry returns the write-ahead log entry for inserting r.
+func insertEntry(r *Record) logEntry {
+	return logEntry{Op: opInsert, Column: r.keyColumn, Key: r.key, Keys: r.keys, Data: r.serialized, Version: r.version}
+}
+
+// updateEntry returns the write-ahead log entry for replacing the Record with r's key with r.
+func updateEntry(r *Record) logEntry {
+	return logEntry{Op: opUpdate, Column: r.keyColumn, Key: r.key, Keys: r.keys, Data: r.serialized, Version: r.version}
+}
+
+// deleteEntry returns the write-ahead log entry for deleting the Record with r's key.
+func deleteEntry(r *Record) logEntry {
+	return logEntry{Op: opDelete, Column: r.keyColumn, Key: r.key}
+}
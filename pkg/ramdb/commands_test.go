@@ -3,7 +3,6 @@ package ramdb
 import (
 	"fmt"
 	"sort"
-	"sync"
 	"testing"
 
 	"github.com/google/btree"
@@ -27,42 +26,36 @@ func TestTable_Get(t *testing.T) {
 		{
 			test: "it should return ErrNoIndex if no index exists for column",
 			tableConfig: func() *table {
-				return &table{
-					exists: true,
-				}
+				return testTable(nil)
 			},
 			expectedError: ErrNoIndex,
 		},
 		{
 			test: "it should return ErrNoRecord if no Record was found for key",
 			tableConfig: func() *table {
-				return &table{
-					exists: true,
-					indexes: map[string]*index{
-						"test_column": &index{
-							tree: btree.New(5),
-						},
+				return testTable(map[string]*index{
+					"test_column": &index{
+						tree:   btree.New(5),
+						column: "test_column",
 					},
-				}
+				})
 			},
 			expectedError: ErrNoRecord,
 		},
 		{
 			test: "it should return a Record when one is found",
 			tableConfig: func() *table {
-				tbl := &table{
-					exists: true,
-					indexes: map[string]*index{
-						"test_column": &index{
-							tree: btree.New(5),
-						},
+				tbl := testTable(map[string]*index{
+					"test_column": &index{
+						tree:   btree.New(5),
+						column: "test_column",
 					},
-				}
+				})
 				rec, err := NewRecord("test_key", "test_column", struct{}{})
 				if err != nil {
 					t.Error(err)
 				}
-				tbl.indexes["test_column"].tree.ReplaceOrInsert(rec)
+				tbl.indexes()["test_column"].insert(rec)
 				return tbl
 			},
 			expectedRecord: &Record{
@@ -96,35 +89,26 @@ func TestTable_Select(t *testing.T) {
 		{
 			test: "it should return ErrNoTable if an invalid table is supplied",
 			tableConfig: func() (*table, []*Record) {
-				return &table{
-					mutex: &sync.Mutex{},
-				}, nil
+				return &table{}, nil
 			},
 			expectedError: ErrNoTable,
 		},
 		{
 			test: "it should return ErrNoIndex if no index exists for column",
 			tableConfig: func() (*table, []*Record) {
-				return &table{
-					exists:  true,
-					mutex:   &sync.Mutex{},
-					indexes: make(map[string]*index),
-				}, nil
+				return testTable(nil), nil
 			},
 			expectedError: ErrNoIndex,
 		},
 		{
 			test: "it should return all Records in the database",
 			tableConfig: func() (*table, []*Record) {
-				tbl := &table{
-					exists: true,
-					mutex:  &sync.Mutex{},
-					indexes: map[string]*index{
-						"test_column": &index{
-							tree: btree.New(5),
-						},
+				tbl := testTable(map[string]*index{
+					"test_column": &index{
+						tree:   btree.New(5),
+						column: "test_column",
 					},
-				}
+				})
 
 				expectedRecords := make([]*Record, 0)
 				for i := 0; i < 10; i++ {
@@ -133,7 +117,7 @@ func TestTable_Select(t *testing.T) {
 					if err != nil {
 						t.Error(err)
 					}
-					tbl.indexes["test_column"].tree.ReplaceOrInsert(rec)
+					tbl.indexes()["test_column"].insert(rec)
 					expectedRecords = append(expectedRecords, rec)
 				}
 
@@ -167,41 +151,32 @@ func TestTable_Insert(t *testing.T) {
 		{
 			test: "it should return ErrNoTable if an invalid table is supplied",
 			tableConfig: func() *table {
-				return &table{
-					mutex: &sync.Mutex{},
-				}
+				return &table{}
 			},
 			expectedError: ErrNoTable,
 		},
 		{
 			test: "it should return ErrNoIndex if no index exists for column",
 			tableConfig: func() *table {
-				return &table{
-					exists:  true,
-					mutex:   &sync.Mutex{},
-					indexes: make(map[string]*index),
-				}
+				return testTable(nil)
 			},
 			expectedError: ErrNoIndex,
 		},
 		{
 			test: "it should return ErrRecordExists if a Record with key exists",
 			tableConfig: func() *table {
-				tbl := &table{
-					exists: true,
-					mutex:  &sync.Mutex{},
-					indexes: map[string]*index{
-						"test_column": &index{
-							tree: btree.New(5),
-						},
+				tbl := testTable(map[string]*index{
+					"test_column": &index{
+						tree:   btree.New(5),
+						column: "test_column",
 					},
-				}
+				})
 
 				rec, err := NewRecord("test_key", "test_column", struct{}{})
 				if err != nil {
 					t.Error(err)
 				}
-				tbl.indexes["test_column"].tree.ReplaceOrInsert(rec)
+				tbl.indexes()["test_column"].insert(rec)
 
 				return tbl
 			},
@@ -210,15 +185,12 @@ func TestTable_Insert(t *testing.T) {
 		{
 			test: "it should return no error if successful",
 			tableConfig: func() *table {
-				return &table{
-					exists: true,
-					mutex:  &sync.Mutex{},
-					indexes: map[string]*index{
-						"test_column": &index{
-							tree: btree.New(5),
-						},
+				return testTable(map[string]*index{
+					"test_column": &index{
+						tree:   btree.New(5),
+						column: "test_column",
 					},
-				}
+				})
 			},
 		},
 	}
@@ -247,56 +219,44 @@ func TestTable_Delete(t *testing.T) {
 		{
 			test: "it should return ErrNoTable if an invalid table is supplied",
 			tableConfig: func() *table {
-				return &table{
-					mutex: &sync.Mutex{},
-				}
+				return &table{}
 			},
 			expectedError: ErrNoTable,
 		},
 		{
 			test: "it should return ErrNoIndex if no index exists for column",
 			tableConfig: func() *table {
-				return &table{
-					exists:  true,
-					mutex:   &sync.Mutex{},
-					indexes: make(map[string]*index),
-				}
+				return testTable(nil)
 			},
 			expectedError: ErrNoIndex,
 		},
 		{
 			test: "it should return ErrNoRecord if the Record does not exist",
 			tableConfig: func() *table {
-				return &table{
-					exists: true,
-					mutex:  &sync.Mutex{},
-					indexes: map[string]*index{
-						"test_column": &index{
-							tree: btree.New(5),
-						},
+				return testTable(map[string]*index{
+					"test_column": &index{
+						tree:   btree.New(5),
+						column: "test_column",
 					},
-				}
+				})
 			},
 			expectedError: ErrNoRecord,
 		},
 		{
 			test: "it should return no error if the item was deleted",
 			tableConfig: func() *table {
-				tbl := &table{
-					exists: true,
-					mutex:  &sync.Mutex{},
-					indexes: map[string]*index{
-						"test_column": &index{
-							tree: btree.New(5),
-						},
+				tbl := testTable(map[string]*index{
+					"test_column": &index{
+						tree:   btree.New(5),
+						column: "test_column",
 					},
-				}
+				})
 				rec, err := NewRecord("test_key", "test_column", struct{}{})
 				if err != nil {
 					t.Error(err)
 				}
 
-				tbl.indexes["test_column"].tree.ReplaceOrInsert(rec)
+				tbl.indexes()["test_column"].insert(rec)
 				return tbl
 			},
 		},
@@ -316,3 +276,233 @@ func TestTable_Delete(t *testing.T) {
 		})
 	}
 }
+
+func TestTable_Lookup(t *testing.T) {
+	tests := []struct {
+		test            string
+		tableConfig     func() (*table, []*Record)
+		expectedError   error
+		expectedRecords int
+	}{
+		{
+			test: "it should return ErrNoTable if an invalid table is supplied",
+			tableConfig: func() (*table, []*Record) {
+				return &table{}, nil
+			},
+			expectedError: ErrNoTable,
+		},
+		{
+			test: "it should return ErrNoIndex if no index exists for column",
+			tableConfig: func() (*table, []*Record) {
+				return testTable(nil), nil
+			},
+			expectedError: ErrNoIndex,
+		},
+		{
+			test: "it should return ErrNoRecord if no Records have the key",
+			tableConfig: func() (*table, []*Record) {
+				db := NewDatabase()
+				db.CreateTable("test_table", "key_column", "test_column")
+				return db.From("test_table"), nil
+			},
+			expectedError: ErrNoRecord,
+		},
+		{
+			test: "it should return every Record with the key",
+			tableConfig: func() (*table, []*Record) {
+				db := NewDatabase()
+				db.CreateTable("test_table", "key_column", "test_column")
+
+				var expectedRecords []*Record
+				for i := 0; i < 5; i++ {
+					secondaryKey := "test_key"
+					if i%2 == 0 {
+						secondaryKey = "other_key"
+					}
+
+					rec, err := NewRecord(fmt.Sprintf("key-%d", i), "key_column", struct{}{})
+					if err != nil {
+						t.Error(err)
+					}
+
+					rec.WithKey("test_column", secondaryKey)
+					db.From("test_table").Insert(rec)
+
+					if secondaryKey == "test_key" {
+						expectedRecords = append(expectedRecords, rec.withVersion(1))
+					}
+				}
+
+				sort.Slice(expectedRecords, func(a, b int) bool {
+					return expectedRecords[a].id < expectedRecords[b].id
+				})
+
+				return db.From("test_table"), expectedRecords
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			tbl, expectedRecords := tc.tableConfig()
+
+			rr, err := tbl.Lookup("test_column", "test_key")
+
+			assert.Equal(t, expectedRecords, rr)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestTable_SecondaryIndexes(t *testing.T) {
+	t.Run("it should return ErrMissingIndexKey if a Record has no key for an index", func(t *testing.T) {
+		db := NewDatabase()
+		db.CreateTable("test_table", "key_column", "test_column")
+
+		rec, err := NewRecord("test_key", "key_column", struct{}{})
+		if err != nil {
+			t.Error(err)
+		}
+
+		err = db.From("test_table").Insert(rec)
+
+		assert.Equal(t, ErrMissingIndexKey, err)
+		_, err = db.From("test_table").Get("key_column", "test_key")
+		assert.Equal(t, ErrNoRecord, err)
+	})
+
+	t.Run("it should maintain every index on insert and delete", func(t *testing.T) {
+		db := NewDatabase()
+		db.CreateTable("test_table", "key_column", "test_column")
+		tbl := db.From("test_table")
+
+		rec, err := NewRecord("test_key", "key_column", struct{}{})
+		if err != nil {
+			t.Error(err)
+		}
+
+		assert.Nil(t, tbl.Insert(rec.WithKey("test_column", "secondary_key")))
+
+		r, err := tbl.Get("test_column", "secondary_key")
+		assert.Nil(t, err)
+		assert.Equal(t, rec.withVersion(1), r)
+
+		deleteRec, err := NewRecord("test_key", "key_column", nil)
+		if err != nil {
+			t.Error(err)
+		}
+
+		assert.Nil(t, tbl.Delete(deleteRec))
+
+		_, err = tbl.Get("test_column", "secondary_key")
+		assert.Equal(t, ErrNoRecord, err)
+
+		rr, err := tbl.Select("test_column")
+		assert.Nil(t, err)
+		assert.Empty(t, rr)
+	})
+}
+
+func TestTable_Update(t *testing.T) {
+	tests := []struct {
+		test            string
+		write           func(tbl *table, r *Record) error
+		key             string
+		expectedVersion uint64
+		expectedData    string
+		expectedNames   map[string]int
+		expectedError   error
+	}{
+		{
+			test:            "it should replace the Record and increment its version",
+			write:           func(tbl *table, r *Record) error { return tbl.Update(r) },
+			key:             "key-1",
+			expectedVersion: 2,
+			expectedData:    "updated",
+			expectedNames:   map[string]int{"pepper": 0, "apple": 1},
+		},
+		{
+			test:          "it should return ErrNoRecord when updating a Record that does not exist",
+			write:         func(tbl *table, r *Record) error { return tbl.Update(r) },
+			key:           "key-2",
+			expectedNames: map[string]int{"pepper": 1, "apple": 0},
+			expectedError: ErrNoRecord,
+		},
+		{
+			test:            "it should update a Record that exists when upserting",
+			write:           func(tbl *table, r *Record) error { return tbl.Upsert(r) },
+			key:             "key-1",
+			expectedVersion: 2,
+			expectedData:    "updated",
+			expectedNames:   map[string]int{"pepper": 0, "apple": 1},
+		},
+		{
+			test:            "it should insert a Record that does not exist when upserting",
+			write:           func(tbl *table, r *Record) error { return tbl.Upsert(r) },
+			key:             "key-2",
+			expectedVersion: 1,
+			expectedData:    "updated",
+			expectedNames:   map[string]int{"pepper": 1, "apple": 1},
+		},
+		{
+			test:            "it should swap the Record if the version matches",
+			write:           func(tbl *table, r *Record) error { return tbl.CompareAndSwap(r, 1) },
+			key:             "key-1",
+			expectedVersion: 2,
+			expectedData:    "updated",
+			expectedNames:   map[string]int{"pepper": 0, "apple": 1},
+		},
+		{
+			test:            "it should return ErrVersionMismatch if the version does not match",
+			write:           func(tbl *table, r *Record) error { return tbl.CompareAndSwap(r, 2) },
+			key:             "key-1",
+			expectedVersion: 1,
+			expectedData:    "original",
+			expectedNames:   map[string]int{"pepper": 1, "apple": 0},
+			expectedError:   ErrVersionMismatch,
+		},
+		{
+			test:          "it should return ErrNoRecord when swapping a Record that does not exist",
+			write:         func(tbl *table, r *Record) error { return tbl.CompareAndSwap(r, 1) },
+			key:           "key-2",
+			expectedNames: map[string]int{"pepper": 1, "apple": 0},
+			expectedError: ErrNoRecord,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			db := NewDatabase()
+			db.CreateTable("test_table", "key_column", "name")
+			tbl := db.From("test_table")
+
+			rec, err := NewRecord("key-1", "key_column", "original")
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Nil(t, tbl.Insert(rec.WithKey("name", "pepper")))
+
+			updated, err := NewRecord(tc.key, "key_column", "updated")
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = tc.write(tbl, updated.WithKey("name", "apple"))
+			assert.Equal(t, tc.expectedError, err)
+
+			r, err := tbl.Get("key_column", tc.key)
+			if tc.expectedVersion == 0 {
+				assert.Equal(t, ErrNoRecord, err)
+			} else {
+				var data string
+				assert.Nil(t, r.Deserialize(&data))
+				assert.Equal(t, tc.expectedData, data)
+				assert.Equal(t, tc.expectedVersion, r.Version())
+			}
+
+			for name, expectedLen := range tc.expectedNames {
+				rr, _ := tbl.Lookup("name", name)
+				assert.Len(t, rr, expectedLen)
+			}
+		})
+	}
+}
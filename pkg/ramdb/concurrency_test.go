@@ -0,0 +1,172 @@
+package ramdb
+
+import (
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTable_Concurrency(t *testing.T) {
+	const (
+		records    = 50
+		writers    = 4
+		readers    = 4
+		iterations = 200
+	)
+
+	db := NewDatabase()
+	db.CreateTable("test_table")
+	tbl := db.From("test_table")
+	tbl.CreateOrderedIndex("test_column")
+	tbl.CreateIndex("name")
+
+	newRecord := func(i int, name string) *Record {
+		rec, err := NewRecord(fmt.Sprintf("key-%03d", i), "test_column", struct{}{})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return rec.WithKey("name", name)
+	}
+
+	for i := 0; i < records; i++ {
+		assert.Nil(t, tbl.Insert(newRecord(i, "even")))
+	}
+
+	var writersWG, readersWG sync.WaitGroup
+	done := make(chan struct{})
+
+	// Each writer moves its own Records between the "even" and "odd" names with a Tx, so the table always holds
+	// exactly records Records.
+	for w := 0; w < writers; w++ {
+		writersWG.Add(1)
+		go func(w int) {
+			defer writersWG.Done()
+			for n := 0; n < iterations; n++ {
+				name := "odd"
+				if n%2 == 1 {
+					name = "even"
+				}
+
+				for i := w; i < records; i += writers {
+					tx := tbl.Begin()
+					tx.Delete(newRecord(i, ""))
+					tx.Insert(newRecord(i, name))
+					assert.Nil(t, tx.Commit())
+				}
+			}
+		}(w)
+	}
+
+	// A separate writer inserts and deletes Records outside of the moved ones without a Tx.
+	writersWG.Add(1)
+	go func() {
+		defer writersWG.Done()
+		for n := 0; n < iterations; n++ {
+			rec := newRecord(records+n, "extra")
+			assert.Nil(t, tbl.Insert(rec))
+			assert.Nil(t, tbl.Delete(rec))
+		}
+	}()
+
+	for r := 0; r < readers; r++ {
+		readersWG.Add(1)
+		go func() {
+			defer readersWG.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				// Each read of the table must see all of the moved Records and at most one extra Record, however
+				// the writers are interleaved.
+				rr, err := tbl.Select("test_column")
+				assert.Nil(t, err)
+				assert.True(t, len(rr) == records || len(rr) == records+1, "Select returned %d Records", len(rr))
+
+				rr, err = tbl.Range("test_column", "key-000", fmt.Sprintf("key-%03d", records))
+				assert.Nil(t, err)
+				assert.Len(t, rr, records)
+
+				_, err = tbl.Get("test_column", "key-007")
+				assert.Nil(t, err)
+
+				even, _ := tbl.Lookup("name", "even")
+				for _, r := range even {
+					assert.Equal(t, "even", r.keys["name"])
+				}
+			}
+		}()
+	}
+
+	readersWG.Add(1)
+	go func() {
+		defer readersWG.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+
+			assert.Nil(t, db.Snapshot(ioutil.Discard))
+		}
+	}()
+
+	writersWG.Wait()
+	close(done)
+	readersWG.Wait()
+
+	rr, err := tbl.Select("test_column")
+	assert.Nil(t, err)
+	assert.Len(t, rr, records)
+
+	even, err := tbl.Lookup("name", "even")
+	assert.Nil(t, err)
+	assert.Len(t, even, records)
+}
+
+func TestTable_Concurrency_Insert(t *testing.T) {
+	t.Run("it should insert each key exactly once when writers race", func(t *testing.T) {
+		db := NewDatabase()
+		db.CreateTable("test_table", "test_column")
+		tbl := db.From("test_table")
+
+		var wg sync.WaitGroup
+		var mutex sync.Mutex
+		inserted := 0
+		for w := 0; w < 8; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 100; i++ {
+					rec, err := NewRecord(fmt.Sprintf("key-%d", i), "test_column", struct{}{})
+					if err != nil {
+						t.Error(err)
+					}
+
+					err = tbl.Insert(rec)
+					if err == nil {
+						mutex.Lock()
+						inserted++
+						mutex.Unlock()
+						continue
+					}
+
+					assert.Equal(t, ErrRecordExists, err)
+				}
+			}()
+		}
+		wg.Wait()
+
+		rr, err := tbl.Select("test_column")
+		assert.Nil(t, err)
+		assert.Len(t, rr, 100)
+		assert.Equal(t, 100, inserted)
+	})
+}
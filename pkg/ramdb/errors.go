@@ -9,7 +9,29 @@ var (
 	ErrNoRecord     = errors.New("record does not exist")
 	ErrRecordExists = errors.New("record already exists")
 
+	ErrVersionMismatch = errors.New("record version does not match")
+
 	ErrNoIndex      = errors.New("index does not exist")
 	ErrInvalidIndex = errors.New("invalid index column")
 	ErrIndexExists  = errors.New("index already exists")
+
+	ErrUnorderedIndex = errors.New("index is not ordered")
+	ErrTextIndex      = errors.New("index is a text index")
+	ErrNotTextIndex   = errors.New("index is not a text index")
+	ErrInvalidCursor  = errors.New("invalid scan cursor")
+	ErrCursorMismatch = errors.New("scan cursor is for another column or direction")
+
+	ErrMissingIndexKey = errors.New("record has no key for index")
+
+	ErrCorruptLog        = errors.New("write-ahead log is corrupt")
+	ErrLogClosed         = errors.New("write-ahead log is closed")
+	ErrLogEntryTooLarge  = errors.New("write-ahead log entry is too large")
+	ErrInvalidSyncPolicy = errors.New("invalid write-ahead log sync policy")
+
+	ErrInvalidSnapshot = errors.New("invalid snapshot")
+
+	ErrTxDone = errors.New("transaction has already been committed or rolled back")
+
+	ErrSequenceUnavailable = errors.New("events from sequence number are not available")
+	ErrWatchLagged         = errors.New("watcher fell too far behind")
 )
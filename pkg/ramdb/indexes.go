@@ -3,7 +3,100 @@ package ramdb
 import "github.com/google/btree"
 
 type index struct {
-	tree   *btree.BTree
-	column string
-	table  *table
+	tree    *btree.BTree
+	column  string
+	ordered bool
+	// text is set for a text index, which has an entry for every trigram of a Record's key instead of the key itself.
+	text  bool
+	table *table
+}
+
+// entry is an item in an index's tree pointing at a Record. Entries are ordered by the hash of the indexed key, or by
+// the key itself in an ordered index, and then by the id of the Record so many Records can share a key in a secondary
+// index.
+type entry struct {
+	hash    uint64
+	key     string
+	id      uint64
+	ordered bool
+	record  *Record
+}
+
+// Less is used to order entries and for looking up entries in the tree.
+func (e *entry) Less(than btree.Item) bool {
+	o := than.(*entry)
+	if e.ordered {
+		if e.key != o.key {
+			return e.key < o.key
+		}
+	} else if e.hash != o.hash {
+		return e.hash < o.hash
+	}
+
+	return e.id < o.id
+}
+
+// newEntry returns an entry for key ordered according to the index.
+func (idx *index) newEntry(key string, id uint64, r *Record) *entry {
+	e := &entry{key: key, id: id, ordered: idx.ordered, record: r}
+	if !idx.ordered {
+		e.hash = keyHash(key)
+	}
+
+	return e
+}
+
+// insert adds an entry for r under its key for the index's column, or under each trigram of the key in a text index.
+func (idx *index) insert(r *Record) {
+	key, _ := r.indexKey(idx.column)
+	if idx.text {
+		for _, g := range trigrams(key) {
+			idx.tree.ReplaceOrInsert(idx.newEntry(g, r.id, r))
+		}
+
+		return
+	}
+
+	idx.tree.ReplaceOrInsert(idx.newEntry(key, r.id, r))
+}
+
+// remove deletes the entries for r from the index.
+func (idx *index) remove(r *Record) {
+	key, _ := r.indexKey(idx.column)
+	if idx.text {
+		for _, g := range trigrams(key) {
+			idx.tree.Delete(idx.newEntry(g, r.id, nil))
+		}
+
+		return
+	}
+
+	idx.tree.Delete(idx.newEntry(key, r.id, nil))
+}
+
+// first returns the first Record indexed under key, or nil if there are none.
+func (idx *index) first(key string) (r *Record) {
+	idx.ascendKey(key, func(e *entry) bool {
+		r = e.record
+		return false
+	})
+
+	return
+}
+
+// ascendKey calls iter for each entry indexed under key until iter returns false.
+func (idx *index) ascendKey(key string, iter func(e *entry) bool) {
+	pivot := idx.newEntry(key, 0, nil)
+	idx.tree.AscendGreaterOrEqual(pivot, func(item btree.Item) bool {
+		e := item.(*entry)
+		if e.hash != pivot.hash || (idx.ordered && e.key != key) {
+			return false
+		}
+
+		if e.key != key {
+			return true
+		}
+
+		return iter(e)
+	})
 }
@@ -3,7 +3,9 @@ package ramdb
 import "sync"
 
 type database struct {
+	mutex  sync.RWMutex
 	tables map[string]*table
+	log    *writeAheadLog
 }
 
 // NewDatabase initializes a new database with no tables.
@@ -15,6 +17,9 @@ func NewDatabase() *database {
 
 // From selects a table for running commands.
 func (db *database) From(tablename string) *table {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+
 	t, ok := db.tables[tablename]
 	if !ok {
 		return &table{}
@@ -23,25 +28,60 @@ func (db *database) From(tablename string) *table {
 	return t
 }
 
-// CreateTable creates a new table in the database with indexes for each column specified.
+// HasTable returns true if a table named tablename exists and false if it does not.
+func (db *database) HasTable(tablename string) bool {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+
+	_, found := db.tables[tablename]
+	return found
+}
+
+// CreateTable creates a new table in the database with indexes for each column specified. The table and its indexes
+// are logged as a single entry once they have all been created, so a failed call leaves nothing behind to replay.
 func (db *database) CreateTable(tablename string, indexOnColumns ...string) error {
+	db.mutex.Lock()
+	defer db.mutex.Unlock()
+
 	if _, found := db.tables[tablename]; found {
 		return ErrTableExists
 	}
 
-	tbl := &table{
-		exists:  true,
-		mutex:   &sync.Mutex{},
-		indexes: make(map[string]*index),
-	}
+	entry := logEntry{Op: opCreateTable, Table: tablename}
+	ops := []logEntry{entry}
+	tbl := newTable(tablename, nil)
 
 	for _, onColumn := range indexOnColumns {
 		err := tbl.CreateIndex(onColumn)
 		if err != nil {
 			return err
 		}
+
+		ops = append(ops, logEntry{Op: opCreateIndex, Table: tablename, Column: onColumn})
+	}
+
+	if len(ops) > 1 {
+		entry = logEntry{Op: opBatch, Ops: ops}
+	}
+
+	if db.log != nil {
+		err := db.log.append(entry)
+		if err != nil {
+			return err
+		}
 	}
 
+	tbl.log = db.log
 	db.tables[tablename] = tbl
 	return nil
 }
+
+// Close flushes and closes the write-ahead log of a database returned by OpenDatabase. It is a no-op for databases
+// returned by NewDatabase.
+func (db *database) Close() error {
+	if db.log == nil {
+		return nil
+	}
+
+	return db.log.close()
+}
@@ -4,15 +4,15 @@ import (
 	"crypto/sha256"
 	"encoding/binary"
 	"encoding/json"
-
-	"github.com/google/btree"
 )
 
 type Record struct {
 	serialized []byte
 	keyColumn  string
 	key        string
+	keys       map[string]string
 	id         uint64
+	version    uint64
 }
 
 // NewRecord returns a pointer to a Record populated with data, key, and a hash of the key used for ordering in the tree.
@@ -22,12 +22,57 @@ func NewRecord(key, keyColumn string, data interface{}) (*Record, error) {
 		return nil, err
 	}
 
+	return newRecordFromSerialized(key, keyColumn, serialized), nil
+}
+
+// newRecordFromSerialized returns a Record for data that has already been serialized.
+func newRecordFromSerialized(key, keyColumn string, serialized []byte) *Record {
 	var r Record
 	r.serialized = serialized
 	r.keyColumn = keyColumn
 	r.key = key
 	r.id = keyHash(key)
-	return &r, nil
+	return &r
+}
+
+// WithKey sets the key the Record is indexed under for a secondary index on column, and returns the Record. It must
+// be called before the Record is inserted.
+func (r *Record) WithKey(column, key string) *Record {
+	if column == r.keyColumn {
+		r.key = key
+		r.id = keyHash(key)
+		return r
+	}
+
+	if r.keys == nil {
+		r.keys = make(map[string]string)
+	}
+
+	r.keys[column] = key
+	return r
+}
+
+// Version returns the version of a Record read from a table. It is 1 when the Record is inserted and is incremented
+// every time the Record is updated. Records that have not been stored have a version of 0.
+func (r *Record) Version() uint64 {
+	return r.version
+}
+
+// withVersion returns a copy of the Record at version.
+func (r *Record) withVersion(version uint64) *Record {
+	c := *r
+	c.version = version
+	return &c
+}
+
+// indexKey returns the key the Record is indexed under for column and whether it has one.
+func (r *Record) indexKey(column string) (key string, ok bool) {
+	if column == r.keyColumn {
+		return r.key, true
+	}
+
+	key, ok = r.keys[column]
+	return
 }
 
 func keyHash(s string) uint64 {
@@ -43,9 +88,3 @@ func (r *Record) Deserialize(into interface{}) error {
 	err := json.Unmarshal(r.serialized, &into)
 	return err
 }
-
-// Less is used to order items and for looking up Records in the tree.
-func (r *Record) Less(than btree.Item) bool {
-	re := than.(*Record)
-	return r.id < re.id
-}
@@ -50,6 +50,52 @@ func TestRecord_NewRecord(t *testing.T) {
 	}
 }
 
+func TestRecord_WithKey(t *testing.T) {
+	tests := []struct {
+		test           string
+		column         string
+		key            string
+		expectedRecord *Record
+	}{
+		{
+			test:   "it should set the key for a secondary index column",
+			column: "other-column",
+			key:    "other-key",
+			expectedRecord: &Record{
+				serialized: []byte("{}"),
+				key:        "test-record",
+				keyColumn:  "test-column",
+				keys:       map[string]string{"other-column": "other-key"},
+				id:         0x267fc212f178ef79,
+			},
+		},
+		{
+			test:   "it should replace the key and id for the key column",
+			column: "test-column",
+			key:    "test string",
+			expectedRecord: &Record{
+				serialized: []byte("{}"),
+				key:        "test string",
+				keyColumn:  "test-column",
+				id:         0xd5579c46dfcc7f18,
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			r, err := NewRecord("test-record", "test-column", struct{}{})
+			if err != nil {
+				t.Error(err)
+			}
+
+			r.WithKey(tc.column, tc.key)
+
+			assert.Equal(t, tc.expectedRecord, r)
+		})
+	}
+}
+
 func TestRecord_keyHash(t *testing.T) {
 	tests := []struct {
 		test   string
@@ -0,0 +1,192 @@
+package ramdb
+
+import (
+	"encoding/base64"
+	"encoding/json"
+
+	"github.com/google/btree"
+)
+
+// ScanOptions selects and orders the Records returned by Scan. Keys are compared byte-wise.
+type ScanOptions struct {
+	// From is the smallest key returned. An empty From has no lower bound.
+	From string
+	// To is the first key not returned. An empty To has no upper bound.
+	To string
+	// Prefix limits the scan to keys beginning with Prefix.
+	Prefix string
+	// Descending returns Records from the largest key to the smallest.
+	Descending bool
+	// After is a cursor returned by Record.Cursor for the scanned column and direction. Only Records after it in the scan
+	// order are returned, so passing the cursor of the last Record of one scan to the next returns the following page.
+	After string
+	// Filter, if set, is called for each Record in the scan and only Records it returns true for are returned. Records
+	// that are filtered out do not count towards Limit.
+	Filter func(r *Record) bool
+	// Limit is the most Records returned. A Limit of 0 returns every Record.
+	Limit int
+}
+
+// cursor is the position of a Record in an index scanned in one direction.
+type cursor struct {
+	Key        string `json:"k"`
+	ID         uint64 `json:"i"`
+	Column     string `json:"c"`
+	Descending bool   `json:"d,omitempty"`
+}
+
+// Cursor returns an opaque cursor for the Record's position in a scan of the index on column, for use as
+// ScanOptions.After in a scan of the same column in the same direction.
+func (r *Record) Cursor(column string, descending bool) string {
+	key, _ := r.indexKey(column)
+	b, _ := json.Marshal(cursor{Key: key, ID: r.id, Column: column, Descending: descending})
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// parseCursor returns the entry in idx that s is the cursor of. It returns ErrInvalidCursor if s is not a cursor, and
+// ErrCursorMismatch if it is the cursor of a scan of another column or in the other direction.
+func (idx *index) parseCursor(s string, descending bool) (*entry, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c cursor
+	err = json.Unmarshal(b, &c)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	if c.Column != idx.column || c.Descending != descending {
+		return nil, ErrCursorMismatch
+	}
+
+	return idx.newEntry(c.Key, c.ID, nil), nil
+}
+
+// Scan returns the Records in an ordered index matching opts, sorted by key. It returns ErrUnorderedIndex if the
+// index on column is not ordered.
+func (t *table) Scan(column string, opts ScanOptions) (rr []*Record, err error) {
+	if !t.exists {
+		return nil, ErrNoTable
+	}
+
+	idx, found := t.indexes()[column]
+	if !found {
+		return nil, ErrNoIndex
+	}
+
+	if idx.text {
+		return nil, ErrTextIndex
+	}
+
+	if !idx.ordered {
+		return nil, ErrUnorderedIndex
+	}
+
+	from, to := opts.From, opts.To
+	if opts.Prefix != "" {
+		if from < opts.Prefix {
+			from = opts.Prefix
+		}
+
+		if end := prefixEnd(opts.Prefix); end != "" && (to == "" || end < to) {
+			to = end
+		}
+	}
+
+	var after *entry
+	if opts.After != "" {
+		after, err = idx.parseCursor(opts.After, opts.Descending)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// add appends the Record of e to rr unless it is the cursor's or is filtered out, and returns false once rr is full.
+	add := func(e *entry) bool {
+		if after != nil && e.key == after.key && e.id == after.id {
+			return true
+		}
+
+		if opts.Filter != nil && !opts.Filter(e.record) {
+			return true
+		}
+
+		rr = append(rr, e.record)
+		return opts.Limit == 0 || len(rr) < opts.Limit
+	}
+
+	// The pivot entries have an id of 0, so they sort before every entry with the same key.
+	if opts.Descending {
+		iter := func(item btree.Item) bool {
+			e := item.(*entry)
+			if e.key < from {
+				return false
+			}
+
+			return add(e)
+		}
+
+		var pivot *entry
+		if to != "" {
+			pivot = idx.newEntry(to, 0, nil)
+		}
+
+		if after != nil && (pivot == nil || after.Less(pivot)) {
+			pivot = after
+		}
+
+		if pivot == nil {
+			idx.tree.Descend(iter)
+		} else {
+			idx.tree.DescendLessOrEqual(pivot, iter)
+		}
+
+		return
+	}
+
+	pivot := idx.newEntry(from, 0, nil)
+	if after != nil && pivot.Less(after) {
+		pivot = after
+	}
+
+	idx.tree.AscendGreaterOrEqual(pivot, func(item btree.Item) bool {
+		e := item.(*entry)
+		if to != "" && e.key >= to {
+			return false
+		}
+
+		return add(e)
+	})
+
+	return
+}
+
+// Range returns the Records in an ordered index with keys from from up to, but not including, to.
+func (t *table) Range(column, from, to string) ([]*Record, error) {
+	return t.Scan(column, ScanOptions{From: from, To: to})
+}
+
+// Prefix returns the Records in an ordered index with keys beginning with prefix.
+func (t *table) Prefix(column, prefix string) ([]*Record, error) {
+	return t.Scan(column, ScanOptions{Prefix: prefix})
+}
+
+// Descend returns every Record in an ordered index from the largest key to the smallest.
+func (t *table) Descend(column string) ([]*Record, error) {
+	return t.Scan(column, ScanOptions{Descending: true})
+}
+
+// prefixEnd returns the smallest key greater than every key beginning with prefix, or "" if there is none.
+func prefixEnd(prefix string) string {
+	b := []byte(prefix)
+	for i := len(b) - 1; i >= 0; i-- {
+		if b[i] < 0xff {
+			b[i]++
+			return string(b[:i+1])
+		}
+	}
+
+	return ""
+}
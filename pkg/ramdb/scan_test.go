@@ -0,0 +1,235 @@
+package ramdb
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTable_Scan(t *testing.T) {
+	keys := []string{"a12t-0001", "a12t-0002", "a12t-0003", "b000-0001", "e5t6-0001", "e5t6-0002"}
+
+	tests := []struct {
+		test          string
+		ordered       bool
+		opts          ScanOptions
+		afterKey      string
+		afterColumn   string
+		afterReversed bool
+		expectedKeys  []string
+		expectedError error
+	}{
+		{
+			test:          "it should return ErrUnorderedIndex if the index is not ordered",
+			opts:          ScanOptions{},
+			expectedError: ErrUnorderedIndex,
+		},
+		{
+			test:         "it should return every Record in key order",
+			ordered:      true,
+			opts:         ScanOptions{},
+			expectedKeys: keys,
+		},
+		{
+			test:         "it should return Records from From up to but not including To",
+			ordered:      true,
+			opts:         ScanOptions{From: "a12t-0002", To: "e5t6-0001"},
+			expectedKeys: []string{"a12t-0002", "a12t-0003", "b000-0001"},
+		},
+		{
+			test:         "it should return Records with keys beginning with Prefix",
+			ordered:      true,
+			opts:         ScanOptions{Prefix: "a12t-"},
+			expectedKeys: []string{"a12t-0001", "a12t-0002", "a12t-0003"},
+		},
+		{
+			test:         "it should return no Records if none begin with Prefix",
+			ordered:      true,
+			opts:         ScanOptions{Prefix: "zzzz"},
+			expectedKeys: nil,
+		},
+		{
+			test:         "it should return every Record in descending key order",
+			ordered:      true,
+			opts:         ScanOptions{Descending: true},
+			expectedKeys: []string{"e5t6-0002", "e5t6-0001", "b000-0001", "a12t-0003", "a12t-0002", "a12t-0001"},
+		},
+		{
+			test:         "it should return a range in descending key order",
+			ordered:      true,
+			opts:         ScanOptions{From: "a12t-0002", To: "e5t6-0001", Descending: true},
+			expectedKeys: []string{"b000-0001", "a12t-0003", "a12t-0002"},
+		},
+		{
+			test:         "it should return a prefix in descending key order",
+			ordered:      true,
+			opts:         ScanOptions{Prefix: "e5t6", Descending: true},
+			expectedKeys: []string{"e5t6-0002", "e5t6-0001"},
+		},
+		{
+			test:         "it should return at most Limit Records",
+			ordered:      true,
+			opts:         ScanOptions{From: "a12t-0002", Limit: 2},
+			expectedKeys: []string{"a12t-0002", "a12t-0003"},
+		},
+		{
+			test:         "it should return Records after the cursor",
+			ordered:      true,
+			opts:         ScanOptions{Limit: 2},
+			afterKey:     "a12t-0003",
+			expectedKeys: []string{"b000-0001", "e5t6-0001"},
+		},
+		{
+			test:         "it should return Records after the cursor in descending key order",
+			ordered:      true,
+			opts:         ScanOptions{Descending: true, Limit: 2},
+			afterKey:     "b000-0001",
+			expectedKeys: []string{"a12t-0003", "a12t-0002"},
+		},
+		{
+			test:         "it should return Records after the cursor within a range",
+			ordered:      true,
+			opts:         ScanOptions{From: "a12t-0002", To: "e5t6-0002"},
+			afterKey:     "a12t-0001",
+			expectedKeys: []string{"a12t-0002", "a12t-0003", "b000-0001", "e5t6-0001"},
+		},
+		{
+			test:    "it should return only Records the filter accepts and not count the rest towards Limit",
+			ordered: true,
+			opts: ScanOptions{
+				Filter: func(r *Record) bool { return r.key[len(r.key)-1] != '1' },
+				Limit:  2,
+			},
+			expectedKeys: []string{"a12t-0002", "a12t-0003"},
+		},
+		{
+			test:          "it should return ErrInvalidCursor if the cursor is invalid",
+			ordered:       true,
+			opts:          ScanOptions{After: "not a cursor"},
+			expectedError: ErrInvalidCursor,
+		},
+		{
+			test:          "it should return ErrCursorMismatch if the cursor is for the other direction",
+			ordered:       true,
+			opts:          ScanOptions{},
+			afterKey:      "a12t-0001",
+			afterReversed: true,
+			expectedError: ErrCursorMismatch,
+		},
+		{
+			test:          "it should return ErrCursorMismatch if the cursor is for another column",
+			ordered:       true,
+			opts:          ScanOptions{},
+			afterKey:      "a12t-0001",
+			afterColumn:   "other_column",
+			expectedError: ErrCursorMismatch,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			db := NewDatabase()
+			db.CreateTable("test_table")
+			tbl := db.From("test_table")
+			if tc.ordered {
+				tbl.CreateOrderedIndex("test_column")
+			} else {
+				tbl.CreateIndex("test_column")
+			}
+
+			for _, key := range keys {
+				rec, err := NewRecord(key, "test_column", struct{}{})
+				if err != nil {
+					t.Error(err)
+				}
+				tbl.Insert(rec)
+			}
+
+			if tc.afterKey != "" {
+				rec, err := NewRecord(tc.afterKey, "test_column", struct{}{})
+				if err != nil {
+					t.Error(err)
+				}
+				column := "test_column"
+				if tc.afterColumn != "" {
+					column = tc.afterColumn
+				}
+				tc.opts.After = rec.Cursor(column, tc.opts.Descending != tc.afterReversed)
+			}
+
+			rr, err := tbl.Scan("test_column", tc.opts)
+
+			var scanned []string
+			for _, r := range rr {
+				scanned = append(scanned, r.key)
+			}
+
+			assert.Equal(t, tc.expectedKeys, scanned)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestTable_OrderedSecondaryIndex(t *testing.T) {
+	t.Run("it should order Records sharing a secondary key by id", func(t *testing.T) {
+		db := NewDatabase()
+		db.CreateTable("test_table", "key_column")
+		tbl := db.From("test_table")
+		tbl.CreateOrderedIndex("name")
+
+		for _, kv := range [][2]string{{"key-1", "pepper"}, {"key-2", "apple"}, {"key-3", "pepper"}} {
+			rec, err := NewRecord(kv[0], "key_column", struct{}{})
+			if err != nil {
+				t.Error(err)
+			}
+			assert.Nil(t, tbl.Insert(rec.WithKey("name", kv[1])))
+		}
+
+		rr, err := tbl.Lookup("name", "pepper")
+		assert.Nil(t, err)
+		assert.Len(t, rr, 2)
+		assert.True(t, rr[0].id < rr[1].id)
+
+		r, err := tbl.Get("name", "apple")
+		assert.Nil(t, err)
+		assert.Equal(t, "key-2", r.key)
+
+		rr, err = tbl.Range("name", "b", "")
+		assert.Nil(t, err)
+		assert.Len(t, rr, 2)
+
+		// Paging one Record at a time must visit both Records sharing a key.
+		var keys []string
+		opts := ScanOptions{Limit: 1}
+		for {
+			rr, err = tbl.Scan("name", opts)
+			assert.Nil(t, err)
+			if len(rr) == 0 {
+				break
+			}
+
+			keys = append(keys, rr[0].key)
+			opts.After = rr[0].Cursor("name", false)
+		}
+		assert.Len(t, keys, 3)
+		assert.Equal(t, "key-2", keys[0])
+	})
+}
+
+func TestDatabase_Snapshot_OrderedIndex(t *testing.T) {
+	t.Run("it should restore ordered indexes as ordered", func(t *testing.T) {
+		db := NewDatabase()
+		db.CreateTable("test_table")
+		db.From("test_table").CreateOrderedIndex("test_column")
+
+		var buf bytes.Buffer
+		assert.Nil(t, db.Snapshot(&buf))
+
+		restored, err := LoadSnapshot(&buf)
+		assert.Nil(t, err)
+
+		_, err = restored.From("test_table").Descend("test_column")
+		assert.Nil(t, err)
+	})
+}
@@ -0,0 +1,145 @@
+package ramdb
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// DefaultSearchThreshold is the fraction of a query's trigrams a key must share to match it when
+// SearchOptions.Threshold is 0.
+const DefaultSearchThreshold = 0.5
+
+// SearchOptions limits the Records returned by Search.
+type SearchOptions struct {
+	// Threshold is the fraction of the query's trigrams, from 0 to 1, that a key must share to match. Keys with a word
+	// beginning with each word of the query always match. A Threshold of 0 uses DefaultSearchThreshold.
+	Threshold float64
+	// Limit is the most results returned. A Limit of 0 returns every match.
+	Limit int
+}
+
+// SearchResult is a Record matched by Search and how relevant it is to the query.
+type SearchResult struct {
+	Record *Record
+	// Score is the similarity of the key to the query, from 0 to 1, plus 1 if each word of the query begins a word of
+	// the key. Higher scores are more relevant.
+	Score float64
+}
+
+// Search returns the Records in a text index whose keys are similar to query, most relevant first. Keys and queries
+// are compared by the trigrams of their words ignoring case and punctuation, so misspellings and partial words still
+// match. Records with the same score are ordered by key. It returns ErrNotTextIndex if the index on column is not a
+// text index.
+func (t *table) Search(column, query string, opts SearchOptions) ([]SearchResult, error) {
+	if !t.exists {
+		return nil, ErrNoTable
+	}
+
+	idx, found := t.indexes()[column]
+	if !found {
+		return nil, ErrNoIndex
+	}
+
+	if !idx.text {
+		return nil, ErrNotTextIndex
+	}
+
+	grams := trigrams(query)
+	if len(grams) == 0 {
+		return nil, nil
+	}
+
+	threshold := opts.Threshold
+	if threshold <= 0 {
+		threshold = DefaultSearchThreshold
+	}
+
+	shared := make(map[uint64]int)
+	records := make(map[uint64]*Record)
+	for _, g := range grams {
+		idx.ascendKey(g, func(e *entry) bool {
+			shared[e.id]++
+			records[e.id] = e.record
+			return true
+		})
+	}
+
+	words := searchWords(query)
+	var results []SearchResult
+	for id, n := range shared {
+		r := records[id]
+		key, _ := r.indexKey(column)
+
+		prefixed := hasWordPrefixes(searchWords(key), words)
+		if !prefixed && float64(n)/float64(len(grams)) < threshold {
+			continue
+		}
+
+		score := float64(n) / float64(len(grams)+len(trigrams(key))-n)
+		if prefixed {
+			score++
+		}
+
+		results = append(results, SearchResult{Record: r, Score: score})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+
+		return results[i].Record.key < results[j].Record.key
+	})
+
+	if opts.Limit > 0 && len(results) > opts.Limit {
+		results = results[:opts.Limit]
+	}
+
+	return results, nil
+}
+
+// searchWords returns the lower-cased words of s, split on anything that isn't a letter or digit.
+func searchWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// trigrams returns the distinct trigrams of the words of s. Each word is padded with two spaces before it and one
+// after, so short words have trigrams and the start of a word weighs more than its end.
+func trigrams(s string) []string {
+	seen := make(map[string]bool)
+	var grams []string
+	for _, word := range searchWords(s) {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			g := string(padded[i : i+3])
+			if !seen[g] {
+				seen[g] = true
+				grams = append(grams, g)
+			}
+		}
+	}
+
+	return grams
+}
+
+// hasWordPrefixes returns true if every word in prefixes begins one of words.
+func hasWordPrefixes(words, prefixes []string) bool {
+	for _, p := range prefixes {
+		found := false
+		for _, w := range words {
+			if strings.HasPrefix(w, p) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,182 @@
+package ramdb
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newSearchTable returns a table with a text index on "name" holding a Record for each key and name pair.
+func newSearchTable(t *testing.T, names map[string]string) *table {
+	db := NewDatabase()
+	db.CreateTable("test_table", "key_column")
+	tbl := db.From("test_table")
+	assert.Nil(t, tbl.CreateTextIndex("name"))
+
+	for key, name := range names {
+		rec, err := NewRecord(key, "key_column", struct{}{})
+		if err != nil {
+			t.Error(err)
+		}
+		assert.Nil(t, tbl.Insert(rec.WithKey("name", name)))
+	}
+
+	return tbl
+}
+
+// resultKeys returns the keys of the Records in results, in order.
+func resultKeys(results []SearchResult) (keys []string) {
+	for _, r := range results {
+		keys = append(keys, r.Record.key)
+	}
+
+	return
+}
+
+func TestTable_Search(t *testing.T) {
+	names := map[string]string{
+		"a": "Gala Apple",
+		"b": "Banana",
+		"c": "Green Pepper",
+		"d": "Lettuce",
+		"e": "Pineapple",
+	}
+
+	tests := []struct {
+		test          string
+		column        string
+		query         string
+		opts          SearchOptions
+		expectedKeys  []string
+		expectedError error
+	}{
+		{
+			test:          "it should return ErrNoIndex if the column has no index",
+			column:        "missing",
+			query:         "apple",
+			expectedError: ErrNoIndex,
+		},
+		{
+			test:          "it should return ErrNotTextIndex if the index is not a text index",
+			column:        "key_column",
+			query:         "apple",
+			expectedError: ErrNotTextIndex,
+		},
+		{
+			test:         "it should ignore case",
+			column:       "name",
+			query:        "BANANA",
+			expectedKeys: []string{"b"},
+		},
+		{
+			test:         "it should match misspellings",
+			column:       "name",
+			query:        "bananna",
+			expectedKeys: []string{"b"},
+		},
+		{
+			test:         "it should rank keys with words beginning with the query first",
+			column:       "name",
+			query:        "apple",
+			expectedKeys: []string{"a", "e"},
+		},
+		{
+			test:         "it should match the beginning of a word",
+			column:       "name",
+			query:        "pep",
+			expectedKeys: []string{"c"},
+		},
+		{
+			test:         "it should match words in any order",
+			column:       "name",
+			query:        "apple gala",
+			expectedKeys: []string{"a"},
+		},
+		{
+			test:         "it should return at most Limit results",
+			column:       "name",
+			query:        "apple",
+			opts:         SearchOptions{Limit: 1},
+			expectedKeys: []string{"a"},
+		},
+		{
+			test:         "it should return nothing for a query without words",
+			column:       "name",
+			query:        " ?! ",
+			expectedKeys: nil,
+		},
+		{
+			test:         "it should return nothing if no key is similar",
+			column:       "name",
+			query:        "zucchini",
+			expectedKeys: nil,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			tbl := newSearchTable(t, names)
+
+			results, err := tbl.Search(tc.column, tc.query, tc.opts)
+			assert.Equal(t, tc.expectedError, err)
+			assert.Equal(t, tc.expectedKeys, resultKeys(results))
+		})
+	}
+}
+
+func TestTable_Search_StaysInSync(t *testing.T) {
+	t.Run("it should reflect updates and deletes", func(t *testing.T) {
+		tbl := newSearchTable(t, map[string]string{"a": "Gala Apple", "b": "Banana"})
+
+		rec, _ := NewRecord("a", "key_column", struct{}{})
+		assert.Nil(t, tbl.Update(rec.WithKey("name", "Red Onion")))
+
+		results, err := tbl.Search("name", "apple", SearchOptions{})
+		assert.Nil(t, err)
+		assert.Empty(t, results)
+
+		results, err = tbl.Search("name", "onion", SearchOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"a"}, resultKeys(results))
+
+		rec, _ = NewRecord("b", "key_column", nil)
+		assert.Nil(t, tbl.Delete(rec))
+
+		results, err = tbl.Search("name", "banana", SearchOptions{})
+		assert.Nil(t, err)
+		assert.Empty(t, results)
+	})
+
+	t.Run("it should return ErrTextIndex for lookups on a text index", func(t *testing.T) {
+		tbl := newSearchTable(t, map[string]string{"a": "Gala Apple"})
+
+		_, err := tbl.Get("name", "gal")
+		assert.Equal(t, ErrTextIndex, err)
+
+		_, err = tbl.Scan("name", ScanOptions{})
+		assert.Equal(t, ErrTextIndex, err)
+	})
+}
+
+func TestDatabase_Snapshot_TextIndex(t *testing.T) {
+	t.Run("it should restore text indexes as text indexes", func(t *testing.T) {
+		db := NewDatabase()
+		db.CreateTable("test_table", "key_column")
+		tbl := db.From("test_table")
+		tbl.CreateTextIndex("name")
+
+		rec, _ := NewRecord("a", "key_column", struct{}{})
+		assert.Nil(t, tbl.Insert(rec.WithKey("name", "Gala Apple")))
+
+		var buf bytes.Buffer
+		assert.Nil(t, db.Snapshot(&buf))
+
+		restored, err := LoadSnapshot(&buf)
+		assert.Nil(t, err)
+
+		results, err := restored.From("test_table").Search("name", "gala", SearchOptions{})
+		assert.Nil(t, err)
+		assert.Equal(t, []string{"a"}, resultKeys(results))
+	})
+}
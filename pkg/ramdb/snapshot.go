@@ -0,0 +1,216 @@
+package ramdb
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/google/btree"
+)
+
+const snapshotFormat = "ramdb-snapshot"
+const snapshotVersion = 1
+
+// snapshotHeader is the first line of every snapshot.
+type snapshotHeader struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+}
+
+// tableSnapshot is a point-in-time copy of a table's indexes.
+type tableSnapshot struct {
+	name    string
+	indexes map[string]*btree.BTree
+	ordered map[string]bool
+	text    map[string]bool
+}
+
+// Snapshot writes a consistent copy of every table, index and record in the database to w. Tables are only locked
+// long enough to load their current indexes, so Insert and Delete are not blocked while the snapshot is written.
+func (db *database) Snapshot(w io.Writer) error {
+	snapshots := db.cloneTables()
+
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	err := enc.Encode(snapshotHeader{Format: snapshotFormat, Version: snapshotVersion})
+	if err != nil {
+		return err
+	}
+
+	for _, ts := range snapshots {
+		err = ts.encode(enc)
+		if err != nil {
+			return err
+		}
+	}
+
+	return bw.Flush()
+}
+
+// SnapshotFile writes a snapshot to path. The snapshot is written to a temporary file which is renamed over path once
+// it is complete, so an interrupted snapshot never replaces a good one.
+func (db *database) SnapshotFile(path string) error {
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	err = db.Snapshot(f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	err = f.Sync()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	err = f.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// Restore loads the tables, indexes and records in a snapshot into the database. It returns ErrTableExists if a
+// table in the snapshot already exists. When the database has a write-ahead log the restored data is logged.
+func (db *database) Restore(r io.Reader) error {
+	dec := json.NewDecoder(bufio.NewReader(r))
+
+	var header snapshotHeader
+	err := dec.Decode(&header)
+	if err != nil || header.Format != snapshotFormat || header.Version != snapshotVersion {
+		return ErrInvalidSnapshot
+	}
+
+	for {
+		var entry logEntry
+		err = dec.Decode(&entry)
+		if err == io.EOF {
+			return nil
+		}
+
+		if err != nil {
+			return ErrInvalidSnapshot
+		}
+
+		err = db.apply(entry)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// LoadSnapshot returns a new in-memory database populated from the snapshot in r.
+func LoadSnapshot(r io.Reader) (*database, error) {
+	db := NewDatabase()
+	err := db.Restore(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// LoadSnapshotFile returns a new in-memory database populated from the snapshot at path.
+func LoadSnapshotFile(path string) (*database, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return LoadSnapshot(f)
+}
+
+// cloneTables locks every table, loads its indexes and unlocks them again. Holding every table lock at once makes
+// the snapshot consistent with transactions that span tables.
+func (db *database) cloneTables() []tableSnapshot {
+	db.mutex.RLock()
+	defer db.mutex.RUnlock()
+
+	names := make([]string, 0, len(db.tables))
+	for name := range db.tables {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		db.tables[name].mutex.Lock()
+	}
+
+	snapshots := make([]tableSnapshot, 0, len(names))
+	for _, name := range names {
+		indexes := db.tables[name].indexes()
+		ts := tableSnapshot{
+			name:    name,
+			indexes: make(map[string]*btree.BTree, len(indexes)),
+			ordered: make(map[string]bool, len(indexes)),
+			text:    make(map[string]bool, len(indexes)),
+		}
+
+		for column, index := range indexes {
+			ts.indexes[column] = index.tree
+			ts.ordered[column] = index.ordered
+			ts.text[column] = index.text
+		}
+
+		snapshots = append(snapshots, ts)
+	}
+
+	for _, name := range names {
+		db.tables[name].mutex.Unlock()
+	}
+
+	return snapshots
+}
+
+// encode writes the table, its indexes and its records to enc as log entries.
+func (ts tableSnapshot) encode(enc *json.Encoder) error {
+	err := enc.Encode(logEntry{Op: opCreateTable, Table: ts.name})
+	if err != nil {
+		return err
+	}
+
+	columns := make([]string, 0, len(ts.indexes))
+	for column := range ts.indexes {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	for _, column := range columns {
+		err = enc.Encode(logEntry{Op: opCreateIndex, Table: ts.name, Column: column, Ordered: ts.ordered[column], Text: ts.text[column]})
+		if err != nil {
+			return err
+		}
+	}
+
+	// Every Record is in all of the table's indexes, so it is only written from the index on its key column.
+	for _, column := range columns {
+		ts.indexes[column].Ascend(func(item btree.Item) bool {
+			r := item.(*entry).record
+			if r.keyColumn != column {
+				return true
+			}
+
+			entry := insertEntry(r)
+			entry.Table = ts.name
+			err = enc.Encode(entry)
+			return err == nil
+		})
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
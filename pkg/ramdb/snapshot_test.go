@@ -0,0 +1,153 @@
+package ramdb
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDatabase_Snapshot(t *testing.T) {
+	t.Run("it should restore every table, index and record in the snapshot", func(t *testing.T) {
+		db := NewDatabase()
+		assert.Nil(t, db.CreateTable("table_a", "column_a"))
+		assert.Nil(t, db.CreateTable("table_b", "column_b", "column_c"))
+
+		for i := 0; i < 10; i++ {
+			rec, err := NewRecord(fmt.Sprintf("key-%d", i), "column_a", struct{ I int }{I: i})
+			if err != nil {
+				t.Fatal(err)
+			}
+			assert.Nil(t, db.From("table_a").Insert(rec))
+		}
+
+		rec, err := NewRecord("key-b", "column_c", struct{}{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Nil(t, db.From("table_b").Insert(rec.WithKey("column_b", "secondary-key")))
+
+		var buf bytes.Buffer
+		assert.Nil(t, db.Snapshot(&buf))
+
+		restored, err := LoadSnapshot(&buf)
+		assert.Nil(t, err)
+
+		expectedA, _ := db.From("table_a").Select("column_a")
+		restoredA, err := restored.From("table_a").Select("column_a")
+		assert.Nil(t, err)
+		assert.Equal(t, expectedA, restoredA)
+
+		restoredB, err := restored.From("table_b").Get("column_c", "key-b")
+		assert.Nil(t, err)
+		assert.Equal(t, rec.withVersion(1), restoredB)
+
+		restoredB, err = restored.From("table_b").Get("column_b", "secondary-key")
+		assert.Nil(t, err)
+		assert.Equal(t, rec.withVersion(1), restoredB)
+	})
+}
+
+func TestDatabase_Restore(t *testing.T) {
+	tests := []struct {
+		test          string
+		snapshot      string
+		expectFunc    func(t *testing.T, db *database)
+		expectedError error
+	}{
+		{
+			test:          "it should return ErrInvalidSnapshot if the header is missing",
+			snapshot:      `{"op":"create_table","table":"test_table"}`,
+			expectFunc:    func(t *testing.T, db *database) {},
+			expectedError: ErrInvalidSnapshot,
+		},
+		{
+			test:          "it should return ErrInvalidSnapshot if the snapshot is not json",
+			snapshot:      "{\"format\":\"ramdb-snapshot\",\"version\":1}\nnot json",
+			expectFunc:    func(t *testing.T, db *database) {},
+			expectedError: ErrInvalidSnapshot,
+		},
+		{
+			test:     "it should return ErrTableExists if a table in the snapshot exists",
+			snapshot: "{\"format\":\"ramdb-snapshot\",\"version\":1}\n{\"op\":\"create_table\",\"table\":\"test_table\"}",
+			expectFunc: func(t *testing.T, db *database) {
+				db.CreateTable("test_table")
+			},
+			expectedError: ErrTableExists,
+		},
+		{
+			test:       "it should restore an empty snapshot",
+			snapshot:   "{\"format\":\"ramdb-snapshot\",\"version\":1}\n",
+			expectFunc: func(t *testing.T, db *database) {},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			db := NewDatabase()
+			tc.expectFunc(t, db)
+
+			err := db.Restore(strings.NewReader(tc.snapshot))
+
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestDatabase_SnapshotFile(t *testing.T) {
+	t.Run("it should write a snapshot file that can be loaded", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ramdb.snapshot")
+
+		db := NewDatabase()
+		assert.Nil(t, db.CreateTable("test_table", "test_column"))
+
+		rec, err := NewRecord("test_key", "test_column", struct{}{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Nil(t, db.From("test_table").Insert(rec))
+		assert.Nil(t, db.SnapshotFile(path))
+
+		restored, err := LoadSnapshotFile(path)
+		assert.Nil(t, err)
+
+		r, err := restored.From("test_table").Get("test_column", "test_key")
+		assert.Nil(t, err)
+		assert.Equal(t, rec.withVersion(1), r)
+	})
+}
+
+func TestDatabase_Snapshot_Concurrent(t *testing.T) {
+	t.Run("it should take consistent snapshots while records are inserted", func(t *testing.T) {
+		db := NewDatabase()
+		assert.Nil(t, db.CreateTable("test_table", "test_column"))
+
+		var wg sync.WaitGroup
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				rec, err := NewRecord(fmt.Sprintf("key-%d", i), "test_column", struct{}{})
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				db.From("test_table").Insert(rec)
+			}
+		}()
+
+		for i := 0; i < 20; i++ {
+			var buf bytes.Buffer
+			assert.Nil(t, db.Snapshot(&buf))
+
+			_, err := LoadSnapshot(&buf)
+			assert.Nil(t, err)
+		}
+
+		wg.Wait()
+	})
+}
@@ -2,44 +2,140 @@ package ramdb
 
 import (
 	"sync"
+	"sync/atomic"
 
 	"github.com/google/btree"
 )
 
+// table holds its indexes in an atomic.Value. The indexes it holds are never modified: writers hold mutex, apply
+// their changes to copy-on-write clones of the indexes, and store the clones. Readers load the indexes without
+// locking and see a consistent view of every index on the table, however long they hold it for.
 type table struct {
-	exists  bool
-	mutex   *sync.Mutex
-	indexes map[string]*index
+	exists bool
+	name   string
+	mutex  *sync.Mutex
+	state  atomic.Value
+	log    *writeAheadLog
+	feed   *feed
 }
 
-// CreateIndex creates an index for onColumn.
+// newTable returns an empty table named name that logs to log.
+func newTable(name string, log *writeAheadLog) *table {
+	t := &table{
+		exists: true,
+		name:   name,
+		mutex:  &sync.Mutex{},
+		log:    log,
+	}
+	t.feed = newFeed(t)
+	t.state.Store(make(map[string]*index))
+
+	return t
+}
+
+// indexes returns the table's current indexes. The returned map and indexes must not be modified.
+func (t *table) indexes() map[string]*index {
+	indexes, _ := t.state.Load().(map[string]*index)
+	return indexes
+}
+
+// CreateIndex creates an index for onColumn. Records already in the table are indexed under their key for the
+// column, which is empty for Records stored without one.
 func (t *table) CreateIndex(column string) error {
+	return t.createIndex(column, false, false)
+}
+
+// CreateOrderedIndex creates an index for onColumn that is ordered by the key itself instead of by its hash, which
+// allows range, prefix and descending scans. Records already in the table are indexed as by CreateIndex.
+func (t *table) CreateOrderedIndex(column string) error {
+	return t.createIndex(column, true, false)
+}
+
+// CreateTextIndex creates an index for onColumn that supports fuzzy matching of its keys with Search. A text index
+// can't be the key column of a table, and Get, Lookup, Select and Scan return ErrTextIndex for it. Records already in
+// the table are indexed as by CreateIndex.
+func (t *table) CreateTextIndex(column string) error {
+	return t.createIndex(column, true, true)
+}
+
+func (t *table) createIndex(column string, ordered, text bool) error {
 	if column == "" {
 		return ErrInvalidIndex
 	}
 
-	if _, found := t.indexes[column]; found {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	indexes := t.indexes()
+	if _, found := indexes[column]; found {
 		return ErrIndexExists
 	}
 
-	idx := &index{
-		tree:   btree.New(5),
-		column: column,
-		table:  t,
+	err := t.writeLog(logEntry{Op: opCreateIndex, Column: column, Ordered: ordered, Text: text})
+	if err != nil {
+		return err
 	}
 
-	t.mutex.Lock()
-	defer t.mutex.Unlock()
+	updated := make(map[string]*index, len(indexes)+1)
+	for c, idx := range indexes {
+		updated[c] = idx
+	}
+
+	created := &index{
+		tree:    btree.New(5),
+		column:  column,
+		ordered: ordered,
+		text:    text,
+		table:   t,
+	}
 
-	t.indexes[column] = idx
+	// Every Record has one entry in each index that isn't a text index, so any of them lists the Records to backfill.
+	for _, idx := range indexes {
+		if idx.text {
+			continue
+		}
+
+		idx.tree.Ascend(func(i btree.Item) bool {
+			created.insert(i.(*entry).record)
+			return true
+		})
+		break
+	}
+
+	updated[column] = created
+	t.state.Store(updated)
 	return nil
 }
 
 // HasIndex returns true if an index exists for the column and false if it does not.
 func (t *table) HasIndex(column string) bool {
-	if _, found := t.indexes[column]; found {
+	if _, found := t.indexes()[column]; found {
 		return true
 	}
 
 	return false
 }
+
+// cloneIndexes returns a copy-on-write clone of every index on the table. The caller must hold the table's mutex.
+func (t *table) cloneIndexes() map[string]*index {
+	indexes := t.indexes()
+	clones := make(map[string]*index, len(indexes))
+	for column, idx := range indexes {
+		clone := *idx
+		clone.tree = idx.tree.Clone()
+		clones[column] = &clone
+	}
+
+	return clones
+}
+
+// writeLog appends entry to the table's write-ahead log, if it has one. The caller must hold the table's mutex so
+// entries are logged in the order they are applied.
+func (t *table) writeLog(entry logEntry) error {
+	if t.log == nil {
+		return nil
+	}
+
+	entry.Table = t.name
+	return t.log.append(entry)
+}
@@ -1,39 +1,47 @@
 package ramdb
 
 import (
-	"sync"
 	"testing"
 
+	"github.com/google/btree"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestTable_CreateIndex(t *testing.T) {
 	tests := []struct {
 		test          string
-		table         table
+		table         *table
 		column        string
 		expectedError error
 	}{
 		{
 			test:          "it should return ErrInvalidIndex if column is empty",
+			table:         testTable(nil),
 			expectedError: ErrInvalidIndex,
 		},
 		{
 			test: "it should return ErrIndexExists if an index exists for column",
-			table: table{
-				mutex: &sync.Mutex{},
-				indexes: map[string]*index{
-					"test_column": &index{},
-				}},
+			table: testTable(map[string]*index{
+				"test_column": &index{},
+			}),
 			column:        "test_column",
 			expectedError: ErrIndexExists,
 		},
 		{
-			test: "it should create an index successfully",
-			table: table{
-				mutex:   &sync.Mutex{},
-				indexes: make(map[string]*index),
-			},
+			test: "it should create an index on a table with Records",
+			table: testTable(map[string]*index{
+				"key_column": func() *index {
+					idx := &index{tree: btree.New(5), column: "key_column"}
+					rec, _ := NewRecord("test_key", "key_column", struct{}{})
+					idx.insert(rec)
+					return idx
+				}(),
+			}),
+			column: "test_column",
+		},
+		{
+			test:   "it should create an index successfully",
+			table:  testTable(nil),
 			column: "test_column",
 		},
 	}
@@ -47,6 +55,76 @@ func TestTable_CreateIndex(t *testing.T) {
 	}
 }
 
+func TestTable_CreateIndex_backfill(t *testing.T) {
+	tests := []struct {
+		test        string
+		createIndex func(tbl *table) error
+		assertFunc  func(t *testing.T, tbl *table)
+	}{
+		{
+			test:        "it should index the Records already in the table",
+			createIndex: func(tbl *table) error { return tbl.CreateIndex("name_column") },
+			assertFunc: func(t *testing.T, tbl *table) {
+				recs, err := tbl.Lookup("name_column", "lettuce")
+				assert.Nil(t, err)
+				assert.Len(t, recs, 1)
+				assert.Equal(t, "key-1", recs[0].key)
+			},
+		},
+		{
+			test:        "it should index the Records already in the table in order",
+			createIndex: func(tbl *table) error { return tbl.CreateOrderedIndex("name_column") },
+			assertFunc: func(t *testing.T, tbl *table) {
+				recs, err := tbl.Scan("name_column", ScanOptions{Prefix: "p"})
+				assert.Nil(t, err)
+				assert.Len(t, recs, 2)
+				assert.Equal(t, "key-2", recs[0].key)
+				assert.Equal(t, "key-3", recs[1].key)
+			},
+		},
+		{
+			test:        "it should index the Records already in the table for search",
+			createIndex: func(tbl *table) error { return tbl.CreateTextIndex("name_column") },
+			assertFunc: func(t *testing.T, tbl *table) {
+				results, err := tbl.Search("name_column", "letuce", SearchOptions{})
+				assert.Nil(t, err)
+				assert.Len(t, results, 1)
+				assert.Equal(t, "key-1", results[0].Record.key)
+			},
+		},
+		{
+			test:        "it should index Records without a key for the column under the empty key",
+			createIndex: func(tbl *table) error { return tbl.CreateIndex("name_column") },
+			assertFunc: func(t *testing.T, tbl *table) {
+				recs, err := tbl.Lookup("name_column", "")
+				assert.Nil(t, err)
+				assert.Len(t, recs, 1)
+				assert.Equal(t, "key-4", recs[0].key)
+
+				// The Record is removed from the index with the rest of the table.
+				assert.Nil(t, tbl.Delete(testRecord(t, "key-4")))
+				_, err = tbl.Lookup("name_column", "")
+				assert.Equal(t, ErrNoRecord, err)
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			db := NewDatabase()
+			assert.Nil(t, db.CreateTable("test_table", "test_column"))
+			tbl := db.From("test_table")
+			for key, name := range map[string]string{"key-1": "lettuce", "key-2": "pepper", "key-3": "potato"} {
+				assert.Nil(t, tbl.Insert(testRecord(t, key).WithKey("name_column", name)))
+			}
+			assert.Nil(t, tbl.Insert(testRecord(t, "key-4")))
+
+			assert.Nil(t, tc.createIndex(tbl))
+			tc.assertFunc(t, tbl)
+		})
+	}
+}
+
 func TestTable_HasIndex(t *testing.T) {
 	tests := []struct {
 		test        string
@@ -56,20 +134,16 @@ func TestTable_HasIndex(t *testing.T) {
 		{
 			test: "it should return true if index exists",
 			tableConfig: func() *table {
-				return &table{
-					indexes: map[string]*index{
-						"test_column": &index{},
-					},
-				}
+				return testTable(map[string]*index{
+					"test_column": &index{},
+				})
 			},
 			expectedHas: true,
 		},
 		{
 			test: "it should return false if index does not exist",
 			tableConfig: func() *table {
-				return &table{
-					indexes: make(map[string]*index),
-				}
+				return testTable(nil)
 			},
 		},
 	}
@@ -84,3 +158,13 @@ func TestTable_HasIndex(t *testing.T) {
 		})
 	}
 }
+
+// testTable returns an existing table with indexes.
+func testTable(indexes map[string]*index) *table {
+	tbl := newTable("test_table", nil)
+	if indexes != nil {
+		tbl.state.Store(indexes)
+	}
+
+	return tbl
+}
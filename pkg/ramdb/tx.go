@@ -0,0 +1,204 @@
+package ramdb
+
+import "sort"
+
+// Tx groups writes to one or more tables so they are applied all together or not at all. Commands are buffered until
+// Commit, so a Tx does not see its own writes and other callers never see a partially applied Tx. A Tx is not safe
+// for concurrent use.
+type Tx struct {
+	state *txState
+	table *table
+}
+
+// txState is shared by every Tx returned by From so they commit together.
+type txState struct {
+	db   *database
+	ops  []txOp
+	done bool
+}
+
+// txOp is a buffered command.
+type txOp struct {
+	command
+	table *table
+}
+
+// Begin starts a transaction on the database. Use From to select the table for each command.
+func (db *database) Begin() *Tx {
+	return &Tx{
+		state: &txState{db: db},
+		table: &table{},
+	}
+}
+
+// Begin starts a transaction whose commands run on the table.
+func (t *table) Begin() *Tx {
+	return &Tx{
+		state: &txState{},
+		table: t,
+	}
+}
+
+// Join returns a Tx that runs commands on the table as part of tx, so services holding different tables of a database
+// can write to them in one transaction. Committing or rolling back either Tx commits or rolls back both. The table
+// must be in the same database as the tables tx runs commands on.
+func (t *table) Join(tx *Tx) *Tx {
+	return &Tx{
+		state: tx.state,
+		table: t,
+	}
+}
+
+// From selects a table for running commands in the transaction. The returned Tx shares its commands with tx, so
+// committing or rolling back either one commits or rolls back both. Transactions started from a table can only run
+// commands on that table.
+func (tx *Tx) From(tablename string) *Tx {
+	t := &table{}
+	if tx.state.db != nil {
+		t = tx.state.db.From(tablename)
+	}
+
+	return &Tx{
+		state: tx.state,
+		table: t,
+	}
+}
+
+// Insert adds the Record to the table when the transaction is committed.
+func (tx *Tx) Insert(r *Record) error {
+	return tx.add(command{op: opInsert, record: r})
+}
+
+// Update replaces the Record with the same key in the table when the transaction is committed.
+func (tx *Tx) Update(r *Record) error {
+	return tx.add(command{op: opUpdate, record: r})
+}
+
+// Upsert updates or inserts the Record in the table when the transaction is committed.
+func (tx *Tx) Upsert(r *Record) error {
+	return tx.add(command{op: opUpsert, record: r})
+}
+
+// CompareAndSwap updates the Record with the same key when the transaction is committed, if its version is version.
+func (tx *Tx) CompareAndSwap(r *Record, version uint64) error {
+	return tx.add(command{op: opCompareAndSwap, record: r, version: version})
+}
+
+// Check fails the transaction with ErrNoRecord if the table has no Record with the same key when it is committed, or
+// with ErrVersionMismatch if that Record isn't at version. The Record is left unchanged, so writes to other tables can
+// depend on it without changing its version or being sent to its watchers.
+func (tx *Tx) Check(r *Record, version uint64) error {
+	return tx.add(command{op: opCheck, record: r, version: version})
+}
+
+// Delete removes the Record with the same key from the table when the transaction is committed.
+func (tx *Tx) Delete(r *Record) error {
+	return tx.add(command{op: opDelete, record: r})
+}
+
+func (tx *Tx) add(cmd command) error {
+	if tx.state.done {
+		return ErrTxDone
+	}
+
+	if !tx.table.exists {
+		return ErrNoTable
+	}
+
+	tx.state.ops = append(tx.state.ops, txOp{command: cmd, table: tx.table})
+	return nil
+}
+
+// Commit applies every command in the transaction in the order they were made. If any command fails, for example
+// with ErrRecordExists, none of them are applied and its error is returned. Every table in the transaction is locked
+// while it commits, and the commands are written to the write-ahead log as a single entry.
+func (tx *Tx) Commit() error {
+	state := tx.state
+	if state.done {
+		return ErrTxDone
+	}
+	state.done = true
+
+	if len(state.ops) == 0 {
+		return nil
+	}
+
+	tables := state.tables()
+	for _, t := range tables {
+		t.mutex.Lock()
+		defer t.mutex.Unlock()
+	}
+
+	// Commands are applied to copy-on-write clones of each table's indexes, so a failed command leaves the tables
+	// untouched and later commands see the effects of earlier ones.
+	staged := make(map[*table]map[string]*index, len(tables))
+	for _, t := range tables {
+		staged[t] = t.cloneIndexes()
+	}
+
+	entries := make([]logEntry, 0, len(state.ops))
+	events := make(map[*table][]Event, len(tables))
+	for _, op := range state.ops {
+		if op.op == opCheck {
+			err := check(staged[op.table], op.command)
+			if err != nil {
+				return err
+			}
+
+			continue
+		}
+
+		entry, event, err := execute(staged[op.table], op.command)
+		if err != nil {
+			return err
+		}
+
+		entry.Table = op.table.name
+		entries = append(entries, entry)
+		events[op.table] = append(events[op.table], event)
+	}
+
+	if len(entries) > 0 {
+		err := tables[0].writeLog(logEntry{Op: opBatch, Ops: entries})
+		if err != nil {
+			return err
+		}
+	}
+
+	for t, indexes := range staged {
+		t.state.Store(indexes)
+		t.feed.publish(events[t]...)
+	}
+
+	return nil
+}
+
+// Rollback discards every command in the transaction.
+func (tx *Tx) Rollback() error {
+	if tx.state.done {
+		return ErrTxDone
+	}
+
+	tx.state.done = true
+	tx.state.ops = nil
+	return nil
+}
+
+// tables returns each table with a command in the transaction sorted by name, which is the order tables are locked
+// in to avoid deadlocks.
+func (s *txState) tables() []*table {
+	seen := make(map[*table]bool)
+	var tables []*table
+	for _, op := range s.ops {
+		if !seen[op.table] {
+			seen[op.table] = true
+			tables = append(tables, op.table)
+		}
+	}
+
+	sort.SliceStable(tables, func(a, b int) bool {
+		return tables[a].name < tables[b].name
+	})
+
+	return tables
+}
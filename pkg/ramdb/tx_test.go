@@ -0,0 +1,306 @@
+package ramdb
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTx_Commit(t *testing.T) {
+	tests := []struct {
+		test          string
+		txConfig      func(t *testing.T, db *database) *Tx
+		expectedKeys  map[string][]string
+		expectedError error
+	}{
+		{
+			test: "it should apply inserts and deletes across tables",
+			txConfig: func(t *testing.T, db *database) *Tx {
+				tx := db.Begin()
+				assert.Nil(t, tx.From("table_a").Insert(testRecord(t, "key-2")))
+				assert.Nil(t, tx.From("table_a").Delete(testRecord(t, "key-1")))
+				assert.Nil(t, tx.From("table_b").Insert(testRecord(t, "key-3")))
+				return tx
+			},
+			expectedKeys: map[string][]string{
+				"table_a": {"key-2"},
+				"table_b": {"key-3"},
+			},
+		},
+		{
+			test: "it should see the effects of earlier commands",
+			txConfig: func(t *testing.T, db *database) *Tx {
+				tx := db.From("table_a").Begin()
+				assert.Nil(t, tx.Delete(testRecord(t, "key-1")))
+				assert.Nil(t, tx.Insert(testRecord(t, "key-1")))
+				assert.Nil(t, tx.Insert(testRecord(t, "key-2")))
+				assert.Nil(t, tx.Delete(testRecord(t, "key-2")))
+				return tx
+			},
+			expectedKeys: map[string][]string{
+				"table_a": {"key-1"},
+			},
+		},
+		{
+			test: "it should apply nothing if an insert fails",
+			txConfig: func(t *testing.T, db *database) *Tx {
+				tx := db.Begin()
+				assert.Nil(t, tx.From("table_b").Insert(testRecord(t, "key-2")))
+				assert.Nil(t, tx.From("table_a").Insert(testRecord(t, "key-2")))
+				assert.Nil(t, tx.From("table_a").Insert(testRecord(t, "key-2")))
+				return tx
+			},
+			expectedKeys: map[string][]string{
+				"table_a": {"key-1"},
+			},
+			expectedError: ErrRecordExists,
+		},
+		{
+			test: "it should apply nothing if a delete fails",
+			txConfig: func(t *testing.T, db *database) *Tx {
+				tx := db.From("table_a").Begin()
+				assert.Nil(t, tx.Delete(testRecord(t, "key-1")))
+				assert.Nil(t, tx.Delete(testRecord(t, "key-9")))
+				return tx
+			},
+			expectedKeys: map[string][]string{
+				"table_a": {"key-1"},
+			},
+			expectedError: ErrNoRecord,
+		},
+		{
+			test: "it should apply nothing if a compare and swap fails",
+			txConfig: func(t *testing.T, db *database) *Tx {
+				tx := db.From("table_a").Begin()
+				assert.Nil(t, tx.Insert(testRecord(t, "key-2")))
+				assert.Nil(t, tx.CompareAndSwap(testRecord(t, "key-1"), 2))
+				return tx
+			},
+			expectedKeys: map[string][]string{
+				"table_a": {"key-1"},
+			},
+			expectedError: ErrVersionMismatch,
+		},
+		{
+			test: "it should return ErrTxDone if the transaction was rolled back",
+			txConfig: func(t *testing.T, db *database) *Tx {
+				tx := db.From("table_a").Begin()
+				assert.Nil(t, tx.Insert(testRecord(t, "key-2")))
+				assert.Nil(t, tx.Rollback())
+				return tx
+			},
+			expectedKeys: map[string][]string{
+				"table_a": {"key-1"},
+			},
+			expectedError: ErrTxDone,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			db := NewDatabase()
+			db.CreateTable("table_a", "test_column")
+			db.CreateTable("table_b", "test_column")
+			db.From("table_a").Insert(testRecord(t, "key-1"))
+
+			tx := tc.txConfig(t, db)
+			err := tx.Commit()
+
+			assert.Equal(t, tc.expectedError, err)
+			for tablename, expectedKeys := range tc.expectedKeys {
+				rr, _ := db.From(tablename).Select("test_column")
+
+				var keys []string
+				for _, r := range rr {
+					keys = append(keys, r.key)
+				}
+
+				assert.ElementsMatch(t, expectedKeys, keys)
+			}
+		})
+	}
+}
+
+func TestTx_Commands(t *testing.T) {
+	tests := []struct {
+		test          string
+		txConfig      func(t *testing.T, db *database) *Tx
+		expectedError error
+	}{
+		{
+			test: "it should return ErrNoTable if the table does not exist",
+			txConfig: func(t *testing.T, db *database) *Tx {
+				return db.Begin().From("missing_table")
+			},
+			expectedError: ErrNoTable,
+		},
+		{
+			test: "it should return ErrNoTable if no table was selected",
+			txConfig: func(t *testing.T, db *database) *Tx {
+				return db.Begin()
+			},
+			expectedError: ErrNoTable,
+		},
+		{
+			test: "it should return ErrNoTable for other tables in a transaction started from a table",
+			txConfig: func(t *testing.T, db *database) *Tx {
+				return db.From("test_table").Begin().From("test_table")
+			},
+			expectedError: ErrNoTable,
+		},
+		{
+			test: "it should return ErrTxDone if the transaction was committed",
+			txConfig: func(t *testing.T, db *database) *Tx {
+				tx := db.From("test_table").Begin()
+				assert.Nil(t, tx.Commit())
+				return tx
+			},
+			expectedError: ErrTxDone,
+		},
+		{
+			test: "it should buffer the command",
+			txConfig: func(t *testing.T, db *database) *Tx {
+				return db.From("test_table").Begin()
+			},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			db := NewDatabase()
+			db.CreateTable("test_table", "test_column")
+
+			tx := tc.txConfig(t, db)
+			err := tx.Insert(testRecord(t, "key-1"))
+
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestTable_Join(t *testing.T) {
+	t.Run("it should commit commands on a joined table with the transaction", func(t *testing.T) {
+		db := NewDatabase()
+		db.CreateTable("test_table", "test_column")
+		db.CreateTable("other_table", "test_column")
+		assert.Nil(t, db.From("other_table").Insert(testRecord(t, "key-1")))
+
+		tx := db.From("test_table").Begin()
+		assert.Nil(t, tx.Insert(testRecord(t, "key-1")))
+		assert.Nil(t, db.From("other_table").Join(tx).Insert(testRecord(t, "key-1")))
+		assert.Equal(t, ErrRecordExists, tx.Commit())
+
+		_, err := db.From("test_table").Get("test_column", "key-1")
+		assert.Equal(t, ErrNoRecord, err)
+
+		tx = db.From("test_table").Begin()
+		assert.Nil(t, tx.Insert(testRecord(t, "key-1")))
+		assert.Nil(t, db.From("other_table").Join(tx).Delete(testRecord(t, "key-1")))
+		assert.Nil(t, tx.Commit())
+
+		_, err = db.From("test_table").Get("test_column", "key-1")
+		assert.Nil(t, err)
+		_, err = db.From("other_table").Get("test_column", "key-1")
+		assert.Equal(t, ErrNoRecord, err)
+	})
+}
+
+func TestTx_Check(t *testing.T) {
+	tests := []struct {
+		test          string
+		key           string
+		version       uint64
+		expectedError error
+	}{
+		{
+			test:    "it should commit if the record is at the version",
+			key:     "key-1",
+			version: 1,
+		},
+		{
+			test:          "it should return ErrVersionMismatch if the record is at another version",
+			key:           "key-1",
+			version:       2,
+			expectedError: ErrVersionMismatch,
+		},
+		{
+			test:          "it should return ErrNoRecord if the record does not exist",
+			key:           "key-2",
+			version:       1,
+			expectedError: ErrNoRecord,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			db := NewDatabase()
+			db.CreateTable("test_table", "test_column")
+			db.CreateTable("other_table", "test_column")
+			assert.Nil(t, db.From("test_table").Insert(testRecord(t, "key-1")))
+
+			tx := db.From("other_table").Begin()
+			assert.Nil(t, tx.Insert(testRecord(t, "key-1")))
+			assert.Nil(t, db.From("test_table").Join(tx).Check(testRecord(t, tc.key), tc.version))
+			assert.Equal(t, tc.expectedError, tx.Commit())
+
+			_, err := db.From("other_table").Get("test_column", "key-1")
+			if tc.expectedError == nil {
+				assert.Nil(t, err)
+			} else {
+				assert.Equal(t, ErrNoRecord, err)
+			}
+
+			// Checking leaves the record as it was.
+			rec, err := db.From("test_table").Get("test_column", "key-1")
+			assert.Nil(t, err)
+			assert.Equal(t, uint64(1), rec.Version())
+		})
+	}
+}
+
+func TestTx_Commit_WriteAheadLog(t *testing.T) {
+	t.Run("it should replay committed transactions", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ramdb.wal")
+
+		db, err := OpenDatabase(path, SyncAlways, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.CreateTable("table_a", "test_column")
+		db.CreateTable("table_b", "test_column")
+
+		tx := db.Begin()
+		tx.From("table_a").Insert(testRecord(t, "key-1"))
+		tx.From("table_b").Insert(testRecord(t, "key-2"))
+		assert.Nil(t, tx.Commit())
+
+		tx = db.Begin()
+		tx.From("table_a").Insert(testRecord(t, "key-3"))
+		tx.Rollback()
+		assert.Nil(t, db.Close())
+
+		db, err = OpenDatabase(path, SyncAlways, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+
+		_, err = db.From("table_a").Get("test_column", "key-1")
+		assert.Nil(t, err)
+		_, err = db.From("table_b").Get("test_column", "key-2")
+		assert.Nil(t, err)
+		_, err = db.From("table_a").Get("test_column", "key-3")
+		assert.Equal(t, ErrNoRecord, err)
+	})
+}
+
+// testRecord returns an empty Record with key in test_column.
+func testRecord(t *testing.T, key string) *Record {
+	rec, err := NewRecord(key, "test_column", struct{}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return rec
+}
@@ -0,0 +1,323 @@
+package ramdb
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// SyncPolicy controls how often the write-ahead log is fsynced to disk.
+type SyncPolicy int
+
+const (
+	// SyncAlways fsyncs the log after every write. No acknowledged write is lost on a crash.
+	SyncAlways SyncPolicy = iota
+	// SyncInterval fsyncs the log on a timer. At most one interval of writes can be lost on a crash.
+	SyncInterval
+	// SyncNever leaves flushing the log to the operating system.
+	SyncNever
+)
+
+const (
+	opCreateTable = "create_table"
+	opCreateIndex = "create_index"
+	opInsert      = "insert"
+	opUpdate      = "update"
+	opDelete      = "delete"
+	opBatch       = "batch"
+
+	// opUpsert and opCompareAndSwap are only used by commands. They are logged as an insert or an update.
+	opUpsert         = "upsert"
+	opCompareAndSwap = "compare_and_swap"
+	// opCheck is only used by transactions, and is not logged as it changes nothing.
+	opCheck = "check"
+
+	// frameHeaderSize is the length prefix and checksum written before every log entry.
+	frameHeaderSize = 8
+	// maxFrameSize is the longest entry the log holds. A longer length in a frame header can only be corruption.
+	maxFrameSize = 256 << 20
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// ParseSyncPolicy converts always, interval, or never into a SyncPolicy.
+func ParseSyncPolicy(s string) (SyncPolicy, error) {
+	switch s {
+	case "always", "":
+		return SyncAlways, nil
+	case "interval":
+		return SyncInterval, nil
+	case "never":
+		return SyncNever, nil
+	}
+
+	return 0, ErrInvalidSyncPolicy
+}
+
+// logEntry is a single mutation recorded in the write-ahead log.
+type logEntry struct {
+	Op      string            `json:"op"`
+	Table   string            `json:"table"`
+	Column  string            `json:"column,omitempty"`
+	Key     string            `json:"key,omitempty"`
+	Keys    map[string]string `json:"keys,omitempty"`
+	Ordered bool              `json:"ordered,omitempty"`
+	Text    bool              `json:"text,omitempty"`
+	Data    []byte            `json:"data,omitempty"`
+	Version uint64            `json:"version,omitempty"`
+	Ops     []logEntry        `json:"ops,omitempty"`
+}
+
+// writeAheadLog is an append-only file of checksummed logEntry frames.
+type writeAheadLog struct {
+	mutex  sync.Mutex
+	file   *os.File
+	policy SyncPolicy
+	dirty  bool
+	done   chan struct{}
+	stop   sync.Once
+	wg     sync.WaitGroup
+}
+
+// OpenDatabase opens the write-ahead log at path, creating it if needed, and replays it into a new database. Every
+// table, index, Insert and Delete applied to the returned database is appended to the log before it is applied.
+func OpenDatabase(path string, policy SyncPolicy, interval time.Duration) (*database, error) {
+	if policy == SyncInterval && interval <= 0 {
+		return nil, ErrInvalidSyncPolicy
+	}
+
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	db := NewDatabase()
+	err = db.replay(f)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	log := &writeAheadLog{
+		file:   f,
+		policy: policy,
+		done:   make(chan struct{}),
+	}
+
+	if policy == SyncInterval {
+		log.wg.Add(1)
+		go log.syncEvery(interval)
+	}
+
+	db.log = log
+	for _, t := range db.tables {
+		t.log = log
+	}
+
+	return db, nil
+}
+
+// replay applies every complete entry in the log to db. A partially written or corrupt entry at the end of the log is
+// the result of a crash mid-write, and is truncated so new entries are appended after the last complete one.
+func (db *database) replay(f *os.File) error {
+	r := bufio.NewReader(f)
+	var offset int64
+
+	for {
+		entry, n, err := readFrame(r)
+		if err == io.EOF {
+			break
+		}
+
+		if err == io.ErrUnexpectedEOF || err == ErrCorruptLog && atEOF(r) {
+			err = f.Truncate(offset)
+			if err != nil {
+				return err
+			}
+			break
+		}
+
+		if err != nil {
+			return err
+		}
+
+		err = db.apply(entry)
+		if err != nil {
+			return err
+		}
+
+		offset += n
+	}
+
+	_, err := f.Seek(offset, io.SeekStart)
+	return err
+}
+
+// apply performs the mutation described by entry without logging it.
+func (db *database) apply(entry logEntry) error {
+	switch entry.Op {
+	case opCreateTable:
+		return db.CreateTable(entry.Table)
+	case opBatch:
+		for _, op := range entry.Ops {
+			err := db.apply(op)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	t := db.From(entry.Table)
+	switch entry.Op {
+	case opCreateIndex:
+		return t.createIndex(entry.Column, entry.Ordered, entry.Text)
+	case opInsert:
+		r := newRecordFromSerialized(entry.Key, entry.Column, entry.Data)
+		r.keys = entry.Keys
+		return t.write(command{op: opInsert, record: r, version: entry.Version})
+	case opUpdate:
+		r := newRecordFromSerialized(entry.Key, entry.Column, entry.Data)
+		r.keys = entry.Keys
+		return t.Update(r)
+	case opDelete:
+		return t.Delete(newRecordFromSerialized(entry.Key, entry.Column, entry.Data))
+	}
+
+	return ErrCorruptLog
+}
+
+// atEOF returns true if nothing is left to read from r.
+func atEOF(r *bufio.Reader) bool {
+	_, err := r.Peek(1)
+	return err == io.EOF
+}
+
+// readFrame reads one entry from r and returns it with the number of bytes consumed. It returns ErrCorruptLog without
+// reading the payload if the header gives a length over maxFrameSize.
+func readFrame(r io.Reader) (entry logEntry, n int64, err error) {
+	var header [frameHeaderSize]byte
+	_, err = io.ReadFull(r, header[:])
+	if err != nil {
+		return
+	}
+
+	length := binary.BigEndian.Uint32(header[:4])
+	checksum := binary.BigEndian.Uint32(header[4:])
+	if length > maxFrameSize {
+		err = ErrCorruptLog
+		return
+	}
+
+	payload := make([]byte, length)
+	_, err = io.ReadFull(r, payload)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return
+	}
+
+	if crc32.Checksum(payload, castagnoli) != checksum {
+		err = ErrCorruptLog
+		return
+	}
+
+	err = json.Unmarshal(payload, &entry)
+	if err != nil {
+		err = ErrCorruptLog
+		return
+	}
+
+	n = int64(frameHeaderSize + length)
+	return
+}
+
+// append writes entry to the end of the log and fsyncs it when the policy is SyncAlways.
+func (l *writeAheadLog) append(entry logEntry) error {
+	payload, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	if len(payload) > maxFrameSize {
+		return ErrLogEntryTooLarge
+	}
+
+	frame := make([]byte, frameHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(frame[:4], uint32(len(payload)))
+	binary.BigEndian.PutUint32(frame[4:8], crc32.Checksum(payload, castagnoli))
+	copy(frame[frameHeaderSize:], payload)
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.file == nil {
+		return ErrLogClosed
+	}
+
+	_, err = l.file.Write(frame)
+	if err != nil {
+		return err
+	}
+
+	if l.policy == SyncAlways {
+		return l.file.Sync()
+	}
+
+	l.dirty = true
+	return nil
+}
+
+// syncEvery fsyncs the log each interval until the log is closed.
+func (l *writeAheadLog) syncEvery(interval time.Duration) {
+	defer l.wg.Done()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			l.mutex.Lock()
+			if l.dirty && l.file != nil {
+				l.file.Sync()
+				l.dirty = false
+			}
+			l.mutex.Unlock()
+		case <-l.done:
+			return
+		}
+	}
+}
+
+// close stops the sync timer, fsyncs any outstanding writes and closes the file.
+func (l *writeAheadLog) close() error {
+	l.stop.Do(func() {
+		close(l.done)
+	})
+	l.wg.Wait()
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.file == nil {
+		return ErrLogClosed
+	}
+
+	syncErr := l.file.Sync()
+	closeErr := l.file.Close()
+	l.file = nil
+
+	if syncErr != nil {
+		return syncErr
+	}
+
+	return closeErr
+}
@@ -0,0 +1,337 @@
+package ramdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSyncPolicy(t *testing.T) {
+	tests := []struct {
+		test           string
+		input          string
+		expectedPolicy SyncPolicy
+		expectedError  error
+	}{
+		{
+			test:           "it should default to SyncAlways",
+			input:          "",
+			expectedPolicy: SyncAlways,
+		},
+		{
+			test:           "it should parse always",
+			input:          "always",
+			expectedPolicy: SyncAlways,
+		},
+		{
+			test:           "it should parse interval",
+			input:          "interval",
+			expectedPolicy: SyncInterval,
+		},
+		{
+			test:           "it should parse never",
+			input:          "never",
+			expectedPolicy: SyncNever,
+		},
+		{
+			test:          "it should return ErrInvalidSyncPolicy for unknown policies",
+			input:         "sometimes",
+			expectedError: ErrInvalidSyncPolicy,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			policy, err := ParseSyncPolicy(tc.input)
+
+			assert.Equal(t, tc.expectedPolicy, policy)
+			assert.Equal(t, tc.expectedError, err)
+		})
+	}
+}
+
+func TestOpenDatabase(t *testing.T) {
+	tests := []struct {
+		test          string
+		policy        SyncPolicy
+		interval      time.Duration
+		logConfig     func(t *testing.T, path string)
+		expectedKeys  []string
+		expectedError error
+	}{
+		{
+			test:         "it should open an empty database when no log exists",
+			policy:       SyncAlways,
+			logConfig:    func(t *testing.T, path string) {},
+			expectedKeys: nil,
+		},
+		{
+			test:          "it should return ErrInvalidSyncPolicy if interval syncing has no interval",
+			policy:        SyncInterval,
+			interval:      0,
+			logConfig:     func(t *testing.T, path string) {},
+			expectedError: ErrInvalidSyncPolicy,
+		},
+		{
+			test:   "it should replay inserts and deletes",
+			policy: SyncAlways,
+			logConfig: func(t *testing.T, path string) {
+				writeTestLog(t, path, SyncAlways, 0)
+			},
+			expectedKeys: []string{"key-2"},
+		},
+		{
+			test:     "it should replay a log written with interval syncing",
+			policy:   SyncInterval,
+			interval: time.Millisecond,
+			logConfig: func(t *testing.T, path string) {
+				writeTestLog(t, path, SyncInterval, time.Millisecond)
+			},
+			expectedKeys: []string{"key-2"},
+		},
+		{
+			test:   "it should discard a partially written entry at the end of the log",
+			policy: SyncNever,
+			logConfig: func(t *testing.T, path string) {
+				writeTestLog(t, path, SyncNever, 0)
+
+				f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+				defer f.Close()
+
+				_, err = f.Write([]byte{0x00, 0x00, 0x00, 0x40, 0x01})
+				if err != nil {
+					t.Fatal(err)
+				}
+			},
+			expectedKeys: []string{"key-2"},
+		},
+		{
+			test:   "it should return ErrCorruptLog if an entry fails its checksum",
+			policy: SyncAlways,
+			logConfig: func(t *testing.T, path string) {
+				writeTestLog(t, path, SyncAlways, 0)
+
+				b, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				b[frameHeaderSize+2] ^= 0xff
+				err = os.WriteFile(path, b, 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			},
+			expectedError: ErrCorruptLog,
+		},
+		{
+			test:   "it should discard an entry at the end of the log that fails its checksum",
+			policy: SyncAlways,
+			logConfig: func(t *testing.T, path string) {
+				writeTestLog(t, path, SyncAlways, 0)
+
+				b, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				b[len(b)-2] ^= 0xff
+				err = os.WriteFile(path, b, 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			},
+			expectedKeys: []string{"key-2", "key-1"},
+		},
+		{
+			test:   "it should return ErrCorruptLog if an entry is longer than the longest entry",
+			policy: SyncAlways,
+			logConfig: func(t *testing.T, path string) {
+				writeTestLog(t, path, SyncAlways, 0)
+
+				b, err := os.ReadFile(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				binary.BigEndian.PutUint32(b[:4], maxFrameSize+1)
+				err = os.WriteFile(path, b, 0644)
+				if err != nil {
+					t.Fatal(err)
+				}
+			},
+			expectedError: ErrCorruptLog,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.test, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "ramdb.wal")
+			tc.logConfig(t, path)
+
+			db, err := OpenDatabase(path, tc.policy, tc.interval)
+
+			assert.Equal(t, tc.expectedError, err)
+			if err != nil {
+				return
+			}
+			defer db.Close()
+
+			var keys []string
+			rr, _ := db.From("test_table").Select("test_column")
+			for _, r := range rr {
+				keys = append(keys, r.key)
+			}
+
+			assert.Equal(t, tc.expectedKeys, keys)
+		})
+	}
+}
+
+func TestOpenDatabase_AppendsAfterReplay(t *testing.T) {
+	t.Run("it should keep logging writes made after a replay", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ramdb.wal")
+		writeTestLog(t, path, SyncAlways, 0)
+
+		db, err := OpenDatabase(path, SyncAlways, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, ErrTableExists, db.CreateTable("test_table"))
+
+		rec, err := NewRecord("key-3", "test_column", struct{}{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Nil(t, db.From("test_table").Insert(rec))
+		assert.Nil(t, db.Close())
+
+		db, err = OpenDatabase(path, SyncAlways, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+
+		_, err = db.From("test_table").Get("test_column", "key-3")
+		assert.Nil(t, err)
+
+		_, err = db.From("test_table").Get("test_column", "key-2")
+		assert.Nil(t, err)
+	})
+}
+
+func TestOpenDatabase_FailedCreateTable(t *testing.T) {
+	t.Run("it should replay a table created after a failed attempt", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ramdb.wal")
+
+		db, err := OpenDatabase(path, SyncAlways, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		assert.Equal(t, ErrIndexExists, db.CreateTable("test_table", "test_column", "test_column"))
+		assert.False(t, db.HasTable("test_table"))
+		assert.Nil(t, db.CreateTable("test_table", "test_column"))
+
+		rec, err := NewRecord("key-1", "test_column", struct{}{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Nil(t, db.From("test_table").Insert(rec))
+		assert.Nil(t, db.Close())
+
+		db, err = OpenDatabase(path, SyncAlways, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+
+		_, err = db.From("test_table").Get("test_column", "key-1")
+		assert.Nil(t, err)
+	})
+}
+
+// writeTestLog writes a log creating test_table, inserting key-1 and key-2, and deleting key-1.
+func writeTestLog(t *testing.T, path string, policy SyncPolicy, interval time.Duration) {
+	db, err := OpenDatabase(path, policy, interval)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.CreateTable("test_table", "test_column")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, key := range []string{"key-1", "key-2"} {
+		rec, err := NewRecord(key, "test_column", struct{ Key string }{Key: key})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = db.From("test_table").Insert(rec)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rec, err := NewRecord("key-1", "test_column", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.From("test_table").Delete(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOpenDatabase_Versions(t *testing.T) {
+	t.Run("it should replay updates and restore Record versions", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ramdb.wal")
+
+		db, err := OpenDatabase(path, SyncAlways, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		db.CreateTable("test_table", "test_column")
+		tbl := db.From("test_table")
+		assert.Nil(t, tbl.Insert(testRecord(t, "key-1")))
+		assert.Nil(t, tbl.Update(testRecord(t, "key-1")))
+		assert.Nil(t, tbl.Upsert(testRecord(t, "key-1")))
+		assert.Nil(t, db.Close())
+
+		db, err = OpenDatabase(path, SyncAlways, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer db.Close()
+
+		r, err := db.From("test_table").Get("test_column", "key-1")
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(3), r.Version())
+
+		var buf bytes.Buffer
+		assert.Nil(t, db.Snapshot(&buf))
+		restored, err := LoadSnapshot(&buf)
+		assert.Nil(t, err)
+
+		r, err = restored.From("test_table").Get("test_column", "key-1")
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(3), r.Version())
+	})
+}
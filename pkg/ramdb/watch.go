@@ -0,0 +1,239 @@
+package ramdb
+
+import (
+	"context"
+	"sync"
+)
+
+const (
+	EventInsert = "insert"
+	EventUpdate = "update"
+	EventDelete = "delete"
+)
+
+const (
+	// DefaultWatchBuffer is the number of undelivered events a Watcher holds when WatchOptions.Buffer is 0.
+	DefaultWatchBuffer = 256
+	// WatchHistory is the number of a table's most recent events kept for watchers resuming from a sequence number.
+	WatchHistory = 1024
+)
+
+// Event is a change to a Record in a table.
+type Event struct {
+	// Seq is the position of the event in the table's changes. It starts at 1 and goes up by one for every change,
+	// including each change in a committed transaction. Sequence numbers are not persisted, so they start again at 1
+	// whenever the database is opened.
+	Seq   uint64
+	Op    string
+	Table string
+	Key   string
+	// Before is the Record as it was before an update or delete, and is nil for an insert.
+	Before *Record
+	// After is the Record as it is after an insert or update, and is nil for a delete.
+	After *Record
+}
+
+// WatchOptions configures a Watcher.
+type WatchOptions struct {
+	// From is the sequence number of the first event to deliver, to resume after the last event a previous Watcher
+	// delivered. It must be no older than the last WatchHistory events. A From of 0 only delivers new events.
+	From uint64
+	// Buffer is the most events held for a Watcher that has not received them. A Buffer of 0 uses
+	// DefaultWatchBuffer.
+	Buffer int
+}
+
+// Watcher delivers the events of a table in sequence order.
+type Watcher struct {
+	events chan Event
+	notify chan struct{}
+	feed   *feed
+	limit  int
+
+	// replay holds the events from before the Watcher subscribed that it resumes from. They are sent before queue and
+	// don't count towards limit.
+	replay []Event
+
+	mutex sync.Mutex
+	queue []Event
+	err   error
+}
+
+// Watch returns a Watcher delivering every change made to the table from opts.From, or from now if it is 0, until ctx
+// is done. A Watcher that falls more than opts.Buffer events behind is stopped with ErrWatchLagged, and can be
+// resumed from the sequence number after the last event it delivered. It returns ErrSequenceUnavailable if opts.From
+// is older than the table's history or newer than its next event.
+func (t *table) Watch(ctx context.Context, opts WatchOptions) (*Watcher, error) {
+	if !t.exists {
+		return nil, ErrNoTable
+	}
+
+	limit := opts.Buffer
+	if limit <= 0 {
+		limit = DefaultWatchBuffer
+	}
+
+	w := &Watcher{
+		events: make(chan Event),
+		notify: make(chan struct{}, 1),
+		feed:   t.feed,
+		limit:  limit,
+	}
+
+	err := t.feed.subscribe(w, opts.From)
+	if err != nil {
+		return nil, err
+	}
+
+	go w.run(ctx)
+	return w, nil
+}
+
+// Events returns the channel events are delivered on. It is closed when the Watcher stops.
+func (w *Watcher) Events() <-chan Event {
+	return w.events
+}
+
+// Err returns why the Watcher stopped once Events is closed: the context's error, or ErrWatchLagged if it fell too
+// far behind.
+func (w *Watcher) Err() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	return w.err
+}
+
+// run delivers queued events until ctx is done or the Watcher lags, then closes Events.
+func (w *Watcher) run(ctx context.Context) {
+	defer close(w.events)
+
+	queue, err := w.replay, error(nil)
+	w.replay = nil
+	for {
+		w.mutex.Lock()
+		queue, err = append(queue, w.queue...), w.err
+		w.queue = nil
+		w.mutex.Unlock()
+
+		for _, e := range queue {
+			select {
+			case w.events <- e:
+			case <-ctx.Done():
+				w.stop(ctx.Err())
+				return
+			}
+		}
+
+		// A lagged Watcher has already been unsubscribed, and stops once the events queued before it lagged are sent.
+		if err != nil {
+			return
+		}
+		queue = queue[:0]
+
+		select {
+		case <-w.notify:
+		case <-ctx.Done():
+			w.stop(ctx.Err())
+			return
+		}
+	}
+}
+
+// stop unsubscribes the Watcher and records err as why it stopped.
+func (w *Watcher) stop(err error) {
+	w.feed.unsubscribe(w)
+
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.err == nil {
+		w.err = err
+	}
+}
+
+// enqueue queues e for delivery, and returns false if the Watcher has lagged. The caller must hold the feed's mutex.
+func (w *Watcher) enqueue(e Event) bool {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if len(w.queue) >= w.limit {
+		w.err = ErrWatchLagged
+	} else {
+		w.queue = append(w.queue, e)
+	}
+
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+
+	return w.err == nil
+}
+
+// feed numbers a table's events, keeps its recent history and hands events to its Watchers.
+type feed struct {
+	table string
+
+	mutex    sync.Mutex
+	seq      uint64
+	history  []Event
+	watchers map[*Watcher]struct{}
+}
+
+// newFeed returns the feed of t's events.
+func newFeed(t *table) *feed {
+	return &feed{
+		table:    t.name,
+		watchers: make(map[*Watcher]struct{}),
+	}
+}
+
+// publish numbers the events and hands them to every Watcher. The caller must hold the table's mutex, so events are
+// published in the order they were applied.
+func (f *feed) publish(events ...Event) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	for _, e := range events {
+		f.seq++
+		e.Seq = f.seq
+		e.Table = f.table
+
+		f.history = append(f.history, e)
+		if len(f.history) > WatchHistory {
+			f.history = f.history[1:]
+		}
+
+		for w := range f.watchers {
+			if !w.enqueue(e) {
+				delete(f.watchers, w)
+			}
+		}
+	}
+}
+
+// subscribe adds w to the feed, with the events from sequence number from queued for it if from is not 0.
+func (f *feed) subscribe(w *Watcher, from uint64) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if from != 0 {
+		oldest := f.seq + 1 - uint64(len(f.history))
+		if from < oldest || from > f.seq+1 {
+			return ErrSequenceUnavailable
+		}
+
+		w.replay = append(w.replay, f.history[from-oldest:]...)
+	}
+
+	f.watchers[w] = struct{}{}
+	return nil
+}
+
+// unsubscribe removes w from the feed.
+func (f *feed) unsubscribe(w *Watcher) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	delete(f.watchers, w)
+}
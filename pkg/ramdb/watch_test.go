@@ -0,0 +1,206 @@
+package ramdb
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newWatchTable returns an empty table keyed by "key_column".
+func newWatchTable(t *testing.T) *table {
+	db := NewDatabase()
+	assert.Nil(t, db.CreateTable("test_table", "key_column"))
+	return db.From("test_table")
+}
+
+// insertKeys inserts a Record for each key into tbl.
+func insertKeys(t *testing.T, tbl *table, keys ...string) {
+	for _, key := range keys {
+		rec, err := NewRecord(key, "key_column", key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Nil(t, tbl.Insert(rec))
+	}
+}
+
+// receive returns the next n events from w, failing the test if they don't arrive in time.
+func receive(t *testing.T, w *Watcher, n int) (events []Event) {
+	for len(events) < n {
+		select {
+		case e, ok := <-w.Events():
+			if !ok {
+				t.Fatalf("watcher stopped after %d events: %v", len(events), w.Err())
+			}
+			events = append(events, e)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out after %d events", len(events))
+		}
+	}
+
+	return
+}
+
+// drain reads from w until its Events are closed, failing the test if they aren't closed in time.
+func drain(t *testing.T, w *Watcher) (events []Event) {
+	for {
+		select {
+		case e, ok := <-w.Events():
+			if !ok {
+				return
+			}
+			events = append(events, e)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for the watcher to stop")
+		}
+	}
+}
+
+func TestTable_Watch(t *testing.T) {
+	t.Run("it should deliver inserts, updates and deletes in order", func(t *testing.T) {
+		tbl := newWatchTable(t)
+
+		w, err := tbl.Watch(context.Background(), WatchOptions{})
+		assert.Nil(t, err)
+
+		insertKeys(t, tbl, "a")
+		rec, _ := NewRecord("a", "key_column", "changed")
+		assert.Nil(t, tbl.Update(rec))
+		assert.Nil(t, tbl.Delete(rec))
+
+		// A failed write changes nothing, so it has no event.
+		assert.Equal(t, ErrNoRecord, tbl.Delete(rec))
+		insertKeys(t, tbl, "b")
+
+		events := receive(t, w, 4)
+
+		var ops []string
+		for i, e := range events {
+			assert.Equal(t, uint64(i+1), e.Seq)
+			assert.Equal(t, "test_table", e.Table)
+			ops = append(ops, e.Op)
+		}
+		assert.Equal(t, []string{EventInsert, EventUpdate, EventDelete, EventInsert}, ops)
+
+		assert.Nil(t, events[0].Before)
+		assert.Equal(t, uint64(1), events[0].After.Version())
+
+		var before, after string
+		assert.Nil(t, events[1].Before.Deserialize(&before))
+		assert.Nil(t, events[1].After.Deserialize(&after))
+		assert.Equal(t, "a", before)
+		assert.Equal(t, "changed", after)
+		assert.Equal(t, uint64(2), events[1].After.Version())
+
+		assert.Equal(t, "a", events[2].Key)
+		assert.Nil(t, events[2].After)
+		assert.Nil(t, events[2].Before.Deserialize(&before))
+		assert.Equal(t, "changed", before)
+	})
+
+	t.Run("it should deliver each change of a committed transaction", func(t *testing.T) {
+		tbl := newWatchTable(t)
+
+		w, err := tbl.Watch(context.Background(), WatchOptions{})
+		assert.Nil(t, err)
+
+		tx := tbl.Begin()
+		for _, key := range []string{"a", "b"} {
+			rec, _ := NewRecord(key, "key_column", key)
+			assert.Nil(t, tx.Insert(rec))
+		}
+		assert.Nil(t, tx.Commit())
+
+		tx = tbl.Begin()
+		rec, _ := NewRecord("c", "key_column", "c")
+		assert.Nil(t, tx.Insert(rec))
+		tx.Rollback()
+
+		insertKeys(t, tbl, "d")
+
+		var keys []string
+		for _, e := range receive(t, w, 3) {
+			keys = append(keys, e.Key)
+		}
+		assert.Equal(t, []string{"a", "b", "d"}, keys)
+	})
+
+	t.Run("it should resume from a sequence number", func(t *testing.T) {
+		tbl := newWatchTable(t)
+		insertKeys(t, tbl, "a", "b", "c")
+
+		w, err := tbl.Watch(context.Background(), WatchOptions{From: 2})
+		assert.Nil(t, err)
+
+		insertKeys(t, tbl, "d")
+
+		var keys []string
+		for _, e := range receive(t, w, 3) {
+			keys = append(keys, e.Key)
+		}
+		assert.Equal(t, []string{"b", "c", "d"}, keys)
+	})
+
+	t.Run("it should return ErrSequenceUnavailable for sequence numbers outside the history", func(t *testing.T) {
+		tbl := newWatchTable(t)
+		for i := 0; i < WatchHistory+1; i++ {
+			insertKeys(t, tbl, fmt.Sprintf("key-%d", i))
+		}
+
+		_, err := tbl.Watch(context.Background(), WatchOptions{From: 1})
+		assert.Equal(t, ErrSequenceUnavailable, err)
+
+		_, err = tbl.Watch(context.Background(), WatchOptions{From: WatchHistory + 3})
+		assert.Equal(t, ErrSequenceUnavailable, err)
+
+		_, err = tbl.Watch(context.Background(), WatchOptions{From: 2})
+		assert.Nil(t, err)
+
+		_, err = tbl.Watch(context.Background(), WatchOptions{From: WatchHistory + 2})
+		assert.Nil(t, err)
+	})
+
+	t.Run("it should stop a watcher that falls too far behind", func(t *testing.T) {
+		tbl := newWatchTable(t)
+
+		w, err := tbl.Watch(context.Background(), WatchOptions{Buffer: 2})
+		assert.Nil(t, err)
+
+		for i := 0; i < 10; i++ {
+			insertKeys(t, tbl, fmt.Sprintf("key-%d", i))
+		}
+
+		events := drain(t, w)
+		assert.Equal(t, ErrWatchLagged, w.Err())
+		assert.True(t, len(events) < 10)
+
+		// The events it missed can be resumed from the last one it delivered.
+		last := events[len(events)-1].Seq
+		w, err = tbl.Watch(context.Background(), WatchOptions{From: last + 1})
+		assert.Nil(t, err)
+		assert.Equal(t, last+1, receive(t, w, 1)[0].Seq)
+	})
+
+	t.Run("it should stop when the context is done", func(t *testing.T) {
+		tbl := newWatchTable(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		w, err := tbl.Watch(ctx, WatchOptions{})
+		assert.Nil(t, err)
+
+		cancel()
+		assert.Empty(t, drain(t, w))
+		assert.Equal(t, context.Canceled, w.Err())
+
+		// Writes after it stopped must not block or panic.
+		insertKeys(t, tbl, "a")
+	})
+
+	t.Run("it should return ErrNoTable if the table does not exist", func(t *testing.T) {
+		_, err := NewDatabase().From("missing").Watch(context.Background(), WatchOptions{})
+		assert.Equal(t, ErrNoTable, err)
+	})
+}